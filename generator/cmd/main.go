@@ -1,142 +1,771 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/darkphotonKN/go-template-generator/internal/config"
 	"github.com/darkphotonKN/go-template-generator/internal/ddd"
+	"github.com/darkphotonKN/go-template-generator/internal/golden"
+	"github.com/darkphotonKN/go-template-generator/internal/manifest"
+	"github.com/darkphotonKN/go-template-generator/internal/mcp"
+	"github.com/darkphotonKN/go-template-generator/internal/output"
+	"github.com/darkphotonKN/go-template-generator/internal/schema"
+	"github.com/darkphotonKN/go-template-generator/internal/wizard"
 	"github.com/spf13/cobra"
 )
 
 var (
 	// Flags
-	entity       string
+	entities     []string
 	noAuth       bool
 	withS3       bool
 	withFrontend bool
 	description  string
+	configPath   string
+	templateDir  string
+	projectDir   string
+	fields       string
+	schemaFile   string
+	fromSQL      string
+	fromOpenAPI  string
+	relations    []string
+	interactive  bool
+	answersFile  string
+	saveAnswers  string
+	verify       bool
+	fixturesDir  string
+	update       bool
+	outputFormat string
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "go-gen",
 	Short: "Go Template Generator - Create production-ready Go projects",
 	Long:  `A powerful Go project generator that creates production-ready applications with consistent structure, best practices, and pre-configured development environment.`,
+
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		_, err := output.NewPrinter(outputFormat, os.Stdout, os.Stderr)
+		return err
+	},
 }
 
 var createCmd = &cobra.Command{
 	Use:   "create [project-name]",
 	Short: "Create a new DDD API project",
-	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		projectName := args[0]
+	Long: `Create a new DDD API project. With -i, or without a project name on a
+terminal, each option is asked for, defaulting to the flags and config.yaml,
+and the result is confirmed before anything is written.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: run(func(cmd *cobra.Command, args []string) (output.Result, error) {
+		var projectName string
+		if len(args) == 1 {
+			projectName = args[0]
+		}
+		interactive = interactive || (projectName == "" && answersFile == "" && wizard.IsTerminal(os.Stdin))
+		if projectName == "" && answersFile == "" && !interactive {
+			return nil, output.Errorf(output.CodeInvalidArguments, "a project name is required (or run with -i to be asked for it)")
+		}
 
 		// Load configuration
-		cfg, err := config.LoadConfig("config.yaml")
+		cfg, err := loadConfig()
 		if err != nil {
-			fmt.Printf("Error loading config: %v\n", err)
-			os.Exit(1)
+			return nil, err
 		}
-
-		// Override defaults with flags
-		if entity != "" {
-			cfg.Defaults.PrimaryEntity = entity
+		var templatesFS fs.FS
+		if templateDir != "" {
+			templatesFS = os.DirFS(templateDir)
 		}
-		if noAuth {
-			cfg.Features.Auth.Enabled = false
+
+		// An answers file or the flags and config.yaml answer what the
+		// wizard would ask
+		answers, err := createAnswers(cmd, cfg, projectName)
+		if err != nil {
+			return nil, output.Wrap(output.CodeInvalidArguments, err)
 		}
-		if withS3 {
-			cfg.Features.S3.Enabled = true
+		var wiz *wizard.Wizard
+		if interactive {
+			wiz = wizard.New(os.Stdin, os.Stderr)
+			wiz.CheckProjectName = func(name string) error {
+				return checkNewProject(cfg, templatesFS, name)
+			}
+			wiz.CheckEntities = func(names []string) error {
+				return ddd.CheckEntities(templatesFS, names)
+			}
+			if err := wiz.Ask(answers, answers.Source() == ""); err != nil {
+				fmt.Fprintln(os.Stderr)
+				return nil, err
+			}
 		}
-		if withFrontend {
-			cfg.Features.Frontend.Enabled = true
+		projectName = answers.ProjectName
+
+		// Entities come from a schema file, SQL DDL, an OpenAPI document or the answers
+		var domain *schema.Schema
+		var migrations, downMigrations map[string]string
+		var api *schema.API
+		var schemaName string
+		var warnings []string
+		if source := answers.Source(); source != "" {
+			loaded, err := ddd.LoadSource(answers.Schema, answers.FromSQL, answers.FromOpenAPI)
+			if err != nil {
+				return nil, output.Wrap(output.CodeSchema, err)
+			}
+			domain, migrations, downMigrations, api, schemaName, warnings = loaded.Schema, loaded.Migrations, loaded.DownMigrations, loaded.API, loaded.SchemaName, loaded.Warnings
+			for _, warning := range warnings {
+				fmt.Fprintf(os.Stderr, "⚠️  Warning: %s\n", warning)
+			}
+
+			// Re-running against an existing project only reports changes
+			exists, err := ddd.NewProjectRegistry(cfg.ProjectsRegistry).ProjectExists(projectName)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check projects: %w", err)
+			}
+			if exists {
+				return schemaChanges(cfg, projectName, domain, source)
+			}
+		} else {
+			domain = &schema.Schema{Entities: answers.Entities}
+			if err := domain.Validate(); err != nil {
+				return nil, output.Wrap(output.CodeSchema, err)
+			}
 		}
-		if description == "" {
-			description = fmt.Sprintf("DDD API for %s management", cfg.Defaults.PrimaryEntity)
+		// Every name problem at once, the project's and the entities'
+		projectErr := ddd.CheckProjectName(templatesFS, projectName)
+		entitiesErr := ddd.CheckEntities(templatesFS, domain.EntityNames())
+		if err := errors.Join(projectErr, entitiesErr); err != nil {
+			problems := append(output.NameProblems(projectErr, "project", projectName), output.NameProblems(entitiesErr, "entity", domain.EntityNames()...)...)
+			return nil, output.Wrap(output.CodeInvalidName, &output.NamesError{Err: err, Problems: problems})
 		}
+		cfg.Defaults.ModulePrefix = answers.ModulePrefix
 
 		// Create generator options
 		opts := &ddd.GeneratorOptions{
 			ProjectName:        projectName,
-			Entity:            cfg.Defaults.PrimaryEntity,
-			IncludeAuth:       cfg.Features.Auth.Enabled,
-			IncludeS3:         cfg.Features.S3.Enabled,
-			IncludeRedis:      cfg.Features.Redis.Enabled,
-			IncludeFrontend:   cfg.Features.Frontend.Enabled,
-			ProjectDescription: description,
+			Entities:          domain.EntityNames(),
+			Fields:            domain.Fields(),
+			Relations:         domain.Relations(),
+			SchemaFile:        answers.Source(),
+			SchemaName:        schemaName,
+			Migrations:        migrations,
+			DownMigrations:    downMigrations,
+			API:               api,
+			IncludeAuth:       answers.Features.Auth,
+			IncludeS3:         answers.Features.S3,
+			IncludeRedis:      answers.Features.Redis,
+			IncludeFrontend:   answers.Features.Frontend,
+			ProjectDescription: answers.Description,
 			Config:            cfg,
+			APIPort:           answers.Ports.API,
+			DBPort:            answers.Ports.DB,
+			RedisPort:         answers.Ports.Redis,
+			FrontendPort:      answers.Ports.Frontend,
+			Verify:            verify,
+			Templates:         templatesFS,
 		}
 
-		// Generate the project
+		// Generate the project, once confirmed when asked for interactively
 		generator := ddd.NewGenerator(opts)
+		vars, err := generator.Preview()
+		if err != nil {
+			return nil, err
+		}
+		if interactive {
+			confirmed, err := wiz.Confirm(createSummary(opts, vars))
+			if err != nil {
+				fmt.Fprintln(os.Stderr)
+				return nil, err
+			}
+			if !confirmed {
+				return nil, output.Errorf(output.CodeCancelled, "nothing was created")
+			}
+		}
+		if saveAnswers != "" {
+			if err := resolvedAnswers(answers, opts, vars).Save(saveAnswers); err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(os.Stderr, "📝 Answers saved to %s\n", saveAnswers)
+		}
 		if err := generator.Generate(); err != nil {
-			fmt.Printf("Error generating project: %v\n", err)
-			os.Exit(1)
+			return nil, output.GenerationError(err)
 		}
 
-		fmt.Printf("\n✅ Project '%s' created successfully!\n\n", projectName)
-		fmt.Printf("Next steps:\n")
-		if opts.IncludeFrontend {
-			fmt.Printf("  cd %s/%s-server\n", projectName, projectName)
-		} else {
-			fmt.Printf("  cd %s\n", projectName)
-		}
-		fmt.Printf("  cp .env.example .env\n")
-		fmt.Printf("  make docker-up\n")
-		fmt.Printf("  make migrate-up\n")
-		fmt.Printf("  make dev\n\n")
-		fmt.Printf("Your API will be running at http://localhost:%d\n", opts.APIPort)
-		if opts.IncludeFrontend {
-			fmt.Printf("Your frontend will be running at http://localhost:%d\n", opts.FrontendPort)
+		result, err := output.NewCreate(opts, vars, append(warnings, generator.Warnings()...))
+		if err != nil {
+			return nil, err
 		}
-	},
+		result.AnswersFile = saveAnswers
+		return result, nil
+	}),
 }
 
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all generated projects",
-	Run: func(cmd *cobra.Command, args []string) {
-		cfg, err := config.LoadConfig("config.yaml")
+	Args:  cobra.NoArgs,
+	RunE: run(func(cmd *cobra.Command, args []string) (output.Result, error) {
+		cfg, err := loadConfig()
 		if err != nil {
-			fmt.Printf("Error loading config: %v\n", err)
-			os.Exit(1)
+			return nil, err
 		}
 
 		registry := ddd.NewProjectRegistry(cfg.ProjectsRegistry)
 		projects, err := registry.List()
 		if err != nil {
-			fmt.Printf("Error listing projects: %v\n", err)
-			os.Exit(1)
+			return nil, fmt.Errorf("failed to list projects: %w", err)
+		}
+
+		return output.NewList(projects), nil
+	}),
+}
+
+var addCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add code to an existing generated project",
+}
+
+var addEntityCmd = &cobra.Command{
+	Use:   "entity [name]",
+	Short: "Add an entity (package, migrations and routes) to a generated project",
+	Args:  cobra.ExactArgs(1),
+	RunE: run(func(cmd *cobra.Command, args []string) (output.Result, error) {
+		cfg, err := loadConfig()
+		if err != nil {
+			return nil, err
 		}
 
-		if len(projects) == 0 {
-			fmt.Println("No projects generated yet.")
-			return
+		opts := &ddd.AddEntityOptions{
+			ProjectDir: projectDir,
+			Entity:     args[0],
+			Config:     cfg,
+		}
+		if opts.Fields, err = parseFields(); err != nil {
+			return nil, output.Wrap(output.CodeInvalidArguments, err)
+		}
+		if templateDir != "" {
+			opts.Templates = os.DirFS(templateDir)
 		}
 
-		fmt.Println("Generated projects:")
-		fmt.Println("==================")
-		for _, p := range projects {
-			fmt.Printf("  %s - API: %d, DB: %d, Redis: %d (created: %s)\n",
-				p.Name, p.APIPort, p.DBPort, p.RedisPort, p.CreatedAt.Format("2006-01-02"))
+		if err := ddd.AddEntity(opts); err != nil {
+			if errors.Is(err, ddd.ErrNoProject) {
+				return nil, output.Wrap(output.CodeProjectNotFound, err)
+			}
+			return nil, fmt.Errorf("failed to add entity: %w", err)
 		}
-	},
+
+		path, err := filepath.Abs(projectDir)
+		if err != nil {
+			return nil, err
+		}
+		return &output.AddEntity{
+			Entity:    args[0],
+			Path:      path,
+			NextSteps: []string{"make migrate-up", "make dev"},
+		}, nil
+	}),
+}
+
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Merge template changes into a generated project on a new branch",
+	Args:  cobra.NoArgs,
+	RunE: run(func(cmd *cobra.Command, args []string) (output.Result, error) {
+		cfg, err := loadConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		opts := &ddd.UpgradeOptions{
+			ProjectDir: projectDir,
+			Config:     cfg,
+		}
+		if templateDir != "" {
+			opts.Templates = os.DirFS(templateDir)
+		}
+
+		report, err := ddd.Upgrade(opts)
+		if err != nil {
+			switch {
+			case errors.Is(err, ddd.ErrNoProject):
+				return nil, output.Wrap(output.CodeProjectNotFound, err)
+			case errors.Is(err, ddd.ErrUncommitted):
+				return nil, output.Wrap(output.CodeUncommittedChanges, err)
+			}
+			return nil, fmt.Errorf("failed to upgrade project: %w", err)
+		}
+
+		return &output.Upgrade{
+			UpToDate:  report.Branch == "",
+			Branch:    report.Branch,
+			From:      report.From,
+			To:        report.To,
+			Updated:   report.Updated,
+			Added:     report.Added,
+			Removed:   report.Removed,
+			Conflicts: report.Conflicts,
+			Skipped:   report.Skipped,
+			Warnings:  report.Warnings,
+		}, nil
+	}),
+}
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify [dir]",
+	Short: "Check a generated project builds, vets and has no template leftovers",
+	Long: `Run go build and go vet on a generated project and look through its files
+for unrendered template actions, the template's module path and the sample
+entity's name. Every problem is reported with its file and line.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: run(func(cmd *cobra.Command, args []string) (output.Result, error) {
+		cfg, err := loadConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		opts := &ddd.VerifyOptions{
+			ProjectDir: ".",
+			Config:     cfg,
+		}
+		if len(args) > 0 {
+			opts.ProjectDir = args[0]
+		}
+		if templateDir != "" {
+			opts.Templates = os.DirFS(templateDir)
+		}
+
+		if err := ddd.Verify(opts); err != nil {
+			var verifyErr *ddd.VerifyError
+			switch {
+			case errors.Is(err, ddd.ErrNoProject):
+				return nil, output.Wrap(output.CodeProjectNotFound, err)
+			case errors.As(err, &verifyErr):
+				return nil, output.Wrap(output.CodeVerifyFailed, err)
+			}
+			return nil, fmt.Errorf("failed to verify project: %w", err)
+		}
+
+		path, err := filepath.Abs(opts.ProjectDir)
+		if err != nil {
+			return nil, err
+		}
+		return &output.Verify{Path: path, Checks: ddd.VerifyChecks}, nil
+	}),
+}
+
+var templateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Work on the templates themselves",
+}
+
+// defaultFixtures is where a checkout of the generator keeps the fixtures
+const defaultFixtures = "templates/testdata"
+
+var templateTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Render the templates with fixture answers and compare them with their snapshots",
+	Long: `Render the templates once per fixture, an answers file named
+<name>.answers.yaml, and compare the project with the snapshot in the
+directory <name> next to it. Changed files are shown as diffs on stderr.
+With --update the snapshots are rewritten instead, to review and commit.
+
+The fixtures aren't part of the binary: this is meant for a checkout of the
+generator, run from its root. The fixtures default to <template-dir>/testdata,
+or ./templates/testdata when it exists; pass --template-dir templates to test
+edited templates rather than the embedded ones.`,
+	Args: cobra.NoArgs,
+	RunE: run(func(cmd *cobra.Command, args []string) (output.Result, error) {
+		dir := fixturesDir
+		switch {
+		case dir != "":
+		case templateDir != "":
+			dir = filepath.Join(templateDir, "testdata")
+		default:
+			info, err := os.Stat(defaultFixtures)
+			if err != nil || !info.IsDir() {
+				return nil, output.Errorf(output.CodeInvalidArguments, "no fixtures at ./%s: run from the root of the generator checkout, or give --fixtures or --template-dir", defaultFixtures)
+			}
+			dir = defaultFixtures
+		}
+		var templatesFS fs.FS
+		if templateDir != "" {
+			templatesFS = os.DirFS(templateDir)
+		}
+
+		fixtures, err := golden.Fixtures(dir)
+		if err != nil {
+			return nil, output.Wrap(output.CodeInvalidArguments, err)
+		}
+		result := &output.TemplateTest{Updated: update}
+		var mismatches []string
+		for _, f := range fixtures {
+			result.Fixtures = append(result.Fixtures, f.Name)
+			if update {
+				fmt.Fprintf(os.Stderr, "📸 Updating snapshot '%s'...\n", f.Name)
+				if err := golden.Update(templatesFS, f); err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			fmt.Fprintf(os.Stderr, "📸 Checking fixture '%s'...\n", f.Name)
+			differences, err := golden.Check(templatesFS, f)
+			if err != nil {
+				return nil, err
+			}
+			if len(differences) > 0 {
+				fmt.Fprintf(os.Stderr, "\n%s\n", golden.Report(f, differences))
+				for _, d := range differences {
+					mismatches = append(mismatches, f.Name+": "+d.String())
+				}
+			}
+		}
+		if len(mismatches) > 0 {
+			return nil, output.Errorf(output.CodeSnapshotMismatch, "templates differ from their snapshots (run with --update to accept the changes):\n  %s", strings.Join(mismatches, "\n  "))
+		}
+		return result, nil
+	}),
+}
+
+var templateLintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Check the templates for undefined variables and stale references",
+	Long: `Parse every rendered template file and report the variables its manifest
+doesn't declare, text on the deny-list (lint.deny in config.yaml), template
+actions in files that are copied without rendering, and package paths the
+template lacks or can leave out. Exits non-zero on any problem, for use in
+pre-commit hooks.`,
+	Args: cobra.NoArgs,
+	RunE: run(func(cmd *cobra.Command, args []string) (output.Result, error) {
+		cfg, err := loadConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		opts := &ddd.LintOptions{Deny: cfg.Lint.Deny}
+		if templateDir != "" {
+			opts.Templates = os.DirFS(templateDir)
+		}
+		names, err := ddd.Lint(opts)
+		if err != nil {
+			var lintErr *ddd.LintError
+			if errors.As(err, &lintErr) {
+				return nil, output.Wrap(output.CodeLintFailed, err)
+			}
+			return nil, fmt.Errorf("failed to lint templates: %w", err)
+		}
+		return &output.TemplateLint{Templates: names, Checks: ddd.LintChecks}, nil
+	}),
+}
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Serve generator operations to AI assistants over MCP on stdio",
+	Long: `Speak the Model Context Protocol on stdin and stdout, so an assistant can
+create projects, list the registry, preview ports and validate names as tool
+calls returning JSON. Generation progress goes to stderr.`,
+	Args: cobra.NoArgs,
+	RunE: run(func(cmd *cobra.Command, args []string) (output.Result, error) {
+		cfg, err := loadConfig()
+		if err != nil {
+			return nil, err
+		}
+		var templatesFS fs.FS
+		if templateDir != "" {
+			templatesFS = os.DirFS(templateDir)
+		}
+
+		// Stdout carries the protocol, so there is no result to print
+		return nil, mcp.NewServer(mcp.Tools(cfg, templatesFS)).Serve(os.Stdin, os.Stdout)
+	}),
+}
+
+// run adapts a command to --output: what it returns is printed in the
+// chosen format, and its errors, coded, by main. Commands that write their
+// own output return no result.
+func run(command func(cmd *cobra.Command, args []string) (output.Result, error)) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		result, err := command(cmd, args)
+		if err != nil {
+			return output.Wrap(output.CodeFailed, err)
+		}
+		if result == nil {
+			return nil
+		}
+		return printer().Result(result)
+	}
+}
+
+// printer writes in the --output format, text when it is unknown
+func printer() *output.Printer {
+	p, err := output.NewPrinter(outputFormat, os.Stdout, os.Stderr)
+	if err != nil {
+		p, _ = output.NewPrinter(output.Text, os.Stdout, os.Stderr)
+	}
+	return p
+}
+
+func loadConfig() (*config.Config, error) {
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return nil, output.Wrap(output.CodeConfig, fmt.Errorf("failed to load config: %w", err))
+	}
+	return cfg, nil
+}
+
+// createAnswers gathers the options of a new project from --answers, or
+// from the flags and config.yaml. The flags that pick options can't be
+// combined with --answers; a project name argument overrides its name.
+func createAnswers(cmd *cobra.Command, cfg *config.Config, projectName string) (*wizard.Answers, error) {
+	if answersFile != "" {
+		var conflicts []string
+		for _, flag := range []string{"entity", "fields", "relation", "schema", "from-sql", "from-openapi", "no-auth", "with-s3", "with-frontend", "description"} {
+			if cmd.Flags().Changed(flag) {
+				conflicts = append(conflicts, "--"+flag)
+			}
+		}
+		if len(conflicts) > 0 {
+			return nil, fmt.Errorf("--answers can't be combined with %s", strings.Join(conflicts, ", "))
+		}
+
+		answers, err := wizard.LoadAnswers(answersFile)
+		if err != nil {
+			return nil, err
+		}
+		if projectName != "" {
+			answers.ProjectName = projectName
+		}
+		return answers, nil
+	}
+
+	answers := &wizard.Answers{
+		ProjectName:  projectName,
+		Schema:       schemaFile,
+		FromSQL:      fromSQL,
+		FromOpenAPI:  fromOpenAPI,
+		Description:  description,
+		ModulePrefix: cfg.Defaults.ModulePrefix,
+		Features: wizard.Features{
+			Auth:     cfg.Features.Auth.Enabled && !noAuth,
+			S3:       cfg.Features.S3.Enabled || withS3,
+			Redis:    cfg.Features.Redis.Enabled,
+			Frontend: cfg.Features.Frontend.Enabled || withFrontend,
+		},
+	}
+
+	sources := 0
+	for _, source := range []string{schemaFile, fromSQL, fromOpenAPI} {
+		if source != "" {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return nil, fmt.Errorf("only one of --schema, --from-sql and --from-openapi can be given")
+	}
+	if sources == 1 {
+		if len(entities) > 0 || fields != "" || len(relations) > 0 {
+			return nil, fmt.Errorf("--schema, --from-sql and --from-openapi can't be combined with --entity, --fields or --relation")
+		}
+		return answers, nil
+	}
+
+	if len(entities) == 0 {
+		entities = []string{cfg.Defaults.PrimaryEntity}
+	}
+	domain, err := flagSchema()
+	if err != nil {
+		return nil, err
+	}
+	answers.Entities = domain.Entities
+	return answers, nil
+}
+
+// resolvedAnswers returns the answers that replay a generation exactly: the
+// ports allocated and the description the template derived are filled in
+func resolvedAnswers(answers *wizard.Answers, opts *ddd.GeneratorOptions, vars manifest.Vars) *wizard.Answers {
+	resolved := *answers
+	resolved.Description = vars.String("ProjectDescription")
+	resolved.Ports = wizard.Ports{API: opts.APIPort, DB: opts.DBPort, Redis: opts.RedisPort, Frontend: opts.FrontendPort}
+	return &resolved
+}
+
+// checkNewProject rejects a project name create would refuse
+func checkNewProject(cfg *config.Config, templatesFS fs.FS, name string) error {
+	if err := ddd.CheckProjectName(templatesFS, name); err != nil {
+		return err
+	}
+	if _, err := os.Stat(name); err == nil {
+		return fmt.Errorf("directory '%s' already exists", name)
+	}
+	exists, err := ddd.NewProjectRegistry(cfg.ProjectsRegistry).ProjectExists(name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("project '%s' already exists", name)
+	}
+	return nil
+}
+
+// createSummary describes the project create is about to write
+func createSummary(opts *ddd.GeneratorOptions, vars manifest.Vars) []string {
+	dir := opts.ProjectName
+	if opts.IncludeFrontend {
+		dir = filepath.Join(opts.ProjectName, opts.ProjectName+"-server")
+	}
+
+	tables := make([]string, len(opts.Entities))
+	for i, entity := range opts.Entities {
+		tables[i] = fmt.Sprintf("%s (table %s)", entity, ddd.TableName(entity))
+	}
+
+	var features []string
+	for _, f := range []struct {
+		name string
+		on   bool
+	}{{"auth", opts.IncludeAuth}, {"S3", opts.IncludeS3}, {"Redis", opts.IncludeRedis}, {"frontend", opts.IncludeFrontend}} {
+		if f.on {
+			features = append(features, f.name)
+		}
+	}
+	if len(features) == 0 {
+		features = []string{"none"}
+	}
+
+	ports := fmt.Sprintf("API %d, PostgreSQL %d", opts.APIPort, opts.DBPort)
+	if opts.IncludeRedis {
+		ports += fmt.Sprintf(", Redis %d", opts.RedisPort)
+	}
+	if opts.IncludeFrontend {
+		ports += fmt.Sprintf(", frontend %d", opts.FrontendPort)
+	}
+
+	return []string{
+		fmt.Sprintf("Project:     %s in ./%s", opts.ProjectName, dir),
+		fmt.Sprintf("Module:      %s", vars.String("ModuleName")),
+		fmt.Sprintf("Description: %s", vars.String("ProjectDescription")),
+		fmt.Sprintf("Entities:    %s", strings.Join(tables, ", ")),
+		fmt.Sprintf("Features:    %s", strings.Join(features, ", ")),
+		fmt.Sprintf("Database:    %s", vars.String("DBName")),
+		fmt.Sprintf("Ports:       %s", ports),
+	}
+}
+
+// schemaChanges reports what a schema would change in an existing project
+func schemaChanges(cfg *config.Config, projectName string, domain *schema.Schema, source string) (*output.SchemaChanges, error) {
+	changes, storedPath, err := ddd.SchemaChanges(cfg, projectName, domain)
+	if err != nil {
+		return nil, output.Wrap(output.CodeAlreadyExists, fmt.Errorf("project '%s' already exists and can't be compared: %w", projectName, err))
+	}
+
+	result := &output.SchemaChanges{Project: projectName, Source: source, Stored: storedPath, Changes: []string{}}
+	for _, change := range changes {
+		result.Changes = append(result.Changes, change.String())
+	}
+	return result, nil
+}
+
+// flagSchema builds the schema given by --entity, --fields (for the primary
+// entity) and --relation
+func flagSchema() (*schema.Schema, error) {
+	entityFields, err := parseFields()
+	if err != nil {
+		return nil, err
+	}
+
+	domain := &schema.Schema{}
+	for i, entity := range entities {
+		domain.Entities = append(domain.Entities, schema.Entity{Name: entity})
+		if i == 0 {
+			domain.Entities[0].Fields = entityFields
+		}
+	}
+
+	for _, spec := range relations {
+		entity, kind, ref, err := schema.ParseRelation(spec)
+		if err == nil {
+			err = domain.AddRelation(entity, kind, ref)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid --relation: %w", err)
+		}
+	}
+
+	if err := domain.Validate(); err != nil {
+		return nil, err
+	}
+	return domain, nil
+}
+
+// parseFields parses --fields; nil keeps the template's sample fields
+func parseFields() ([]schema.Field, error) {
+	if fields == "" {
+		return nil, nil
+	}
+	parsed, err := schema.ParseFields(fields)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --fields:\n%w", err)
+	}
+	return parsed, nil
 }
 
 func init() {
-	createCmd.Flags().StringVarP(&entity, "entity", "e", "", "Primary entity name (default: item)")
+	createCmd.Flags().StringSliceVarP(&entities, "entity", "e", nil, "Entity name, repeat for several (default: item)")
+	createCmd.Flags().StringVar(&fields, "fields", "", "Fields of the primary entity, e.g. \"title:string!,price:decimal,due_at:time?,status:enum(open,closed)\"")
+	createCmd.Flags().StringArrayVar(&relations, "relation", nil, "Relation between entities, e.g. \"order belongs_to customer\", \"customer has_many order:cascade\", \"post many_to_many tag\" (repeatable)")
+	createCmd.Flags().StringVar(&schemaFile, "schema", "", "YAML or JSON file declaring the entities and their fields")
+	createCmd.Flags().StringVar(&fromSQL, "from-sql", "", "Postgres DDL whose CREATE TABLE statements become the entities and migrations")
+	createCmd.Flags().StringVar(&fromOpenAPI, "from-openapi", "", "OpenAPI 3 document whose collections become the entities and whose paths the routes follow")
 	createCmd.Flags().BoolVar(&noAuth, "no-auth", false, "Generate without authentication")
 	createCmd.Flags().BoolVar(&withS3, "with-s3", false, "Include S3 file upload support")
 	createCmd.Flags().BoolVar(&withFrontend, "with-frontend", false, "Include Next.js frontend")
 	createCmd.Flags().StringVarP(&description, "description", "d", "", "Project description for CLAUDE.md")
+	createCmd.Flags().StringVar(&templateDir, "template-dir", "", "Read templates from this directory instead of the embedded ones")
+	createCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Ask for each option, then confirm before writing (default on a terminal without a project name)")
+	createCmd.Flags().StringVar(&answersFile, "answers", "", "Create from the options in this YAML file, without asking anything")
+	createCmd.Flags().StringVar(&saveAnswers, "save-answers", "", "Write the fully resolved options to this file, for replaying with --answers")
+	createCmd.Flags().BoolVar(&verify, "verify", false, "Build, vet and scan the project for template leftovers before registering it")
+
+	addEntityCmd.Flags().StringVar(&projectDir, "dir", ".", "Project directory")
+	addEntityCmd.Flags().StringVar(&fields, "fields", "", "Entity fields, e.g. \"title:string!,price:decimal,due_at:time?,status:enum(open,closed)\"")
+	addEntityCmd.Flags().StringVar(&templateDir, "template-dir", "", "Read templates from this directory instead of the embedded ones")
+	addCmd.AddCommand(addEntityCmd)
+
+	upgradeCmd.Flags().StringVar(&projectDir, "dir", ".", "Project directory")
+	upgradeCmd.Flags().StringVar(&templateDir, "template-dir", "", "Read templates from this directory instead of the embedded ones")
+
+	verifyCmd.Flags().StringVar(&templateDir, "template-dir", "", "Read templates from this directory instead of the embedded ones")
+
+	templateTestCmd.Flags().StringVar(&templateDir, "template-dir", "", "Read templates from this directory instead of the embedded ones")
+	templateTestCmd.Flags().StringVar(&fixturesDir, "fixtures", "", "Directory of the fixtures and snapshots (default: <template-dir>/testdata, else "+defaultFixtures+")")
+	templateTestCmd.Flags().BoolVar(&update, "update", false, "Rewrite the snapshots from the current templates")
+	templateCmd.AddCommand(templateTestCmd)
+
+	templateLintCmd.Flags().StringVar(&templateDir, "template-dir", "", "Read templates from this directory instead of the embedded ones")
+	templateCmd.AddCommand(templateLintCmd)
+
+	mcpCmd.Flags().StringVar(&templateDir, "template-dir", "", "Read templates from this directory instead of the embedded ones")
+
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", output.Text, "Output format: text, or json or yaml for scripts (progress goes to stderr)")
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "Path to config.yaml (default: ./config.yaml, then built-in defaults)")
 
 	rootCmd.AddCommand(createCmd)
 	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(addCmd)
+	rootCmd.AddCommand(upgradeCmd)
+	rootCmd.AddCommand(verifyCmd)
+	rootCmd.AddCommand(templateCmd)
+	rootCmd.AddCommand(mcpCmd)
 }
 
 func main() {
+	// Errors cobra reports itself are about the flags and arguments
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
+		printer().Error(output.Wrap(output.CodeInvalidArguments, err))
 		os.Exit(1)
 	}
 }
\ No newline at end of file
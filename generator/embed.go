@@ -0,0 +1,10 @@
+// Package generator bundles the generator's default configuration into the
+// go-gen binary so it runs from any directory.
+package generator
+
+import _ "embed"
+
+// DefaultConfig is config.yaml as shipped with the generator
+//
+//go:embed config.yaml
+var DefaultConfig []byte
@@ -3,11 +3,17 @@ module github.com/darkphotonKN/go-template-generator
 go 1.23.0
 
 require (
+	github.com/darkphotonKN/go-template-generator/templates v0.0.0
 	github.com/spf13/cobra v1.8.0
+	golang.org/x/mod v0.22.0
+	golang.org/x/term v0.27.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
+	golang.org/x/sys v0.28.0 // indirect
 )
+
+replace github.com/darkphotonKN/go-template-generator/templates => ../templates
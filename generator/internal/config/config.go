@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 
+	generator "github.com/darkphotonKN/go-template-generator"
+	"github.com/darkphotonKN/go-template-generator/internal/hooks"
 	"gopkg.in/yaml.v3"
 )
 
@@ -60,17 +62,28 @@ type Config struct {
 			Description string `yaml:"description"`
 		} `yaml:"frontend"`
 	} `yaml:"features"`
+
+	// Hooks run for every generated project, after the template's own
+	Hooks []hooks.Hook `yaml:"hooks"`
+
+	Lint struct {
+		// Deny is text go-gen template lint reports in any template
+		Deny []string `yaml:"deny"`
+	} `yaml:"lint"`
 }
 
+// DefaultFileName is the config file picked up from the current directory
+const DefaultFileName = "config.yaml"
+
+// LoadConfig reads the generator configuration. An explicit path must exist;
+// with an empty path, config.yaml in the current directory is used when
+// present and the configuration embedded in the binary otherwise.
 func LoadConfig(path string) (*Config, error) {
-	// Check if path is relative or absolute
-	if !filepath.IsAbs(path) {
-		// If relative, look in the generator directory
-		execPath, err := os.Executable()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get executable path: %w", err)
+	if path == "" {
+		if _, err := os.Stat(DefaultFileName); err != nil {
+			return Parse(generator.DefaultConfig)
 		}
-		path = filepath.Join(filepath.Dir(execPath), "..", path)
+		path = DefaultFileName
 	}
 
 	data, err := os.ReadFile(path)
@@ -78,10 +91,18 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
+	return Parse(data)
+}
+
+// Parse decodes a YAML configuration
+func Parse(data []byte) (*Config, error) {
 	var config Config
 	if err := yaml.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
+	if err := hooks.Validate(config.Hooks); err != nil {
+		return nil, fmt.Errorf("invalid hooks in config file:\n%w", err)
+	}
 
 	// Expand home directory in registry path
 	if config.ProjectsRegistry != "" && config.ProjectsRegistry[0] == '~' {
@@ -93,4 +114,4 @@ func LoadConfig(path string) (*Config, error) {
 	}
 
 	return &config, nil
-}
\ No newline at end of file
+}
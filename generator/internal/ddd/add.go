@@ -0,0 +1,84 @@
+package ddd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/darkphotonKN/go-template-generator/internal/config"
+	"github.com/darkphotonKN/go-template-generator/internal/lockfile"
+	"github.com/darkphotonKN/go-template-generator/internal/registry"
+	"github.com/darkphotonKN/go-template-generator/internal/schema"
+	"github.com/darkphotonKN/go-template-generator/templates"
+)
+
+type AddEntityOptions struct {
+	// ProjectDir is the generated API project, or the folder holding
+	// <name>-server for full-stack projects
+	ProjectDir string
+	Entity     string
+	Fields     []schema.Field // nil keeps the sample entity's fields
+	Config     *config.Config
+
+	// Templates holds one directory per template; nil uses the templates
+	// embedded in the binary
+	Templates fs.FS
+}
+
+// AddEntity renders a new entity into an existing generated project: its
+// domain package, the next-numbered migration pair and its routes, which
+// are added to the OpenAPI document and, with the files written, to the
+// lockfile. Nothing is written when any of those already exists.
+func AddEntity(opts *AddEntityOptions) error {
+	templatesFS := opts.Templates
+	if templatesFS == nil {
+		templatesFS = templates.FS
+	}
+
+	registryMgr := registry.NewManager(opts.Config.ProjectsRegistry)
+	project, err := findProject(registryMgr, opts.ProjectDir)
+	if err != nil {
+		return err
+	}
+	projectDir := project.Dir
+
+	existing := project.EntityList()
+	if err := checkEntities(templatesFS, APITemplate, append(append([]string{}, existing...), opts.Entity)); err != nil {
+		return err
+	}
+
+	var before map[string]string
+	if project.Lock != nil {
+		if before, err = lockfile.Checksums(project.Root); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "🧩 Adding entity '%s' to '%s'...\n", opts.Entity, project.Name)
+	scaffolder := NewEntityScaffolder(templatesFS, APITemplate, projectDir)
+	if err := scaffolder.Add(opts.Entity, opts.Fields, existing); err != nil {
+		return err
+	}
+
+	if err := NewSpecWriter(projectDir, nil).Apply(); err != nil {
+		return err
+	}
+
+	if project.Lock != nil {
+		project.Lock.Entities = append(project.Lock.Entities, schema.Entity{Name: opts.Entity, Fields: opts.Fields})
+		if err := project.Lock.Track(project.Root, before); err != nil {
+			return err
+		}
+		if err := project.Lock.Save(project.Root); err != nil {
+			return err
+		}
+	}
+
+	if project.Registered {
+		if err := registryMgr.AddEntity(project.Name, opts.Entity); err != nil {
+			return fmt.Errorf("failed to register entity: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package ddd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	generator "github.com/darkphotonKN/go-template-generator"
+	"github.com/darkphotonKN/go-template-generator/internal/config"
+	"github.com/darkphotonKN/go-template-generator/internal/lockfile"
+	"github.com/darkphotonKN/go-template-generator/internal/registry"
+)
+
+func TestAddEntity(t *testing.T) {
+	dir := inTempDir(t)
+	cfg, err := config.Parse(generator.DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Hooks = nil
+	cfg.ProjectsRegistry = filepath.Join(dir, "projects.json")
+
+	if err := NewGenerator(&GeneratorOptions{ProjectName: "shop", Entities: []string{"product"}, Config: cfg}).Generate(); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	projectDir := filepath.Join(dir, "shop")
+
+	// A migration written by hand after generation sets the next number
+	migrations := filepath.Join(projectDir, "migrations")
+	for _, name := range []string{"000007_add_product_sku.up.sql", "000007_add_product_sku.down.sql"} {
+		if err := os.WriteFile(filepath.Join(migrations, name), []byte("SELECT 1;\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := AddEntity(&AddEntityOptions{ProjectDir: projectDir, Entity: "order", Config: cfg}); err != nil {
+		t.Fatalf("AddEntity failed: %v", err)
+	}
+	for _, name := range []string{"000008_create_orders_table.up.sql", "000008_create_orders_table.down.sql", "../internal/order/handler.go"} {
+		if _, err := os.Stat(filepath.Join(migrations, name)); err != nil {
+			t.Errorf("expected %s: %v", name, err)
+		}
+	}
+
+	project, err := registry.NewManager(cfg.ProjectsRegistry).Get("shop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := project.EntityList(), []string{"product", "order"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("registered entities = %v, want %v", got, want)
+	}
+
+	lock, err := lockfile.Load(projectDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entities []string
+	for _, entity := range lock.Entities {
+		entities = append(entities, entity.Name)
+	}
+	if want := []string{"product", "order"}; !reflect.DeepEqual(entities, want) {
+		t.Errorf("%s entities = %v, want %v", lockfile.FileName, entities, want)
+	}
+	for _, file := range []string{"migrations/000008_create_orders_table.up.sql", "internal/order/handler.go"} {
+		if lock.Files[file] == "" {
+			t.Errorf("%s doesn't track %s", lockfile.FileName, file)
+		}
+	}
+
+	// Adding an entity the project has fails without writing anything
+	before, err := lockfile.Checksums(projectDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := AddEntity(&AddEntityOptions{ProjectDir: projectDir, Entity: "order", Config: cfg}); err == nil {
+		t.Fatal("expected adding an existing entity to fail")
+	}
+	after, err := lockfile.Checksums(projectDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(before, after) {
+		t.Errorf("adding an existing entity changed the project")
+	}
+	if project, _ := registry.NewManager(cfg.ProjectsRegistry).Get("shop"); len(project.EntityList()) != 2 {
+		t.Errorf("registered entities = %v after adding an existing entity", project.EntityList())
+	}
+}
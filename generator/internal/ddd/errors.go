@@ -0,0 +1,11 @@
+package ddd
+
+import "errors"
+
+// Errors callers can tell apart with errors.Is. They are worded to end the
+// messages wrapping them, e.g. "directory 'shop' already exists".
+var (
+	ErrExists      = errors.New("already exists")
+	ErrNoProject   = errors.New("no go-gen project")
+	ErrUncommitted = errors.New("uncommitted changes")
+)
@@ -0,0 +1,511 @@
+package ddd
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/darkphotonKN/go-template-generator/internal/schema"
+)
+
+// Fields of the template's sample entity, replaced by user-defined fields
+var sampleFields = []string{"name", "description"}
+
+// FieldRewriter replaces the sample entity's name and description fields
+// with user-defined fields in an entity's model, service, repository and
+// create-table migration
+type FieldRewriter struct {
+	names  EntityNames
+	fields []schema.Field
+}
+
+func NewFieldRewriter(entity string, fields []schema.Field) *FieldRewriter {
+	return &FieldRewriter{
+		names:  NewEntityNames(entity),
+		fields: fields,
+	}
+}
+
+// Apply rewrites the entity's files inside a generated project
+func (f *FieldRewriter) Apply(projectDir string) error {
+	paths := []string{
+		filepath.Join(projectDir, "internal", f.names.Package, "model.go"),
+		filepath.Join(projectDir, "internal", f.names.Package, "service.go"),
+		filepath.Join(projectDir, "internal", f.names.Package, "repository.go"),
+	}
+	migrations, err := filepath.Glob(filepath.Join(projectDir, "migrations", "*_create_"+f.names.SnakePlural+"_table.up.sql"))
+	if err != nil {
+		return err
+	}
+	if len(migrations) != 1 {
+		return fmt.Errorf("expected one create table migration for %s, found %d", f.names.SnakePlural, len(migrations))
+	}
+	paths = append(paths, migrations[0])
+
+	for _, path := range paths {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		out, err := f.RewriteFile(filepath.Base(path), src)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, out, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// RewriteFile rewrites one of the entity's files by its base name; other
+// files are returned unchanged
+func (f *FieldRewriter) RewriteFile(name string, src []byte) ([]byte, error) {
+	var out []byte
+	var err error
+	switch {
+	case name == "model.go":
+		out, err = f.rewriteModel(src)
+	case name == "service.go":
+		out, err = f.rewriteService(src)
+	case name == "repository.go":
+		out, err = f.rewriteRepository(src)
+	case strings.HasSuffix(name, "_create_"+f.names.SnakePlural+"_table.up.sql"):
+		out, err = f.rewriteMigration(src)
+	default:
+		return src, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply fields to %s: %w", name, err)
+	}
+	return out, nil
+}
+
+func (f *FieldRewriter) rewriteModel(src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "model.go", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	structs := map[string]func(schema.Field) string{
+		f.names.Camel: func(field schema.Field) string {
+			return fmt.Sprintf("%s %s `json:\"%s\" db:\"%s\"`", field.GoName(), field.GoType(), field.JSONName(), field.Name)
+		},
+		"Create" + f.names.Camel + "Request": func(field schema.Field) string {
+			goType, _ := createType(field)
+			return fmt.Sprintf("%s %s `json:\"%s\"%s`", field.GoName(), goType, field.JSONName(), bindingTag(field.Binding(false)))
+		},
+		"Update" + f.names.Camel + "Request": func(field schema.Field) string {
+			return fmt.Sprintf("%s %s `json:\"%s,omitempty\"%s`", field.GoName(), updateType(field), field.JSONName(), bindingTag(field.Binding(true)))
+		},
+	}
+
+	var edits []lineEdit
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			st, ok := ts.Type.(*ast.StructType)
+			render, wanted := structs[ts.Name.Name]
+			if !ok || !wanted {
+				continue
+			}
+			delete(structs, ts.Name.Name)
+
+			var lines []string
+			for _, field := range f.fields {
+				lines = append(lines, render(field))
+			}
+			var nodes []ast.Node
+			for _, field := range st.Fields.List {
+				if len(field.Names) == 1 && isSampleName(field.Names[0].Name) {
+					nodes = append(nodes, field)
+				}
+			}
+			if len(nodes) == 0 {
+				return nil, fmt.Errorf("%s has none of the sample fields", ts.Name.Name)
+			}
+			edits = append(edits, replaceNodes(fset, nodes, lines)...)
+		}
+	}
+	for name := range structs {
+		return nil, fmt.Errorf("struct %s not found", name)
+	}
+
+	return format.Source(applyLineEdits(src, edits))
+}
+
+func (f *FieldRewriter) rewriteService(src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "service.go", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	var edits []lineEdit
+	var create, update bool
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+
+		switch fn.Name.Name {
+		case "Create" + f.names.Camel:
+			create = true
+			assign, lit := f.findEntityLiteral(fn.Body)
+			if lit == nil {
+				return nil, fmt.Errorf("%s doesn't build a %s literal", fn.Name.Name, f.names.Camel)
+			}
+			variable := assign.Lhs[0].(*ast.Ident).Name
+
+			var entries []ast.Node
+			for _, elt := range lit.Elts {
+				if kv, ok := elt.(*ast.KeyValueExpr); ok && isSampleIdent(kv.Key) {
+					entries = append(entries, kv)
+				}
+			}
+			if len(entries) == 0 {
+				return nil, fmt.Errorf("%s sets none of the sample fields", fn.Name.Name)
+			}
+
+			var lines, overrides []string
+			for _, field := range f.fields {
+				lines = append(lines, fmt.Sprintf("%s: %s,", field.GoName(), createValue(field)))
+				switch {
+				case field.Default != "" && field.Nullable:
+					// A nullable field left out of the request gets a pointer to its default
+					overrides = append(overrides, fmt.Sprintf("if req.%s == nil {\nvalue := %s\n%s.%s = &value\n}", field.GoName(), typedDefault(field), variable, field.GoName()))
+				case field.Default != "" && !field.Required:
+					overrides = append(overrides, fmt.Sprintf("if req.%s != nil {\n%s.%s = *req.%s\n}", field.GoName(), variable, field.GoName(), field.GoName()))
+				}
+			}
+			edits = append(edits, replaceNodes(fset, entries, lines)...)
+			if len(overrides) > 0 {
+				end := fset.Position(assign.End()).Line
+				edits = append(edits, lineEdit{from: end + 1, to: end, text: strings.Join(overrides, "\n")})
+			}
+
+		case "Update" + f.names.Camel:
+			update = true
+			var ifs []ast.Node
+			var variable string
+			for _, stmt := range fn.Body.List {
+				ifStmt, ok := stmt.(*ast.IfStmt)
+				if !ok || !mentionsSample(ifStmt.Cond) {
+					continue
+				}
+				ifs = append(ifs, ifStmt)
+				if variable == "" && len(ifStmt.Body.List) > 0 {
+					variable = assignedVariable(ifStmt.Body.List[0])
+				}
+			}
+			if len(ifs) == 0 || variable == "" {
+				return nil, fmt.Errorf("%s doesn't update the sample fields", fn.Name.Name)
+			}
+
+			var lines []string
+			for _, field := range f.fields {
+				value := "*req." + field.GoName()
+				if field.Nullable {
+					value = "req." + field.GoName()
+				}
+				lines = append(lines, fmt.Sprintf("if req.%s != nil {\n%s.%s = %s\n}", field.GoName(), variable, field.GoName(), value))
+			}
+			edits = append(edits, replaceNodes(fset, ifs, lines)...)
+		}
+	}
+	if !create || !update {
+		return nil, fmt.Errorf("service has no Create%s or Update%s", f.names.Camel, f.names.Camel)
+	}
+
+	out := applyLineEdits(src, edits)
+	if bytes.Contains(out, []byte("time.Now()")) {
+		out = addImport(out, "time")
+	}
+	return format.Source(out)
+}
+
+// findEntityLiteral finds `x := &Entity{...}` among a function's statements
+func (f *FieldRewriter) findEntityLiteral(body *ast.BlockStmt) (*ast.AssignStmt, *ast.CompositeLit) {
+	for _, stmt := range body.List {
+		assign, ok := stmt.(*ast.AssignStmt)
+		if !ok || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+			continue
+		}
+		if _, ok := assign.Lhs[0].(*ast.Ident); !ok {
+			continue
+		}
+		expr := assign.Rhs[0]
+		if unary, ok := expr.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+			expr = unary.X
+		}
+		lit, ok := expr.(*ast.CompositeLit)
+		if !ok {
+			continue
+		}
+		if ident, ok := lit.Type.(*ast.Ident); ok && ident.Name == f.names.Camel {
+			return assign, lit
+		}
+	}
+	return nil, nil
+}
+
+func (f *FieldRewriter) rewriteRepository(src []byte) ([]byte, error) {
+	var columns, params, sets []string
+	for _, field := range f.fields {
+		columns = append(columns, field.Name)
+		params = append(params, ":"+field.Name)
+		sets = append(sets, field.Name+" = :"+field.Name)
+	}
+
+	sampleColumns := strings.Join(sampleFields, ", ")
+	sampleParams := ":" + strings.Join(sampleFields, ", :")
+	var sampleSets []string
+	for _, name := range sampleFields {
+		sampleSets = append(sampleSets, name+" = :"+name)
+	}
+
+	// One pass, so the SET list isn't matched again as a column list
+	replacements := []string{
+		strings.Join(sampleSets, ", "), strings.Join(sets, ", "),
+		sampleParams, strings.Join(params, ", "),
+		sampleColumns, strings.Join(columns, ", "),
+	}
+	for i := 0; i < len(replacements); i += 2 {
+		if !bytes.Contains(src, []byte(replacements[i])) {
+			return nil, fmt.Errorf("query fragment %q not found", replacements[i])
+		}
+	}
+
+	return []byte(strings.NewReplacer(replacements...).Replace(string(src))), nil
+}
+
+func (f *FieldRewriter) rewriteMigration(src []byte) ([]byte, error) {
+	lines := strings.SplitAfter(string(src), "\n")
+
+	var columns, indexes []string
+	for _, field := range f.fields {
+		columns = append(columns, "    "+field.Column()+",")
+		if field.Index && !field.Unique {
+			indexes = append(indexes, fmt.Sprintf("CREATE INDEX idx_%s_%s ON %s(%s);", f.names.SnakePlural, field.Name, f.names.SnakePlural, field.Name))
+		}
+	}
+
+	var columnLines, indexLines []int
+	lastIndex := 0
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		for _, name := range sampleFields {
+			if strings.HasPrefix(trimmed, name+" ") {
+				columnLines = append(columnLines, i+1)
+			}
+			if strings.HasPrefix(trimmed, "CREATE INDEX") && strings.Contains(trimmed, "("+name+")") {
+				indexLines = append(indexLines, i+1)
+			}
+		}
+		if strings.HasPrefix(trimmed, "CREATE INDEX") {
+			lastIndex = i + 1
+		}
+	}
+	if len(columnLines) == 0 {
+		return nil, fmt.Errorf("none of the sample columns found")
+	}
+
+	edits := replaceLines(columnLines, columns)
+	switch {
+	case len(indexLines) > 0:
+		edits = append(edits, replaceLines(indexLines, indexes)...)
+	case len(indexes) > 0:
+		edits = append(edits, lineEdit{from: lastIndex + 1, to: lastIndex, text: strings.Join(indexes, "\n")})
+	}
+
+	return applyLineEdits(src, edits), nil
+}
+
+// bindingTag returns the binding tag for rules, or nothing when there is
+// nothing to check
+func bindingTag(rules string) string {
+	if rules == "omitempty" {
+		return ""
+	}
+	return fmt.Sprintf(" binding:%q", rules)
+}
+
+// createType returns a create request field's type and whether it is a
+// pointer the service has to dereference
+func createType(field schema.Field) (string, bool) {
+	switch {
+	case field.Nullable:
+		return field.GoType(), false
+	case field.Required && field.ZeroIsValid(), field.Default != "" && !field.Required:
+		// Tell a zero value apart from a missing one
+		return "*" + field.BaseGoType(), true
+	}
+	return field.BaseGoType(), false
+}
+
+// updateType returns an update request field's type; every field is a
+// pointer so only what was sent is changed
+func updateType(field schema.Field) string {
+	if field.Nullable {
+		return field.GoType()
+	}
+	return "*" + field.BaseGoType()
+}
+
+// createValue returns the expression setting a field in the service's
+// create literal
+func createValue(field schema.Field) string {
+	_, pointer := createType(field)
+	switch {
+	case field.Nullable:
+		return "req." + field.GoName()
+	case field.Default != "" && !field.Required:
+		return field.DefaultGo()
+	case pointer:
+		return "*req." + field.GoName()
+	}
+	return "req." + field.GoName()
+}
+
+// typedDefault returns a field's default as an expression of its Go type,
+// converting constants such as 0 that would otherwise be untyped
+func typedDefault(field schema.Field) string {
+	value := field.DefaultGo()
+	if strings.HasSuffix(value, "()") {
+		return value
+	}
+	return field.BaseGoType() + "(" + value + ")"
+}
+
+func isSampleName(name string) bool {
+	for _, sample := range sampleFields {
+		if name == camelJoin([]string{sample}) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSampleIdent(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && isSampleName(ident.Name)
+}
+
+// mentionsSample reports whether an expression reads req.<sample field>
+func mentionsSample(expr ast.Expr) bool {
+	found := false
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if x, ok := sel.X.(*ast.Ident); ok && x.Name == "req" && isSampleName(sel.Sel.Name) {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// assignedVariable returns x from `x.Field = ...`
+func assignedVariable(stmt ast.Stmt) string {
+	assign, ok := stmt.(*ast.AssignStmt)
+	if !ok || len(assign.Lhs) != 1 {
+		return ""
+	}
+	if sel, ok := assign.Lhs[0].(*ast.SelectorExpr); ok {
+		if x, ok := sel.X.(*ast.Ident); ok {
+			return x.Name
+		}
+	}
+	return ""
+}
+
+// addImport adds an import to the first import block unless it is there
+func addImport(src []byte, importPath string) []byte {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ImportsOnly)
+	if err != nil {
+		return src
+	}
+	for _, spec := range file.Imports {
+		if path, _ := strconv.Unquote(spec.Path.Value); path == importPath {
+			return src
+		}
+	}
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if ok && gen.Tok == token.IMPORT && gen.Lparen.IsValid() {
+			line := fset.Position(gen.Lparen).Line
+			return applyLineEdits(src, []lineEdit{{from: line + 1, to: line, text: strconv.Quote(importPath)}})
+		}
+	}
+	return src
+}
+
+// lineEdit replaces the lines from..to (1-based, inclusive) with text, or
+// drops them when delete is set; to = from-1 inserts before from
+type lineEdit struct {
+	from, to int
+	text     string
+	delete   bool
+}
+
+// replaceNodes replaces the first node's lines with the given lines and
+// deletes the lines of the others
+func replaceNodes(fset *token.FileSet, nodes []ast.Node, lines []string) []lineEdit {
+	var starts []lineEdit
+	for _, node := range nodes {
+		starts = append(starts, lineEdit{from: fset.Position(node.Pos()).Line, to: fset.Position(node.End()).Line})
+	}
+	return withText(starts, lines)
+}
+
+// replaceLines is replaceNodes for single lines
+func replaceLines(numbers []int, lines []string) []lineEdit {
+	var edits []lineEdit
+	for _, n := range numbers {
+		edits = append(edits, lineEdit{from: n, to: n})
+	}
+	return withText(edits, lines)
+}
+
+func withText(edits []lineEdit, lines []string) []lineEdit {
+	for i := range edits {
+		edits[i].delete = i > 0 || len(lines) == 0
+	}
+	if len(edits) > 0 {
+		edits[0].text = strings.Join(lines, "\n")
+	}
+	return edits
+}
+
+func applyLineEdits(src []byte, edits []lineEdit) []byte {
+	lines := strings.SplitAfter(string(src), "\n")
+	slices.SortStableFunc(edits, func(a, b lineEdit) int { return b.from - a.from })
+
+	for _, edit := range edits {
+		var replacement []string
+		if !edit.delete {
+			for _, line := range strings.Split(edit.text, "\n") {
+				replacement = append(replacement, line+"\n")
+			}
+		}
+		lines = slices.Replace(lines, edit.from-1, edit.to, replacement...)
+	}
+
+	return []byte(strings.Join(lines, ""))
+}
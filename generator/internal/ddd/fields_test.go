@@ -0,0 +1,147 @@
+package ddd
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	generator "github.com/darkphotonKN/go-template-generator"
+	"github.com/darkphotonKN/go-template-generator/internal/config"
+	"github.com/darkphotonKN/go-template-generator/internal/schema"
+	"github.com/darkphotonKN/go-template-generator/templates"
+)
+
+func TestFieldRewriter(t *testing.T) {
+	fields, err := schema.ParseFields("title:string!,price:decimal,due_at:time?,status:enum(open,closed)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	renamer := NewEntityRenamer(TemplateEntity, "task")
+	rewriter := NewFieldRewriter("task", fields)
+
+	tests := map[string][]string{
+		"internal/item/model.go": {
+			"Title     string     `json:\"title\" db:\"title\"`",
+			"DueAt     *time.Time `json:\"due_at\" db:\"due_at\"`",
+			"Title  string     `json:\"title\" binding:\"required,max=255\"`",
+			"Status *string    `json:\"status\" binding:\"omitempty,oneof=open closed\"`",
+			"Price  *float64   `json:\"price,omitempty\"`",
+		},
+		"internal/item/service.go": {
+			"Status: \"open\",",
+			"if req.Status != nil {\n\t\ttask.Status = *req.Status\n\t}",
+			"if req.DueAt != nil {\n\t\ttask.DueAt = req.DueAt\n\t}",
+		},
+		"internal/item/repository.go": {
+			"INSERT INTO tasks (id, title, price, due_at, status, created_at, updated_at)",
+			"VALUES (:id, :title, :price, :due_at, :status, NOW(), NOW())",
+			"SET title = :title, price = :price, due_at = :due_at, status = :status, updated_at = NOW()",
+		},
+		"migrations/000002_create_items_table.up.sql": {
+			"    title VARCHAR(255) NOT NULL,\n    price NUMERIC(12,2) NOT NULL DEFAULT 0,",
+			"CHECK (status IN ('open', 'closed')),",
+		},
+	}
+
+	for name, want := range tests {
+		src, err := fs.ReadFile(templates.FS, "ddd-api/"+name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		base := name[strings.LastIndex(name, "/")+1:]
+		var renamed []byte
+		if strings.HasSuffix(base, ".sql") {
+			base = strings.Replace(base, "items", "tasks", 1)
+			renamed = []byte(renamer.RenameSQL(string(src)))
+		} else if renamed, err = renamer.RenameGoSource(base, src); err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := rewriter.RewriteFile(base, renamed)
+		if err != nil {
+			t.Fatalf("RewriteFile(%s) failed: %v", base, err)
+		}
+		for _, w := range want {
+			if !strings.Contains(string(out), w) {
+				t.Errorf("%s: expected %q in:\n%s", base, w, out)
+			}
+		}
+		for _, sample := range []string{"description", "Description"} {
+			if strings.Contains(string(out), sample) {
+				t.Errorf("%s: sample field left behind:\n%s", base, out)
+			}
+		}
+	}
+}
+
+func TestNullableDefaultsBuild(t *testing.T) {
+	domain := &schema.Schema{Entities: []schema.Entity{{
+		Name: "product",
+		Fields: []schema.Field{
+			{Name: "title", Type: schema.TypeString, Required: true},
+			{Name: "rating", Type: schema.TypeDecimal, Nullable: true, Default: "0"},
+			{Name: "stock", Type: schema.TypeInt, Nullable: true, Default: "10"},
+			{Name: "featured", Type: schema.TypeBool, Nullable: true, Default: "true"},
+			{Name: "status", Type: schema.TypeEnum, Values: []string{"draft", "live"}, Nullable: true, Default: "draft"},
+			{Name: "published_at", Type: schema.TypeTime, Nullable: true, Default: "NOW()"},
+		},
+	}}}
+	if err := domain.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	root := buildProject(t, domain, nil)
+	service, err := os.ReadFile(filepath.Join(root, "internal", "product", "service.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Rating:      req.Rating,",
+		"if req.Rating == nil {\n\t\tvalue := float64(0)\n\t\tproduct.Rating = &value\n\t}",
+		"if req.PublishedAt == nil {\n\t\tvalue := time.Now()\n\t\tproduct.PublishedAt = &value\n\t}",
+	} {
+		if !strings.Contains(string(service), want) {
+			t.Errorf("expected %q in:\n%s", want, service)
+		}
+	}
+}
+
+// buildProject renders a project of the domain's entities, with the
+// migrations given, and fails the test unless it builds and vets
+func buildProject(t *testing.T, domain *schema.Schema, migrations map[string]string) string {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("builds a generated project")
+	}
+	cfg, err := config.Parse(generator.DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Hooks = nil
+
+	opts := &GeneratorOptions{
+		ProjectName:  "shop",
+		Entities:     domain.EntityNames(),
+		Fields:       domain.Fields(),
+		Relations:    domain.Relations(),
+		Migrations:   migrations,
+		IncludeAuth:  true,
+		IncludeRedis: true,
+		Config:       cfg,
+		APIPort:      8000,
+		DBPort:       5432,
+		RedisPort:    6379,
+	}
+	dir := t.TempDir()
+	if err := Render(opts, dir); err != nil {
+		t.Fatal(err)
+	}
+	root := filepath.Join(dir, opts.ProjectName)
+	if err := verifyProject(templates.FS, APITemplate, root, root, opts.Entities); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
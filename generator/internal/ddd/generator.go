@@ -1,64 +1,94 @@
 package ddd
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/darkphotonKN/go-template-generator/internal/config"
 	"github.com/darkphotonKN/go-template-generator/internal/git"
+	"github.com/darkphotonKN/go-template-generator/internal/hooks"
+	"github.com/darkphotonKN/go-template-generator/internal/lockfile"
+	"github.com/darkphotonKN/go-template-generator/internal/manifest"
 	"github.com/darkphotonKN/go-template-generator/internal/ports"
 	"github.com/darkphotonKN/go-template-generator/internal/registry"
+	"github.com/darkphotonKN/go-template-generator/internal/schema"
+	"github.com/darkphotonKN/go-template-generator/templates"
+)
+
+// Template directories inside the templates filesystem
+const (
+	APITemplate      = "ddd-api"
+	FrontendTemplate = "nextjs-frontend"
 )
 
 type GeneratorOptions struct {
 	ProjectName        string
-	Entity             string
+	Entities           []string                  // the first one is the primary entity
+	Fields             map[string][]schema.Field // per entity; entities without keep the sample fields
+	Relations          []schema.Relation
+	SchemaFile         string            // schema the entities come from, kept in the project
+	SchemaName         string            // name SchemaFile is kept under, schema.StoredName by default
+	API                *schema.API       // OpenAPI routes the generated ones are fitted to
+	Migrations         map[string]string // per entity, replaces its generated create-table migration
+	DownMigrations     map[string]string // per entity, added to its generated drop-table migration
 	IncludeAuth        bool
 	IncludeS3          bool
 	IncludeRedis       bool
 	IncludeFrontend    bool
 	ProjectDescription string
 	Config             *config.Config
-	APIPort            int
+	APIPort            int // ports left at 0 are allocated
 	DBPort             int
 	RedisPort          int
 	FrontendPort       int
+	Verify             bool // build, vet and scan the output before registering it
+
+	// Templates holds one directory per template; nil uses the templates
+	// embedded in the binary
+	Templates fs.FS
 }
 
 type Generator struct {
 	opts        *GeneratorOptions
 	registry    *registry.Manager
 	portMgr     *ports.Manager
+	templates   fs.FS
 	templateDir string
 	targetDir   string
+	clientDir   string // frontend output, empty for backend-only projects
+	rendered    []renderedTemplate
+	hooks       *hooks.Runner // nil outside Generate: upgrades render without hooks
+	warnings    warnings
+	untidy      bool // leave go.mod as the templates wrote it, see Render
+}
+
+// warnings collects what a run warns about, printing each as it happens
+type warnings []string
+
+func (w *warnings) add(format string, args ...any) {
+	warning := fmt.Sprintf(format, args...)
+	fmt.Fprintf(os.Stderr, "⚠️  Warning: %s\n", warning)
+	*w = append(*w, warning)
 }
 
 func NewGenerator(opts *GeneratorOptions) *Generator {
-	// Calculate template directory (relative to current working directory)
-	cwd, _ := os.Getwd()
-	var templateDir string
-
-	// Check if we're in the generator directory
-	if strings.HasSuffix(cwd, "/generator") {
-		templateDir = filepath.Join(cwd, "..", "templates", "ddd-api")
-	} else if strings.HasSuffix(cwd, "/go-template-generator") {
-		// We're in the parent directory
-		templateDir = filepath.Join(cwd, "templates", "ddd-api")
-	} else {
-		// Fallback to relative to binary
-		execPath, _ := os.Executable()
-		generatorDir := filepath.Dir(execPath)
-		templateDir = filepath.Join(generatorDir, "..", "templates", "ddd-api")
+	templatesFS := opts.Templates
+	if templatesFS == nil {
+		templatesFS = templates.FS
 	}
 
 	// Determine target directory based on whether frontend is included
-	var targetDir string
+	var targetDir, clientDir string
 	if opts.IncludeFrontend {
-		// Full-stack: create container folder with -server subfolder
+		// Full-stack: create container folder with -server and -client subfolders
 		targetDir = filepath.Join(opts.ProjectName, opts.ProjectName+"-server")
+		clientDir = filepath.Join(opts.ProjectName, opts.ProjectName+"-client")
 	} else {
 		// Backend only: just the project name
 		targetDir = opts.ProjectName
@@ -68,8 +98,10 @@ func NewGenerator(opts *GeneratorOptions) *Generator {
 		opts:        opts,
 		registry:    registry.NewManager(opts.Config.ProjectsRegistry),
 		portMgr:     ports.NewManager(opts.Config),
-		templateDir: templateDir,
+		templates:   templatesFS,
+		templateDir: APITemplate,
 		targetDir:   targetDir,
+		clientDir:   clientDir,
 	}
 }
 
@@ -80,106 +112,501 @@ func (g *Generator) Generate() error {
 		return fmt.Errorf("failed to check if project exists: %w", err)
 	}
 	if exists {
-		return fmt.Errorf("project '%s' already exists", g.opts.ProjectName)
+		return fmt.Errorf("project '%s' %w", g.opts.ProjectName, ErrExists)
+	}
+
+	// Check the templates are available
+	for _, dir := range g.templateDirs() {
+		if _, err := fs.Stat(g.templates, dir); err != nil {
+			return fmt.Errorf("template '%s' not found: %w", dir, err)
+		}
+	}
+
+	// Check the names are valid and the entities can live side by side
+	if err := checkProjectName(g.templates, g.templateDir, g.opts.ProjectName); err != nil {
+		return err
+	}
+	if err := checkEntities(g.templates, g.templateDir, g.opts.Entities); err != nil {
+		return err
 	}
 
 	// Check if directory already exists
-	if _, err := os.Stat(g.targetDir); !os.IsNotExist(err) {
-		return fmt.Errorf("directory '%s' already exists", g.targetDir)
+	if _, err := os.Stat(g.opts.ProjectName); !os.IsNotExist(err) {
+		return fmt.Errorf("directory '%s' %w", g.opts.ProjectName, ErrExists)
+	}
+
+	if err := g.allocatePorts(); err != nil {
+		return err
+	}
+
+	// Run the hooks of the config and the templates along the way
+	if g.hooks, err = g.newHooks(); err != nil {
+		return err
+	}
+	if err := g.hooks.Run(hooks.BeforeCopy); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "📁 Creating project directory '%s'...\n", g.opts.ProjectName)
+	if err := g.render(); err != nil {
+		return g.rollback(err)
+	}
+
+	// Record how the project was generated for later commands
+	fmt.Fprintf(os.Stderr, "🔒 Writing %s...\n", lockfile.FileName)
+	lock, err := g.lock()
+	if err != nil {
+		return g.rollback(err)
+	}
+	if err := lock.Save(g.rootDir()); err != nil {
+		return g.rollback(err)
+	}
+
+	// Initialize git repository covering the server and, if present, the client
+	fmt.Fprintf(os.Stderr, "🔄 Initializing git repository...\n")
+	gitMgr := git.NewManager(g.opts.ProjectName)
+	if gitMgr.IsGitAvailable() {
+		if err := gitMgr.Initialize(g.opts.Config.Git.InitialCommitMessage); err != nil {
+			g.warnings.add("failed to initialize git repository: %v", err)
+		} else if err := gitMgr.UpdateRef(TemplateRef, "HEAD"); err != nil {
+			g.warnings.add("failed to record the template render: %v", err)
+		}
+	} else {
+		g.warnings.add("git not found, skipping git initialization")
+	}
+	if err := g.hooks.Run(hooks.AfterGitInit); err != nil {
+		return g.rollback(err)
+	}
+
+	// A project that doesn't verify stays on disk to be looked at, unregistered
+	if g.opts.Verify {
+		if err := verifyProject(g.templates, g.templateDir, g.rootDir(), g.targetDir, g.opts.Entities); err != nil {
+			return err
+		}
+	}
+
+	// Register project
+	fmt.Fprintf(os.Stderr, "📋 Registering project...\n")
+	projectPath, err := filepath.Abs(g.targetDir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve project path: %w", err)
+	}
+	version, err := templateVersion(g.templates, g.templateDir)
+	if err != nil {
+		return err
+	}
+	if err := g.registry.AddProject(registry.Project{
+		Name:            g.opts.ProjectName,
+		APIPort:         g.opts.APIPort,
+		DBPort:          g.opts.DBPort,
+		RedisPort:       g.opts.RedisPort,
+		FrontendPort:    g.opts.FrontendPort,
+		Entities:        g.opts.Entities,
+		Path:            projectPath,
+		Template:        g.templateDir,
+		TemplateVersion: version,
+		Description:     g.opts.ProjectDescription,
+		Fields:          g.opts.Fields,
+		Relations:       g.opts.Relations,
+	}); err != nil {
+		return fmt.Errorf("failed to register project: %w", err)
+	}
+
+	return nil
+}
+
+// Render writes the project the templates make of opts under dir, as
+// Generate would but without what lies outside the templates: go mod tidy,
+// hooks, git, the lockfile and the registry. Ports are used as given. Its
+// output depends on nothing but the templates and opts, so it can be
+// compared with snapshots.
+func Render(opts *GeneratorOptions, dir string) error {
+	g := NewGenerator(opts)
+	if err := checkProjectName(g.templates, g.templateDir, opts.ProjectName); err != nil {
+		return err
+	}
+	if err := checkEntities(g.templates, g.templateDir, opts.Entities); err != nil {
+		return err
+	}
+
+	g.targetDir = filepath.Join(dir, g.targetDir)
+	if g.clientDir != "" {
+		g.clientDir = filepath.Join(dir, g.clientDir)
+	}
+	g.untidy = true
+	return g.render()
+}
+
+// Warnings returns what the generation warned about, hooks included
+func (g *Generator) Warnings() []string {
+	warnings := slices.Clone(g.warnings)
+	if g.hooks != nil {
+		warnings = append(warnings, g.hooks.Warnings...)
+	}
+	return warnings
+}
+
+// Preview allocates the project's ports and resolves the API template's
+// variables without writing anything, so the options can be confirmed
+// first. Generate keeps the ports.
+func (g *Generator) Preview() (manifest.Vars, error) {
+	if err := g.allocatePorts(); err != nil {
+		return nil, err
+	}
+
+	tmplManifest, err := manifest.Load(g.templates, g.templateDir)
+	if err != nil {
+		return nil, err
+	}
+	vars, err := tmplManifest.Resolve(g.templateInput(), TemplateFuncs())
+	if err != nil {
+		return nil, fmt.Errorf("invalid template variables:\n%w", err)
+	}
+	return vars, nil
+}
+
+// allocatePorts gives the project the ports of the next registry index
+// wherever the options or an earlier Preview haven't set one
+func (g *Generator) allocatePorts() error {
+	needsFrontend := g.opts.IncludeFrontend && g.opts.FrontendPort == 0
+	if g.opts.APIPort != 0 && g.opts.DBPort != 0 && g.opts.RedisPort != 0 && !needsFrontend {
+		return nil
 	}
 
-	// Get next project index and allocate ports
 	nextIndex, err := g.registry.GetNextIndex()
 	if err != nil {
 		return fmt.Errorf("failed to get next project index: %w", err)
 	}
 
+	// Ports given in the options are kept, e.g. from an answers file
 	allocatedPorts := g.portMgr.AllocatePorts(nextIndex)
-	g.opts.APIPort = allocatedPorts.API
-	g.opts.DBPort = allocatedPorts.DB
-	g.opts.RedisPort = allocatedPorts.Redis
-	g.opts.FrontendPort = allocatedPorts.Frontend
+	for _, port := range []struct {
+		port      *int
+		allocated int
+	}{
+		{&g.opts.APIPort, allocatedPorts.API},
+		{&g.opts.DBPort, allocatedPorts.DB},
+		{&g.opts.RedisPort, allocatedPorts.Redis},
+	} {
+		if *port.port == 0 {
+			*port.port = port.allocated
+		}
+	}
+	if needsFrontend {
+		g.opts.FrontendPort = allocatedPorts.Frontend
+	}
+	return nil
+}
 
-	// Copy template
-	fmt.Printf("📁 Creating project directory '%s'...\n", g.opts.ProjectName)
-	if err := g.copyTemplate(); err != nil {
-		return fmt.Errorf("failed to copy template: %w", err)
+// render writes the project files: the templates with the entities, their
+// fields and relations applied, the OpenAPI document and a tidy Go module
+func (g *Generator) render() error {
+	vars, err := g.renderTemplate(g.templateDir, g.targetDir)
+	if err != nil {
+		return err
 	}
 
-	// Generate template variables
-	vars := g.generateTemplateVars()
+	// Point the template's go.mod and imports at the project's module path
+	if err := NewModuleRewriter(vars.String("ModuleName")).Apply(g.targetDir); err != nil {
+		return fmt.Errorf("failed to rewrite module path: %w", err)
+	}
 
-	// Process templates
-	fmt.Printf("🔧 Processing templates...\n")
-	replacer := NewReplacer(vars)
-	if err := replacer.ProcessDirectory(g.targetDir); err != nil {
-		return fmt.Errorf("failed to process templates: %w", err)
+	// Give the primary entity its fields
+	primary := g.opts.Entities[0]
+	if fields := g.entityFields(primary); len(fields) > 0 {
+		if err := NewFieldRewriter(primary, fields).Apply(g.targetDir); err != nil {
+			return fmt.Errorf("failed to apply fields of '%s': %w", primary, err)
+		}
 	}
 
-	// Rename template files
-	fmt.Printf("📝 Finalizing files...\n")
-	if err := replacer.RenameTemplateFiles(g.targetDir); err != nil {
-		return fmt.Errorf("failed to rename template files: %w", err)
+	// Add the remaining entities next to the primary one
+	scaffolder := NewEntityScaffolder(g.templates, g.templateDir, g.targetDir)
+	for i, entity := range g.opts.Entities[1:] {
+		fmt.Fprintf(os.Stderr, "🧩 Adding entity '%s'...\n", entity)
+		if err := scaffolder.Add(entity, g.entityFields(entity), g.opts.Entities[:i+1]); err != nil {
+			return fmt.Errorf("failed to add entity '%s': %w", entity, err)
+		}
+	}
+
+	// Relate the entities: join tables, nested listings and routes
+	if len(g.opts.Relations) > 0 {
+		fmt.Fprintf(os.Stderr, "🔗 Adding relations...\n")
+		if err := NewRelationWriter(g.targetDir, g.opts.Entities).Apply(g.opts.Relations); err != nil {
+			return err
+		}
+	}
+
+	// Fit the routes to an imported OpenAPI document
+	if g.opts.API != nil {
+		fmt.Fprintf(os.Stderr, "🧭 Fitting routes to the API...\n")
+		if err := NewAPIWriter(g.targetDir, g.opts.Entities, g.opts.API).Apply(); err != nil {
+			return fmt.Errorf("failed to fit routes: %w", err)
+		}
+		for _, op := range g.opts.API.Unmapped() {
+			if op.Entity == "" {
+				g.warnings.add("not generated, no entity to put it in: %s", op)
+				continue
+			}
+			g.warnings.add("not CRUD, answers 501 until implemented in internal/%s/handler.go: %s", NewEntityNames(op.Entity).Package, op)
+		}
+	}
+
+	// Imported DDL replaces the generated tables, and what it creates
+	// besides is dropped with them
+	for entity, sql := range g.opts.Migrations {
+		if err := g.replaceMigration(entity, sql, g.opts.DownMigrations[entity]); err != nil {
+			return err
+		}
+	}
+
+	// Describe the final routes and models at /openapi.json
+	fmt.Fprintf(os.Stderr, "📖 Writing the OpenAPI document...\n")
+	info := &SpecInfo{Title: g.opts.ProjectName, Description: vars.String("ProjectDescription"), Version: "1.0.0"}
+	if err := NewSpecWriter(g.targetDir, info).Apply(); err != nil {
+		return err
+	}
+
+	// Keep the schema so a later run can report what changed
+	if g.opts.SchemaFile != "" {
+		data, err := os.ReadFile(g.opts.SchemaFile)
+		if err != nil {
+			return fmt.Errorf("failed to read schema: %w", err)
+		}
+		name := g.opts.SchemaName
+		if name == "" {
+			name = schema.StoredName(g.opts.SchemaFile)
+		}
+		if err := os.WriteFile(filepath.Join(g.targetDir, name), data, 0644); err != nil {
+			return fmt.Errorf("failed to keep schema: %w", err)
+		}
 	}
 
-	// Rename entity directory
-	if err := replacer.RenameEntityDirectory(g.targetDir); err != nil {
-		return fmt.Errorf("failed to rename entity directory: %w", err)
+	// Render the frontend next to the server
+	if g.clientDir != "" {
+		fmt.Fprintf(os.Stderr, "🎨 Creating frontend '%s'...\n", g.clientDir)
+		if _, err := g.renderTemplate(FrontendTemplate, g.clientDir); err != nil {
+			return fmt.Errorf("failed to create frontend: %w", err)
+		}
+	}
+	if err := g.hooks.Run(hooks.AfterRender); err != nil {
+		return err
 	}
 
-	// Clean up Go imports
-	if err := replacer.CleanGoImports(g.targetDir); err != nil {
-		return fmt.Errorf("failed to clean Go imports: %w", err)
+	if g.untidy {
+		return nil
 	}
 
 	// Initialize Go module
-	fmt.Printf("🐹 Initializing Go module...\n")
-	if err := g.initGoModule(vars.ModuleName); err != nil {
+	fmt.Fprintf(os.Stderr, "🐹 Initializing Go module...\n")
+	if err := g.initGoModule(); err != nil {
 		return fmt.Errorf("failed to initialize Go module: %w", err)
 	}
+	return g.hooks.Run(hooks.AfterGoMod)
+}
 
-	// Initialize git repository
-	fmt.Printf("🔄 Initializing git repository...\n")
-	gitMgr := git.NewManager(g.targetDir)
-	if gitMgr.IsGitAvailable() {
-		if err := gitMgr.Initialize(g.opts.Config.Git.InitialCommitMessage); err != nil {
-			fmt.Printf("⚠️  Warning: failed to initialize git repository: %v\n", err)
+// newHooks collects the hooks of the templates, each run in its template's
+// output, and of the config, run in the project root. The variables of
+// every template are in their environment.
+func (g *Generator) newHooks() (*hooks.Runner, error) {
+	root, err := filepath.Abs(g.rootDir())
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve project path: %w", err)
+	}
+
+	vars := make(map[string]any)
+	templateHooks := make(map[string][]hooks.Hook)
+	for _, dir := range g.templateDirs() {
+		tmplManifest, err := manifest.Load(g.templates, dir)
+		if err != nil {
+			return nil, err
 		}
-	} else {
-		fmt.Printf("⚠️  Warning: git not found, skipping git initialization\n")
+		resolved, err := tmplManifest.Resolve(g.templateInput(), TemplateFuncs())
+		if err != nil {
+			return nil, fmt.Errorf("invalid template variables:\n%w", err)
+		}
+		for name, value := range resolved {
+			if _, ok := vars[name]; !ok {
+				vars[name] = value
+			}
+		}
+		templateHooks[dir] = tmplManifest.Hooks
 	}
 
-	// Register project
-	fmt.Printf("📋 Registering project...\n")
-	if err := g.registry.AddProject(g.opts.ProjectName, g.opts.Entity,
-		g.opts.APIPort, g.opts.DBPort, g.opts.RedisPort); err != nil {
-		return fmt.Errorf("failed to register project: %w", err)
+	runner := hooks.NewRunner(root, vars)
+	for _, dir := range g.templateDirs() {
+		outputDir := g.targetDir
+		if dir == FrontendTemplate {
+			outputDir = g.clientDir
+		}
+		absDir, err := filepath.Abs(outputDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve project path: %w", err)
+		}
+		runner.Add(templateHooks[dir], absDir)
+	}
+	runner.Add(g.opts.Config.Hooks, root)
+	return runner, nil
+}
+
+// rollback removes the half-written project after its generation failed,
+// so that the same name can be generated again
+func (g *Generator) rollback(err error) error {
+	fmt.Fprintf(os.Stderr, "🧹 Removing '%s'...\n", g.opts.ProjectName)
+	if removeErr := os.RemoveAll(g.opts.ProjectName); removeErr != nil {
+		return errors.Join(err, fmt.Errorf("failed to remove project: %w", removeErr))
+	}
+	return err
+}
+
+// entityFields returns an entity's declared fields plus the foreign keys of
+// its belongs_to relations; nil keeps the sample fields
+func (g *Generator) entityFields(entity string) []schema.Field {
+	fields := slices.Clone(g.opts.Fields[entity])
+	for _, r := range g.opts.Relations {
+		if r.Kind != schema.BelongsTo || r.Entity != entity {
+			continue
+		}
+		if len(fields) == 0 {
+			fields = slices.Clone(schema.SampleFields)
+		}
+		fields = append(fields, r.ForeignKeyField(NewEntityNames(r.Target).SnakePlural))
+	}
+	return fields
+}
+
+// replaceMigration overwrites the up migration creating an entity's table
+// and adds down, if any, to the end of its down migration
+func (g *Generator) replaceMigration(entity, up, down string) error {
+	pattern := filepath.Join(g.targetDir, "migrations", "*_create_"+TableName(entity)+"_table.up.sql")
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return err
+	}
+	if len(matches) != 1 {
+		return fmt.Errorf("expected one migration creating %s, found %d", TableName(entity), len(matches))
+	}
+	if err := os.WriteFile(matches[0], []byte(up), 0644); err != nil {
+		return fmt.Errorf("failed to write migration: %w", err)
+	}
+	if down == "" {
+		return nil
 	}
 
+	downFile := strings.TrimSuffix(matches[0], ".up.sql") + ".down.sql"
+	generated, err := os.ReadFile(downFile)
+	if err != nil {
+		return fmt.Errorf("failed to read migration: %w", err)
+	}
+	content := strings.TrimRight(string(generated), "\n") + "\n\n" + down
+	if err := os.WriteFile(downFile, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write migration: %w", err)
+	}
 	return nil
 }
 
-func (g *Generator) copyTemplate() error {
-	return filepath.Walk(g.templateDir, func(path string, info os.FileInfo, err error) error {
+// templateVersion returns the version a template's manifest declares
+func templateVersion(templates fs.FS, templateDir string) (string, error) {
+	tmplManifest, err := manifest.Load(templates, templateDir)
+	if err != nil {
+		return "", err
+	}
+	return tmplManifest.Version, nil
+}
+
+// templateDirs returns the templates this project is rendered from
+func (g *Generator) templateDirs() []string {
+	if g.clientDir != "" {
+		return []string{g.templateDir, FrontendTemplate}
+	}
+	return []string{g.templateDir}
+}
+
+// renderTemplate copies a template into targetDir and renders it: variables
+// are resolved against the template's manifest, files of disabled features
+// are left out and the sample entity is renamed.
+func (g *Generator) renderTemplate(templateDir, targetDir string) (manifest.Vars, error) {
+	// Resolve template variables against the template's manifest
+	tmplManifest, err := manifest.Load(g.templates, templateDir)
+	if err != nil {
+		return nil, err
+	}
+	vars, err := tmplManifest.Resolve(g.templateInput(), TemplateFuncs())
+	if err != nil {
+		return nil, fmt.Errorf("invalid template variables:\n%w", err)
+	}
+	g.rendered = append(g.rendered, renderedTemplate{dir: templateDir, manifest: tmplManifest, vars: vars, targetDir: targetDir})
+
+	// Copy template, leaving out the files of disabled features
+	excluded := tmplManifest.Excluded(vars)
+	if err := g.copyTemplate(templateDir, targetDir, excluded); err != nil {
+		return nil, fmt.Errorf("failed to copy template: %w", err)
+	}
+
+	// Process templates
+	fmt.Fprintf(os.Stderr, "🔧 Processing templates...\n")
+	replacer := NewReplacer(vars)
+	if err := replacer.ProcessDirectory(targetDir); err != nil {
+		return nil, fmt.Errorf("failed to process templates: %w", err)
+	}
+
+	// Rename template files
+	fmt.Fprintf(os.Stderr, "📝 Finalizing files...\n")
+	if err := replacer.RenameTemplateFiles(targetDir); err != nil {
+		return nil, fmt.Errorf("failed to rename template files: %w", err)
+	}
+
+	// Drop the wiring of the packages that were left out
+	if err := NewPackagePruner(excluded).Apply(targetDir); err != nil {
+		return nil, fmt.Errorf("failed to prune disabled features: %w", err)
+	}
+
+	// Rename the template's sample entity to the requested entity
+	renamer := NewEntityRenamer(TemplateEntity, g.opts.Entities[0])
+	if err := renamer.Apply(targetDir); err != nil {
+		return nil, fmt.Errorf("failed to rename entity: %w", err)
+	}
+
+	return vars, nil
+}
+
+func (g *Generator) copyTemplate(templateDir, targetDir string, excluded []string) error {
+	return fs.WalkDir(g.templates, templateDir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
-		// Calculate relative path
-		relPath, err := filepath.Rel(g.templateDir, path)
-		if err != nil {
-			return err
+		// Calculate relative path (template paths always use forward slashes)
+		relPath := strings.TrimPrefix(strings.TrimPrefix(path, templateDir), "/")
+		targetPath := filepath.Join(targetDir, filepath.FromSlash(relPath))
+
+		// The manifest describes the template, it isn't part of the output
+		if relPath == manifest.FileName {
+			return nil
 		}
 
-		targetPath := filepath.Join(g.targetDir, relPath)
+		if manifest.IsExcluded(relPath, excluded) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
 
-		if info.IsDir() {
+		if d.IsDir() {
 			// Create directory
-			return os.MkdirAll(targetPath, info.Mode())
+			return os.MkdirAll(targetPath, 0755)
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
 		}
 
-		// Copy file
-		return g.copyFile(path, targetPath, info.Mode())
+		// Copy file, keeping the executable bit of scripts
+		mode := os.FileMode(0644)
+		if info.Mode()&0111 != 0 {
+			mode = 0755
+		}
+		return g.copyFile(path, targetPath, mode)
 	})
 }
 
@@ -191,7 +618,7 @@ func (g *Generator) copyFile(src, dst string, mode os.FileMode) error {
 	}
 
 	// Read source file
-	data, err := os.ReadFile(src)
+	data, err := fs.ReadFile(g.templates, src)
 	if err != nil {
 		return err
 	}
@@ -200,55 +627,37 @@ func (g *Generator) copyFile(src, dst string, mode os.FileMode) error {
 	return os.WriteFile(dst, data, mode)
 }
 
-func (g *Generator) generateTemplateVars() *TemplateVars {
-	entityCapitalized, entityPlural := GenerateEntityNames(g.opts.Entity)
-
-	// Generate module name
-	moduleName := g.opts.Config.Defaults.ModulePrefix + g.opts.ProjectName
-
-	// Generate database name (replace hyphens with underscores)
-	dbName := strings.ReplaceAll(g.opts.ProjectName, "-", "_") + "_db"
-
-	return &TemplateVars{
-		ProjectName:        g.opts.ProjectName,
-		ModuleName:         moduleName,
-		PrimaryEntity:      g.opts.Entity,
-		EntityCapitalized:  entityCapitalized,
-		EntityPlural:       entityPlural,
-		APIPort:            fmt.Sprintf("%d", g.opts.APIPort),
-		DBPort:             fmt.Sprintf("%d", g.opts.DBPort),
-		RedisPort:          fmt.Sprintf("%d", g.opts.RedisPort),
-		DBName:             dbName,
-		DBUser:             g.opts.Config.Database.User,
-		DBPassword:         g.opts.Config.Database.Password,
-		IncludeAuth:        g.opts.IncludeAuth,
-		IncludeS3:          g.opts.IncludeS3,
-		IncludeRedis:       g.opts.IncludeRedis,
-		ProjectDescription: g.opts.ProjectDescription,
-	}
-}
-
-func (g *Generator) initGoModule(moduleName string) error {
-	originalDir, err := os.Getwd()
-	if err != nil {
-		return err
+// templateInput collects the values the generator knows about. The template
+// manifest decides which of them are used and derives the rest.
+func (g *Generator) templateInput() map[string]any {
+	input := map[string]any{
+		"ProjectName":     g.opts.ProjectName,
+		"PrimaryEntity":   g.opts.Entities[0],
+		"ModulePrefix":    g.opts.Config.Defaults.ModulePrefix,
+		"APIPort":         g.opts.APIPort,
+		"DBPort":          g.opts.DBPort,
+		"RedisPort":       g.opts.RedisPort,
+		"FrontendPort":    g.opts.FrontendPort,
+		"DBUser":          g.opts.Config.Database.User,
+		"DBPassword":      g.opts.Config.Database.Password,
+		"IncludeAuth":     g.opts.IncludeAuth,
+		"IncludeS3":       g.opts.IncludeS3,
+		"IncludeRedis":    g.opts.IncludeRedis,
+		"IncludeFrontend": g.opts.IncludeFrontend,
 	}
-
-	if err := os.Chdir(g.targetDir); err != nil {
-		return err
+	if g.opts.ProjectDescription != "" {
+		input["ProjectDescription"] = g.opts.ProjectDescription
 	}
-	defer os.Chdir(originalDir)
 
-	// Initialize Go module
-	cmd := exec.Command("go", "mod", "init", moduleName)
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to run 'go mod init': %w", err)
-	}
+	return input
+}
 
+func (g *Generator) initGoModule() error {
 	// Run go mod tidy
-	cmd = exec.Command("go", "mod", "tidy")
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to run 'go mod tidy': %w", err)
+	cmd := exec.Command("go", "mod", "tidy")
+	cmd.Dir = g.targetDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to run 'go mod tidy': %w\n%s", err, output)
 	}
 
 	return nil
@@ -258,4 +667,3 @@ func (g *Generator) initGoModule(moduleName string) error {
 func NewProjectRegistry(registryPath string) *registry.Manager {
 	return registry.NewManager(registryPath)
 }
-
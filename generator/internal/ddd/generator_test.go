@@ -0,0 +1,50 @@
+package ddd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	generator "github.com/darkphotonKN/go-template-generator"
+	"github.com/darkphotonKN/go-template-generator/internal/config"
+)
+
+// inTempDir runs the test in a temporary directory, where Generate writes
+func inTempDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+func TestGenerateRemovesProjectWhenRenderFails(t *testing.T) {
+	dir := inTempDir(t)
+	cfg, err := config.Parse(generator.DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Hooks = nil
+	cfg.ProjectsRegistry = filepath.Join(dir, "projects.json")
+
+	// A migration for an entity the project lacks fails the render late
+	opts := &GeneratorOptions{
+		ProjectName: "shop",
+		Entities:    []string{"product"},
+		Migrations:  map[string]string{"order": "CREATE TABLE orders ();"},
+		Config:      cfg,
+	}
+	if err := NewGenerator(opts).Generate(); err == nil {
+		t.Fatal("expected the render to fail")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "shop")); !os.IsNotExist(err) {
+		t.Fatalf("project directory left behind: %v", err)
+	}
+
+}
@@ -0,0 +1,287 @@
+package ddd
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/darkphotonKN/go-template-generator/internal/manifest"
+	"github.com/darkphotonKN/go-template-generator/templates"
+)
+
+// Checks the template linter runs on every file of a template
+const (
+	LintParse       = "parse"       // a rendered file isn't a valid template
+	LintUndefined   = "undefined"   // a variable the manifest doesn't declare
+	LintDenied      = "denied"      // text on the deny-list
+	LintUnprocessed = "unprocessed" // template actions in a file that is copied as is
+	LintStalePath   = "path"        // a package path the template lacks, or can't leave out
+)
+
+// LintChecks lists every check of the linter
+var LintChecks = []string{LintParse, LintUndefined, LintDenied, LintUnprocessed, LintStalePath}
+
+type LintOptions struct {
+	// Templates holds one directory per template; nil uses the templates
+	// embedded in the binary
+	Templates fs.FS
+
+	// Deny is text no template should contain, matched in any case: names
+	// left over from the projects the templates were extracted from
+	Deny []string
+}
+
+// LintProblem is something the linter found at a line of a template file
+type LintProblem struct {
+	Check   string
+	File    string // slash-separated, relative to the templates root
+	Line    int
+	Message string
+}
+
+func (p LintProblem) String() string {
+	if p.Line == 0 {
+		return fmt.Sprintf("%s: %s: %s", p.File, p.Check, p.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s: %s", p.File, p.Line, p.Check, p.Message)
+}
+
+// LintError lists every problem the linter found in the templates
+type LintError struct {
+	Problems []LintProblem
+}
+
+func (e *LintError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d problem(s) in the templates:", len(e.Problems))
+	for _, problem := range e.Problems {
+		fmt.Fprintf(&b, "\n  %s", problem)
+	}
+	return b.String()
+}
+
+// Lint checks every template, a directory with a manifest, returning their
+// names. The problems found are returned as a *LintError.
+func Lint(opts *LintOptions) ([]string, error) {
+	templatesFS := opts.Templates
+	if templatesFS == nil {
+		templatesFS = templates.FS
+	}
+
+	entries, err := fs.ReadDir(templatesFS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read templates: %w", err)
+	}
+	var names []string
+	var problems []LintProblem
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := fs.Stat(templatesFS, path.Join(entry.Name(), manifest.FileName)); err != nil {
+			continue
+		}
+		found, err := lintTemplate(templatesFS, entry.Name(), opts.Deny)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, entry.Name())
+		problems = append(problems, found...)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no templates (directories with a %s) found", manifest.FileName)
+	}
+	if len(problems) > 0 {
+		return names, &LintError{Problems: problems}
+	}
+	return names, nil
+}
+
+// lintTemplate checks the files of one template
+func lintTemplate(templatesFS fs.FS, dir string, deny []string) ([]LintProblem, error) {
+	tmplManifest, err := manifest.Load(templatesFS, dir)
+	if err != nil {
+		return nil, err
+	}
+	declared := make(map[string]bool)
+	for _, v := range tmplManifest.Variables {
+		declared[v.Name] = true
+	}
+
+	var problems []LintProblem
+	err = fs.WalkDir(templatesFS, dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || file == path.Join(dir, manifest.FileName) {
+			return err
+		}
+		data, err := fs.ReadFile(templatesFS, file)
+		if err != nil {
+			return err
+		}
+		if bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0 {
+			return nil // binary
+		}
+
+		rendered := isTemplateFile(file)
+		if rendered {
+			problems = append(problems, lintActions(file, string(data), declared)...)
+		}
+		for i, line := range strings.Split(string(data), "\n") {
+			at := func(check, format string, args ...any) {
+				problems = append(problems, LintProblem{Check: check, File: file, Line: i + 1, Message: fmt.Sprintf(format, args...)})
+			}
+			lower := strings.ToLower(line)
+			for _, word := range deny {
+				if word != "" && strings.Contains(lower, strings.ToLower(word)) {
+					at(LintDenied, "%q is on the deny-list", word)
+				}
+			}
+			if rendered {
+				continue
+			}
+			if loc := unrenderedAction.FindStringIndex(line); loc != nil {
+				at(LintUnprocessed, "template action %q in a file that isn't rendered (only .tmpl files and %s are)", action(line[loc[0]:]), strings.Join(renderedFiles, ", "))
+			}
+			if path.Ext(file) == ".go" {
+				continue // imports of optional packages are pruned
+			}
+			for _, match := range packagePath.FindAllStringSubmatch(line, -1) {
+				pkg := strings.TrimSuffix(match[1], "/")
+				if _, err := fs.Stat(templatesFS, path.Join(dir, pkg)); err != nil {
+					at(LintStalePath, "./%s doesn't exist in the template", pkg)
+				} else if rule, ok := fileRule(tmplManifest, pkg); ok {
+					at(LintStalePath, "./%s is only copied when %s, but this file isn't rendered so can't leave it out", pkg, rule.When)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to lint template %s: %w", dir, err)
+	}
+	return problems, nil
+}
+
+// packagePath matches a relative path to a package of a template, as in
+// "go test ./internal/auth"
+var packagePath = regexp.MustCompile(`\./(internal/[A-Za-z0-9_/-]+)`)
+
+// fileRule returns the rule that makes a package optional
+func fileRule(m *manifest.Manifest, pkg string) (manifest.FileRule, bool) {
+	for _, rule := range m.Files {
+		if manifest.IsExcluded(pkg, []string{rule.Path}) {
+			return rule, true
+		}
+	}
+	return manifest.FileRule{}, false
+}
+
+// action cuts a template action at its end
+func action(s string) string {
+	if end := strings.Index(s, "}}"); end >= 0 {
+		return s[:end+2]
+	}
+	return s
+}
+
+// lintActions parses a rendered file and reports the variables it uses that
+// the manifest doesn't declare
+func lintActions(file, text string, declared map[string]bool) []LintProblem {
+	tmpl, err := template.New(file).Funcs(TemplateFuncs()).Parse(text)
+	if err != nil {
+		problem := LintProblem{Check: LintParse, File: file, Message: err.Error()}
+		// Parse errors read "template: <file>:<line>: <message>"
+		rest := strings.TrimPrefix(err.Error(), "template: "+file+":")
+		if number, message, ok := strings.Cut(rest, ": "); ok {
+			if line, err := strconv.Atoi(number); err == nil {
+				problem.Line, problem.Message = line, message
+			}
+		}
+		return []LintProblem{problem}
+	}
+
+	var problems []LintProblem
+	seen := make(map[string]bool)
+	for _, t := range tmpl.Templates() {
+		if t.Tree == nil {
+			continue
+		}
+		tree := t.Tree
+		report := func(n parse.Node, name string) {
+			if declared[name] {
+				return
+			}
+			location, _ := tree.ErrorContext(n)
+			line := 0
+			// The location reads "<file>:<line>:<column>"
+			if parts := strings.Split(location, ":"); len(parts) >= 3 {
+				line, _ = strconv.Atoi(parts[len(parts)-2])
+			}
+			if key := fmt.Sprint(line, name); !seen[key] {
+				seen[key] = true
+				problems = append(problems, LintProblem{Check: LintUndefined, File: file, Line: line, Message: fmt.Sprintf("variable %s isn't declared in %s", name, manifest.FileName)})
+			}
+		}
+		walkFields(tree.Root, true, report)
+	}
+	slices.SortStableFunc(problems, func(a, b LintProblem) int { return a.Line - b.Line })
+	return problems
+}
+
+// walkFields calls report with each top-level variable a template node uses:
+// .Name where dot is the variables (atTop), and $.Name anywhere
+func walkFields(node parse.Node, atTop bool, report func(parse.Node, string)) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			walkFields(child, atTop, report)
+		}
+	case *parse.ActionNode:
+		walkFields(n.Pipe, atTop, report)
+	case *parse.IfNode:
+		walkFields(n.Pipe, atTop, report)
+		walkFields(n.List, atTop, report)
+		walkFields(n.ElseList, atTop, report)
+	case *parse.RangeNode:
+		// Dot is each element inside range and with
+		walkFields(n.Pipe, atTop, report)
+		walkFields(n.List, false, report)
+		walkFields(n.ElseList, atTop, report)
+	case *parse.WithNode:
+		walkFields(n.Pipe, atTop, report)
+		walkFields(n.List, false, report)
+		walkFields(n.ElseList, atTop, report)
+	case *parse.TemplateNode:
+		walkFields(n.Pipe, atTop, report)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			walkFields(cmd, atTop, report)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			walkFields(arg, atTop, report)
+		}
+	case *parse.ChainNode:
+		walkFields(n.Node, atTop, report)
+	case *parse.FieldNode:
+		if atTop {
+			report(n, n.Ident[0])
+		}
+	case *parse.VariableNode:
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			report(n, n.Ident[1])
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package ddd
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLint(t *testing.T) {
+	manifest := "name: web\nvariables:\n  - name: ProjectName\n  - name: IncludeS3\n    type: bool\nfiles:\n  - path: internal/s3\n    when: IncludeS3\n"
+	templates := fstest.MapFS{
+		"web/template.yaml":     {Data: []byte(manifest)},
+		"web/CLAUDE.md.tmpl":    {Data: []byte("# {{.ProjectTitle}}\n\n{{range .Items}}{{.Name}}{{end}} {{$.ProjectName}} {{$.Owner}}\n")},
+		"web/broken.yml.tmpl":   {Data: []byte("name: {{.ProjectName}}\nport: {{.Port\n")},
+		"web/Makefile":          {Data: []byte("# Cashflow Service\nbuild:\n\tgo build -o bin/{{.ProjectName}}\ntest:\n\tgo test ./internal/s3 ./internal/financial ./internal/app/...\n")},
+		"web/page.tsx":          {Data: []byte("export const Page = () => <div style={{ color: 'red' }} />\n")},
+		"web/internal/app/a.go": {Data: []byte("package app\n\nimport _ \"example.com/web/internal/s3\"\n")},
+		"web/internal/s3/s3.go": {Data: []byte("package s3\n")},
+		"testdata/x.tmpl":       {Data: []byte("{{.Undeclared}}\n")}, // not a template: no manifest
+	}
+
+	names, err := Lint(&LintOptions{Templates: templates, Deny: []string{"cashflow"}})
+	var lintErr *LintError
+	if !errors.As(err, &lintErr) {
+		t.Fatalf("expected a *LintError, got %v", err)
+	}
+	if !reflect.DeepEqual(names, []string{"web"}) {
+		t.Errorf("templates = %v, want [web]", names)
+	}
+
+	var got []string
+	for _, p := range lintErr.Problems {
+		got = append(got, p.String())
+	}
+	want := []string{
+		"web/CLAUDE.md.tmpl:1: undefined: variable ProjectTitle isn't declared in template.yaml",
+		"web/CLAUDE.md.tmpl:3: undefined: variable Items isn't declared in template.yaml",
+		"web/CLAUDE.md.tmpl:3: undefined: variable Owner isn't declared in template.yaml",
+		"web/Makefile:1: denied: \"cashflow\" is on the deny-list",
+		"web/Makefile:3: unprocessed: template action \"{{.ProjectName}}\" in a file that isn't rendered (only .tmpl files and docker-compose.yml, .env.example, CLAUDE.md are)",
+		"web/Makefile:5: path: ./internal/s3 is only copied when IncludeS3, but this file isn't rendered so can't leave it out",
+		"web/Makefile:5: path: ./internal/financial doesn't exist in the template",
+		"web/broken.yml.tmpl:3: parse: unclosed action started at web/broken.yml.tmpl:2",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("problems:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestLintEmbeddedTemplates(t *testing.T) {
+	if _, err := Lint(&LintOptions{Deny: []string{"cashflow", "internal/financial"}}); err != nil {
+		t.Error(err)
+	}
+}
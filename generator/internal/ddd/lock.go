@@ -0,0 +1,175 @@
+package ddd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/darkphotonKN/go-template-generator/internal/lockfile"
+	"github.com/darkphotonKN/go-template-generator/internal/manifest"
+	"github.com/darkphotonKN/go-template-generator/internal/registry"
+	"github.com/darkphotonKN/go-template-generator/internal/schema"
+)
+
+// renderedTemplate is a template the generator rendered, for the lockfile
+type renderedTemplate struct {
+	dir       string // in the templates filesystem
+	manifest  *manifest.Manifest
+	vars      manifest.Vars
+	targetDir string
+}
+
+// localProject is a generated project found on disk
+type localProject struct {
+	*registry.Project
+	Dir        string             // the API project
+	Root       string             // holds the lockfile and the git repository
+	Lock       *lockfile.Lockfile // nil for projects generated before lockfiles
+	Registered bool
+}
+
+// findProject returns the project at dir, which may also be the folder
+// holding <name>-server for full-stack projects. The project's lockfile
+// describes it; projects generated before lockfiles were written are looked
+// up in the registry.
+func findProject(registryMgr *registry.Manager, dir string) (*localProject, error) {
+	projectDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve project path: %w", err)
+	}
+
+	// Full-stack projects keep the API in <name>-server
+	serverDir := filepath.Join(projectDir, filepath.Base(projectDir)+"-server")
+	if isDir(serverDir) {
+		projectDir = serverDir
+	}
+
+	project := &localProject{Dir: projectDir, Root: projectRoot(projectDir)}
+	registered, findErr := registryMgr.Find(projectDir)
+	project.Registered = findErr == nil
+
+	lock, err := lockfile.Load(project.Root)
+	switch {
+	case err == nil:
+		project.Lock, project.Project = lock, projectFromLock(lock, projectDir)
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, err
+	case findErr != nil:
+		return nil, fmt.Errorf("%w at %s; run inside a project created by go-gen or point at one with --dir", ErrNoProject, projectDir)
+	default:
+		project.Project = registered
+	}
+	return project, nil
+}
+
+// projectRoot returns the folder around the API project of a full-stack
+// project, or the API project itself
+func projectRoot(projectDir string) string {
+	parent := filepath.Dir(projectDir)
+	if filepath.Base(projectDir) == filepath.Base(parent)+"-server" {
+		return parent
+	}
+	return projectDir
+}
+
+// rootDir returns the root of the project being rendered
+func (g *Generator) rootDir() string {
+	if g.clientDir != "" {
+		return filepath.Dir(g.targetDir)
+	}
+	return g.targetDir
+}
+
+// lock describes the render in rootDir: the templates and their variables,
+// the features, ports and entities, and the checksum of every file written
+func (g *Generator) lock() (*lockfile.Lockfile, error) {
+	root := g.rootDir()
+	lock := &lockfile.Lockfile{
+		Project: g.opts.ProjectName,
+		Features: lockfile.Features{
+			Auth:     g.opts.IncludeAuth,
+			S3:       g.opts.IncludeS3,
+			Redis:    g.opts.IncludeRedis,
+			Frontend: g.opts.IncludeFrontend,
+		},
+		Ports: lockfile.Ports{
+			API:      g.opts.APIPort,
+			DB:       g.opts.DBPort,
+			Redis:    g.opts.RedisPort,
+			Frontend: g.opts.FrontendPort,
+		},
+		Entities: lockEntities(g.opts.Entities, g.opts.Fields, g.opts.Relations),
+	}
+
+	for _, t := range g.rendered {
+		hash, err := lockfile.HashFS(g.templates, t.dir)
+		if err != nil {
+			return nil, err
+		}
+		dir, err := filepath.Rel(root, t.targetDir)
+		if err != nil {
+			return nil, err
+		}
+		lock.Templates = append(lock.Templates, lockfile.Template{
+			Name:      t.dir,
+			Version:   t.manifest.Version,
+			Hash:      hash,
+			Dir:       filepath.ToSlash(dir),
+			Variables: t.manifest.Public(t.vars),
+		})
+	}
+
+	files, err := lockfile.Checksums(root)
+	if err != nil {
+		return nil, err
+	}
+	lock.Files = files
+	return lock, nil
+}
+
+// lockEntities returns the entities with their fields and relations in the
+// schema form the lockfile records them in
+func lockEntities(names []string, fields map[string][]schema.Field, relations []schema.Relation) []schema.Entity {
+	s := &schema.Schema{Entities: make([]schema.Entity, len(names))}
+	for i, name := range names {
+		s.Entities[i] = schema.Entity{Name: name, Fields: fields[name]}
+	}
+	for _, r := range relations {
+		ref := schema.Reference{Entity: r.Target}
+		if r.Kind == schema.BelongsTo {
+			ref.OnDelete, ref.Optional = r.OnDelete, r.Optional
+		}
+		// Relations of entities that aren't listed can't be recorded
+		_ = s.AddRelation(r.Entity, r.Kind, ref)
+	}
+	return s.Entities
+}
+
+// projectFromLock returns a lockfile's project in the form the registry
+// records it
+func projectFromLock(lock *lockfile.Lockfile, projectDir string) *registry.Project {
+	s := lock.Schema()
+	project := &registry.Project{
+		Name:         lock.Project,
+		APIPort:      lock.Ports.API,
+		DBPort:       lock.Ports.DB,
+		RedisPort:    lock.Ports.Redis,
+		FrontendPort: lock.Ports.Frontend,
+		Entities:     s.EntityNames(),
+		Path:         projectDir,
+		Fields:       s.Fields(),
+		Relations:    s.Relations(),
+	}
+	if len(project.Entities) > 0 {
+		project.Entity = project.Entities[0]
+	}
+
+	if t := lock.Template(APITemplate); t != nil {
+		project.Template, project.TemplateVersion = t.Name, t.Version
+		if description, ok := t.Variables["ProjectDescription"].(string); ok {
+			project.Description = description
+		}
+	}
+	return project
+}
@@ -0,0 +1,81 @@
+package ddd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/darkphotonKN/go-template-generator/internal/lockfile"
+	"github.com/darkphotonKN/go-template-generator/internal/registry"
+	"github.com/darkphotonKN/go-template-generator/internal/schema"
+)
+
+func TestLockEntitiesRoundTrip(t *testing.T) {
+	fields := map[string][]schema.Field{
+		"customer": {{Name: "name", Type: "string"}},
+		"order":    {{Name: "total", Type: "decimal"}},
+	}
+	relations := []schema.Relation{
+		{Kind: schema.BelongsTo, Entity: "order", Target: "customer", OnDelete: schema.OnDeleteCascade},
+		{Kind: schema.ManyToMany, Entity: "order", Target: "tag"},
+	}
+	lock := &lockfile.Lockfile{
+		Project:  "shop",
+		Ports:    lockfile.Ports{API: 8010, DB: 5442, Redis: 6389, Frontend: 3010},
+		Entities: lockEntities([]string{"customer", "order", "tag"}, fields, relations),
+		Templates: []lockfile.Template{{
+			Name:      APITemplate,
+			Version:   "1.2.0",
+			Variables: map[string]any{"ProjectDescription": "Shop API"},
+		}},
+	}
+
+	project := projectFromLock(lock, "/work/shop/shop-server")
+	want := &registry.Project{
+		Name:            "shop",
+		APIPort:         8010,
+		DBPort:          5442,
+		RedisPort:       6389,
+		FrontendPort:    3010,
+		Entity:          "customer",
+		Entities:        []string{"customer", "order", "tag"},
+		Path:            "/work/shop/shop-server",
+		Template:        APITemplate,
+		TemplateVersion: "1.2.0",
+		Description:     "Shop API",
+		Fields:          map[string][]schema.Field{"customer": fields["customer"], "order": fields["order"], "tag": nil},
+		Relations:       relations,
+	}
+	if !reflect.DeepEqual(project, want) {
+		t.Errorf("projectFromLock = %+v,\nwant %+v", project, want)
+	}
+}
+
+func TestFindProjectFromLockfile(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "shop")
+	serverDir := filepath.Join(root, "shop-server")
+	if err := os.MkdirAll(serverDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	lock := &lockfile.Lockfile{Project: "shop", Entities: []schema.Entity{{Name: "order"}}}
+	if err := lock.Save(root); err != nil {
+		t.Fatal(err)
+	}
+
+	// Not in the registry: the lockfile alone describes the project
+	registryMgr := registry.NewManager(filepath.Join(t.TempDir(), "projects.json"))
+	for _, dir := range []string{root, serverDir} {
+		project, err := findProject(registryMgr, dir)
+		if err != nil {
+			t.Fatalf("findProject(%s) failed: %v", dir, err)
+		}
+		if project.Dir != serverDir || project.Root != root || project.Registered || project.Name != "shop" {
+			t.Errorf("findProject(%s) = dir %s, root %s, registered %v, name %q", dir, project.Dir, project.Root, project.Registered, project.Name)
+		}
+	}
+
+	if _, err := findProject(registryMgr, t.TempDir()); err == nil {
+		t.Errorf("Expected a directory without lockfile or registration to fail")
+	}
+}
@@ -0,0 +1,147 @@
+package ddd
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// ModuleRewriter moves a copied template from the module path declared in its
+// go.mod to the generated project's module path, rewriting every import that
+// points inside the old module.
+type ModuleRewriter struct {
+	modulePath string
+}
+
+func NewModuleRewriter(modulePath string) *ModuleRewriter {
+	return &ModuleRewriter{modulePath: modulePath}
+}
+
+// Apply rewrites go.mod and all Go imports under projectPath
+func (m *ModuleRewriter) Apply(projectPath string) error {
+	oldPath, err := m.RewriteGoMod(filepath.Join(projectPath, "go.mod"))
+	if err != nil {
+		return err
+	}
+
+	if oldPath == m.modulePath {
+		return nil
+	}
+
+	return m.RewriteImports(projectPath, oldPath)
+}
+
+// RewriteGoMod replaces the module line of a go.mod file and returns the previous module path
+func (m *ModuleRewriter) RewriteGoMod(goModPath string) (string, error) {
+	data, err := os.ReadFile(goModPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", goModPath, err)
+	}
+
+	file, err := modfile.Parse(goModPath, data, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %s: %w", goModPath, err)
+	}
+	if file.Module == nil {
+		return "", fmt.Errorf("%s has no module directive", goModPath)
+	}
+
+	oldPath := file.Module.Mod.Path
+	if err := file.AddModuleStmt(m.modulePath); err != nil {
+		return "", fmt.Errorf("failed to set module path: %w", err)
+	}
+
+	out, err := file.Format()
+	if err != nil {
+		return "", fmt.Errorf("failed to format %s: %w", goModPath, err)
+	}
+
+	if err := os.WriteFile(goModPath, out, 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", goModPath, err)
+	}
+
+	return oldPath, nil
+}
+
+// RewriteImports rewrites imports of oldPath (and its packages) in every Go file under projectPath
+func (m *ModuleRewriter) RewriteImports(projectPath, oldPath string) error {
+	return filepath.Walk(projectPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if info.Name() == ".git" || info.Name() == "vendor" || info.Name() == "node_modules" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read file %s: %w", path, err)
+		}
+
+		out, changed, err := m.RewriteSource(path, content, oldPath)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			return nil
+		}
+
+		if err := os.WriteFile(path, out, info.Mode()); err != nil {
+			return fmt.Errorf("failed to write file %s: %w", path, err)
+		}
+
+		return nil
+	})
+}
+
+// RewriteSource rewrites the imports of a single Go source file. The file is
+// only reformatted when at least one import changed.
+func (m *ModuleRewriter) RewriteSource(filename string, src []byte, oldPath string) ([]byte, bool, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+
+	changed := false
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		if importPath != oldPath && !strings.HasPrefix(importPath, oldPath+"/") {
+			continue
+		}
+
+		spec.Path.Value = strconv.Quote(m.modulePath + strings.TrimPrefix(importPath, oldPath))
+		changed = true
+	}
+
+	if !changed {
+		return src, false, nil
+	}
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return nil, false, fmt.Errorf("failed to format %s: %w", filename, err)
+	}
+
+	return buf.Bytes(), true, nil
+}
@@ -0,0 +1,57 @@
+package ddd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestModuleRewriterApply(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module github.com/example/template\n\ngo 1.21\n\nrequire github.com/google/uuid v1.6.0\n",
+		"cmd/main.go": `package main
+
+import (
+	"github.com/example/template/config"
+	"github.com/example/template-extras/other"
+	"github.com/google/uuid"
+)
+
+func main() { config.Run(other.X, uuid.New()) }
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := NewModuleRewriter("github.com/acme/shop").Apply(dir); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	goMod, _ := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if !strings.HasPrefix(string(goMod), "module github.com/acme/shop\n") {
+		t.Errorf("Expected rewritten module line, got:\n%s", goMod)
+	}
+	if !strings.Contains(string(goMod), "require github.com/google/uuid v1.6.0") {
+		t.Errorf("Expected requirements to be preserved, got:\n%s", goMod)
+	}
+
+	mainGo, _ := os.ReadFile(filepath.Join(dir, "cmd/main.go"))
+	for _, want := range []string{
+		`"github.com/acme/shop/config"`,
+		`"github.com/example/template-extras/other"`,
+		`"github.com/google/uuid"`,
+	} {
+		if !strings.Contains(string(mainGo), want) {
+			t.Errorf("Expected main.go to contain %s, got:\n%s", want, mainGo)
+		}
+	}
+}
@@ -0,0 +1,439 @@
+package ddd
+
+import (
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/darkphotonKN/go-template-generator/internal/manifest"
+	"github.com/darkphotonKN/go-template-generator/templates"
+	"golang.org/x/mod/modfile"
+)
+
+// NameError is everything wrong with a project or entity name, with a name
+// that would work when one can be derived
+type NameError struct {
+	Kind       string // project or entity
+	Name       string
+	Problems   []string
+	Suggestion string
+}
+
+func (e *NameError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "invalid %s name '%s':", e.Kind, e.Name)
+	for _, problem := range e.Problems {
+		fmt.Fprintf(&b, "\n  %s", problem)
+	}
+	if e.Suggestion != "" {
+		fmt.Fprintf(&b, "\n  try '%s' instead", e.Suggestion)
+	}
+	return b.String()
+}
+
+// maxIdentifier is the longest identifier Postgres keeps; longer database
+// names are silently truncated
+const maxIdentifier = 63
+
+// windowsReserved are device names Windows refuses as file names, with or
+// without an extension; module paths reject them for the same reason
+var windowsReserved = []string{
+	"con", "prn", "aux", "nul",
+	"com1", "com2", "com3", "com4", "com5", "com6", "com7", "com8", "com9",
+	"lpt1", "lpt2", "lpt3", "lpt4", "lpt5", "lpt6", "lpt7", "lpt8", "lpt9",
+}
+
+var goKeywords = []string{
+	"break", "case", "chan", "const", "continue", "default", "defer", "else",
+	"fallthrough", "for", "func", "go", "goto", "if", "import", "interface",
+	"map", "package", "range", "return", "select", "struct", "switch", "type", "var",
+}
+
+var goPredeclared = []string{
+	"any", "bool", "byte", "comparable", "complex64", "complex128", "error",
+	"float32", "float64", "int", "int8", "int16", "int32", "int64", "rune",
+	"string", "uint", "uint8", "uint16", "uint32", "uint64", "uintptr",
+	"true", "false", "iota", "nil",
+	"append", "cap", "clear", "close", "complex", "copy", "delete", "imag",
+	"len", "make", "max", "min", "new", "panic", "print", "println", "real", "recover",
+}
+
+// sqlReserved are the Postgres keywords that can't name a table unquoted
+var sqlReserved = []string{
+	"all", "analyse", "analyze", "and", "any", "array", "as", "asc", "asymmetric",
+	"authorization", "binary", "both", "case", "cast", "check", "collate",
+	"collation", "column", "concurrently", "constraint", "create", "cross",
+	"current_catalog", "current_date", "current_role", "current_schema",
+	"current_time", "current_timestamp", "current_user", "default", "deferrable",
+	"desc", "distinct", "do", "else", "end", "except", "false", "fetch", "for",
+	"foreign", "freeze", "from", "full", "grant", "group", "having", "ilike", "in",
+	"initially", "inner", "intersect", "into", "is", "isnull", "join", "lateral",
+	"leading", "left", "like", "limit", "localtime", "localtimestamp", "natural",
+	"not", "notnull", "null", "offset", "on", "only", "or", "order", "outer",
+	"overlaps", "placing", "primary", "references", "returning", "right", "select",
+	"session_user", "similar", "some", "symmetric", "system_user", "table",
+	"tablesample", "then", "to", "trailing", "true", "union", "unique", "user",
+	"using", "variadic", "verbose", "when", "where", "window", "with",
+}
+
+// entitySynonyms are suggested for entity names that are reserved somewhere
+var entitySynonyms = map[string]string{
+	"type":    "category",
+	"func":    "function",
+	"map":     "location",
+	"range":   "span",
+	"default": "preset",
+	"select":  "selection",
+	"error":   "issue",
+	"string":  "text",
+	"config":  "setting",
+	"auth":    "credential",
+	"cache":   "snapshot",
+}
+
+// CheckProjectName validates a project name against the rules of everything
+// it ends up in (module path, directory, database and container names) and
+// then the API template's manifest; nil templates are the embedded ones
+func CheckProjectName(templatesFS fs.FS, name string) error {
+	if templatesFS == nil {
+		templatesFS = templates.FS
+	}
+	return checkProjectName(templatesFS, APITemplate, name)
+}
+
+func checkProjectName(templates fs.FS, templateDir, name string) error {
+	tmplManifest, err := manifest.Load(templates, templateDir)
+	if err != nil {
+		return err
+	}
+
+	problems := projectNameProblems(name)
+	if len(problems) == 0 {
+		if err := tmplManifest.Check("ProjectName", name); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+
+	nameErr := &NameError{Kind: "project", Name: name, Problems: problems}
+	if suggestion := suggestProjectName(name); suggestion != name &&
+		len(projectNameProblems(suggestion)) == 0 && tmplManifest.Check("ProjectName", suggestion) == nil {
+		nameErr.Suggestion = suggestion
+	}
+	return nameErr
+}
+
+// projectNameProblems lists every rule a project name breaks. The name is
+// the Go module's last path element, the directory, the base of the
+// database name and the prefix of the Docker container names.
+func projectNameProblems(name string) []string {
+	if name == "" {
+		return []string{"the name is empty"}
+	}
+
+	var problems []string
+	var separators, spaces, upper bool
+	var unsupported []string
+	for _, r := range name {
+		switch {
+		case r == '/' || r == '\\':
+			separators = true
+		case r == ' ' || r == '\t':
+			spaces = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+		default:
+			if q := fmt.Sprintf("%q", r); !slices.Contains(unsupported, q) {
+				unsupported = append(unsupported, q)
+			}
+		}
+	}
+	if separators {
+		problems = append(problems, "contains a path separator, so it isn't a single directory")
+	}
+	if spaces {
+		problems = append(problems, "contains spaces, which Go module paths and Docker container names don't allow")
+	}
+	if upper {
+		problems = append(problems, "has upper-case letters, which Docker Compose project names don't allow")
+	}
+	if len(unsupported) > 0 {
+		problems = append(problems, fmt.Sprintf("contains %s, which Go module paths or Docker container names don't allow", strings.Join(unsupported, ", ")))
+	}
+
+	if strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".") {
+		problems = append(problems, "starts or ends with a dot, which Go module path elements can't")
+	}
+	if base, _, _ := strings.Cut(strings.ToLower(name), "."); slices.Contains(windowsReserved, base) {
+		problems = append(problems, fmt.Sprintf("'%s' is a reserved device name on Windows, which Go module paths reject", base))
+	}
+
+	dbName := NewEntityNames(name).Snake + "_db"
+	if c := name[0]; c < 'a' || c > 'z' {
+		if c < 'A' || c > 'Z' {
+			problems = append(problems, fmt.Sprintf("doesn't start with a letter, which the database name '%s' must", dbName))
+		}
+	}
+	if len(dbName) > maxIdentifier {
+		problems = append(problems, fmt.Sprintf("is too long: the database name '%s' is %d bytes and Postgres allows %d", dbName, len(dbName), maxIdentifier))
+	}
+
+	return problems
+}
+
+// nonWordChars are runs of anything suggestions replace with a separator
+var nonWordChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// suggestProjectName turns a name into lower-case words joined by hyphens
+// that fit the database name limit
+func suggestProjectName(name string) string {
+	suggestion := strings.Trim(nonWordChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	suggestion = strings.TrimLeft(suggestion, "0123456789-")
+	if limit := maxIdentifier - len("_db"); len(suggestion) > limit {
+		suggestion = strings.TrimRight(suggestion[:limit], "-")
+	}
+	return suggestion
+}
+
+// CheckEntities validates entity names as create does; nil templates are
+// the embedded ones
+func CheckEntities(templatesFS fs.FS, entities []string) error {
+	if templatesFS == nil {
+		templatesFS = templates.FS
+	}
+	return checkEntities(templatesFS, APITemplate, entities)
+}
+
+// checkEntities validates entity names against Go, SQL, the template's own
+// packages and its manifest, and rejects duplicates and entities that would
+// share a Go package, such as order_item and orderitem. Every problem found
+// is reported, not just the first.
+func checkEntities(templates fs.FS, templateDir string, entities []string) error {
+	if len(entities) == 0 {
+		return fmt.Errorf("at least one entity is required")
+	}
+
+	tmplManifest, err := manifest.Load(templates, templateDir)
+	if err != nil {
+		return err
+	}
+	packages, err := templatePackages(templates, templateDir)
+	if err != nil {
+		return err
+	}
+	imports, err := templateImports(templates, templateDir)
+	if err != nil {
+		return err
+	}
+	check := func(entity string) []string {
+		problems := entityNameProblems(entity, packages, imports)
+		if len(problems) == 0 {
+			if err := tmplManifest.Check("PrimaryEntity", entity); err != nil {
+				problems = append(problems, err.Error())
+			}
+		}
+		return problems
+	}
+
+	var errs []error
+	seen := make(map[string]string)
+	for _, entity := range entities {
+		if problems := check(entity); len(problems) > 0 {
+			nameErr := &NameError{Kind: "entity", Name: entity, Problems: problems}
+			for _, suggestion := range suggestEntityNames(entity) {
+				if len(check(suggestion)) == 0 && !slices.Contains(entities, suggestion) {
+					nameErr.Suggestion = suggestion
+					break
+				}
+			}
+			errs = append(errs, nameErr)
+			continue
+		}
+
+		pkg := NewEntityNames(entity).Package
+		if other, ok := seen[pkg]; ok {
+			if other == entity {
+				errs = append(errs, fmt.Errorf("duplicate entity '%s'", entity))
+			} else {
+				errs = append(errs, fmt.Errorf("entities '%s' and '%s' would both use package '%s'", other, entity, pkg))
+			}
+			continue
+		}
+		seen[pkg] = entity
+	}
+
+	return errors.Join(errs...)
+}
+
+// entityNameProblems lists every rule an entity name breaks. The name
+// becomes a Go package and variables in generated code, next to the
+// packages the template imports, a Postgres table and a directory next to
+// the template's own packages.
+func entityNameProblems(entity string, packages, imports map[string]string) []string {
+	if entity == "" {
+		return []string{"the name is empty"}
+	}
+
+	var problems []string
+	if c := entity[0]; c < 'a' || c > 'z' {
+		problems = append(problems, "doesn't start with a lower-case letter")
+	}
+	if strings.ContainsFunc(entity, func(r rune) bool {
+		return (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '_'
+	}) {
+		problems = append(problems, "may only contain lower-case letters, digits and underscores")
+	}
+	if len(problems) > 0 {
+		return problems
+	}
+
+	names := NewEntityNames(entity)
+	identifiers := []struct{ what, name string }{
+		{"package", names.Package},
+		{"variable", names.LowerCamel()},
+		{"variable", names.LowerCamelPlural()},
+	}
+	var reported []string
+	for _, id := range identifiers {
+		if slices.Contains(reported, id.name) {
+			continue
+		}
+		reported = append(reported, id.name)
+		switch {
+		case slices.Contains(goKeywords, id.name):
+			problems = append(problems, fmt.Sprintf("the Go %s '%s' would be a keyword", id.what, id.name))
+		case slices.Contains(goPredeclared, id.name):
+			problems = append(problems, fmt.Sprintf("the Go %s '%s' would shadow a predeclared identifier", id.what, id.name))
+		case imports[id.name] != "" && packages[id.name] == "": // the template's own are reported below
+			problems = append(problems, fmt.Sprintf("the Go %s '%s' would shadow the package %q the template imports", id.what, id.name, imports[id.name]))
+		}
+	}
+	if dir, ok := packages[names.Package]; ok {
+		problems = append(problems, fmt.Sprintf("the package '%s' would clash with the template's %s/", names.Package, dir))
+	}
+	if table := names.SnakePlural; slices.Contains(sqlReserved, table) {
+		problems = append(problems, fmt.Sprintf("the table '%s' would be an SQL reserved word", table))
+	}
+
+	return problems
+}
+
+// suggestEntityNames returns names to try, best first, for a rejected entity
+func suggestEntityNames(entity string) []string {
+	base := strings.Trim(nonWordChars.ReplaceAllString(strings.ToLower(entity), "_"), "_")
+	base = strings.TrimLeft(base, "0123456789_")
+	if base == "" {
+		return nil
+	}
+
+	var suggestions []string
+	if base != entity {
+		suggestions = append(suggestions, base)
+	}
+	if synonym, ok := entitySynonyms[base]; ok {
+		suggestions = append(suggestions, synonym)
+	}
+	return append(suggestions, base+"_record")
+}
+
+// templatePackageFile matches the package clause of a template Go file
+var templatePackageFile = regexp.MustCompile(`(?m)^package (\w+)`)
+
+// templatePackages maps the Go package and directory names a template
+// already uses to their directory, except the sample entity's, which
+// generated entities replace
+func templatePackages(templates fs.FS, templateDir string) (map[string]string, error) {
+	sample := NewEntityNames(TemplateEntity).Package
+	packages := make(map[string]string)
+	err := fs.WalkDir(templates, templateDir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || path.Ext(file) != ".go" {
+			return nil
+		}
+		dir := strings.TrimPrefix(path.Dir(file), templateDir+"/")
+		if dir == templateDir || slices.Contains(strings.Split(dir, "/"), sample) {
+			return nil
+		}
+
+		for _, name := range strings.Split(dir, "/") {
+			if _, ok := packages[name]; !ok && name != "internal" {
+				packages[name] = dir
+			}
+		}
+		data, err := fs.ReadFile(templates, file)
+		if err != nil {
+			return err
+		}
+		if m := templatePackageFile.FindSubmatch(data); m != nil && string(m[1]) != "main" {
+			packages[string(m[1])] = dir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template packages: %w", err)
+	}
+	return packages, nil
+}
+
+// templateImports maps the names the template's Go files import packages
+// under to their import path; an entity package or variable of the same
+// name would shadow the package in the files using it. The template's own
+// packages are left to templatePackages.
+func templateImports(templates fs.FS, templateDir string) (map[string]string, error) {
+	templateGoMod, err := fs.ReadFile(templates, path.Join(templateDir, "go.mod.tmpl"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template go.mod: %w", err)
+	}
+	templateModule := modfile.ModulePath(templateGoMod)
+
+	imports := make(map[string]string)
+	err = fs.WalkDir(templates, templateDir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || path.Ext(file) != ".go" {
+			return nil
+		}
+		data, err := fs.ReadFile(templates, file)
+		if err != nil {
+			return err
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), file, data, parser.ImportsOnly)
+		if err != nil {
+			return err
+		}
+		for _, spec := range f.Imports {
+			importPath, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				return err
+			}
+			if importPath == templateModule || strings.HasPrefix(importPath, templateModule+"/") {
+				continue
+			}
+			name := importName(importPath)
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			if name != "_" && name != "." {
+				imports[name] = importPath
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template imports: %w", err)
+	}
+	return imports, nil
+}
@@ -0,0 +1,105 @@
+package ddd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckProjectName(t *testing.T) {
+	tests := []struct {
+		name       string
+		problems   []string
+		suggestion string
+	}{
+		{name: "shop-api"},
+		{name: "My App", problems: []string{"contains spaces", "upper-case letters"}, suggestion: "my-app"},
+		{name: "acme/shop", problems: []string{"path separator"}, suggestion: "acme-shop"},
+		{name: "1shop", problems: []string{"start with a letter"}, suggestion: "shop"},
+		{name: "con", problems: []string{"reserved device name"}},
+		{name: "shop_api", problems: []string{"does not match"}, suggestion: "shop-api"},
+		{name: strings.Repeat("a", 61), problems: []string{"too long"}, suggestion: strings.Repeat("a", 60)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckProjectName(nil, tt.name)
+			checkNameError(t, err, tt.problems, tt.suggestion)
+		})
+	}
+}
+
+func TestCheckEntities(t *testing.T) {
+	tests := []struct {
+		entity     string
+		problems   []string
+		suggestion string
+	}{
+		{entity: "order"}, // its table is orders
+		{entity: "user"},
+		{entity: "type", problems: []string{"keyword"}, suggestion: "category"},
+		{entity: "new", problems: []string{"predeclared"}, suggestion: "new_record"},
+		{entity: "config", problems: []string{"template's config/"}, suggestion: "setting"},
+		{entity: "middleware", problems: []string{"template's internal/middleware/"}, suggestion: "middleware_record"},
+		{entity: "utils", problems: []string{"template's internal/utils/"}, suggestion: "utils_record"},
+		// Packages the template imports
+		{entity: "errors", problems: []string{`package "errors" the template imports`}, suggestion: "errors_record"},
+		{entity: "fmt", problems: []string{`package "fmt" the template imports`}, suggestion: "fmt_record"},
+		{entity: "gin", problems: []string{`package "github.com/gin-gonic/gin" the template imports`}, suggestion: "gin_record"},
+		{entity: "slog", problems: []string{`package "log/slog" the template imports`}, suggestion: "slog_record"},
+		{entity: "sqlx", problems: []string{`package "github.com/jmoiron/sqlx" the template imports`}, suggestion: "sqlx_record"},
+		{entity: "redis", problems: []string{`package "github.com/redis/go-redis/v9" the template imports`}, suggestion: "redis_record"},
+		{entity: "Blog-Post", problems: []string{"lower-case letter", "lower-case letters, digits and underscores"}, suggestion: "blog_post"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.entity, func(t *testing.T) {
+			err := CheckEntities(nil, []string{tt.entity})
+			checkNameError(t, err, tt.problems, tt.suggestion)
+		})
+	}
+}
+
+func TestCheckEntitiesReportsAllProblems(t *testing.T) {
+	err := CheckEntities(nil, []string{"type", "order", "order", "order_item", "orderitem", "func"})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{
+		"invalid entity name 'type'",
+		"duplicate entity 'order'",
+		"entities 'order_item' and 'orderitem' would both use package 'orderitem'",
+		"invalid entity name 'func'",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not mention %q:\n%v", want, err)
+		}
+	}
+}
+
+func checkNameError(t *testing.T, err error, problems []string, suggestion string) {
+	t.Helper()
+	if len(problems) == 0 {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return
+	}
+
+	var nameErr *NameError
+	if !errors.As(err, &nameErr) {
+		t.Fatalf("expected a NameError, got %v", err)
+	}
+	all := strings.Join(nameErr.Problems, "\n")
+	for _, want := range problems {
+		if !strings.Contains(all, want) {
+			t.Errorf("problems do not mention %q:\n%s", want, all)
+		}
+	}
+	if len(nameErr.Problems) != len(problems) {
+		t.Errorf("got %d problems, want %d:\n%s", len(nameErr.Problems), len(problems), all)
+	}
+	if nameErr.Suggestion != suggestion {
+		t.Errorf("suggestion = %q, want %q", nameErr.Suggestion, suggestion)
+	}
+}
@@ -0,0 +1,201 @@
+package ddd
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/darkphotonKN/go-template-generator/internal/schema"
+)
+
+var (
+	apiGroup   = regexp.MustCompile(`^(\s*api := router\.Group\()"[^"]*"(\).*)$`)
+	routeGroup = regexp.MustCompile(`^(\s*)(\w+) := api\.Group\("([^"]*)"\)$`)
+	routeLine  = regexp.MustCompile(`^(\s*)(\w+)\.(GET|POST|PUT|PATCH|DELETE)\("([^"]*)", ([\w.]+)\)$`)
+)
+
+// APIWriter fits the generated route table to an imported OpenAPI
+// document: its base path, collection segments and methods. Generated
+// routes the document doesn't list are dropped, and operations on an
+// entity that aren't CRUD get a handler answering 501 to fill in.
+type APIWriter struct {
+	*RelationWriter
+	api *schema.API
+}
+
+func NewAPIWriter(projectDir string, entities []string, api *schema.API) *APIWriter {
+	return &APIWriter{RelationWriter: NewRelationWriter(projectDir, entities), api: api}
+}
+
+// stub is a handler for an operation that doesn't map to CRUD
+type stub struct {
+	Name      string
+	Operation string
+}
+
+// Apply rewrites the routes and adds the stubs
+func (w *APIWriter) Apply() error {
+	// The document's routes, keyed by the generated route they replace
+	routes := make(map[string][]string)
+	for _, op := range w.api.Operations {
+		if op.Entity == "" {
+			continue
+		}
+		entity := NewEntityNames(op.Entity)
+		segments := strings.FieldsFunc(op.Path, func(r rune) bool { return r == '/' })[1:]
+
+		method, path := generatedRoute(op)
+		if op.Action == "" {
+			name, err := w.addStub(entity, op)
+			if err != nil {
+				return err
+			}
+			method, path = op.Method, ginPath(segments, nil)
+			route := fmt.Sprintf("%s.%s(%q, %sHandler.%s)", entity.LowerCamelPlural(), method, path, entity.LowerCamel(), name)
+			if err := w.addRoutes(entity, route); err != nil {
+				return err
+			}
+		}
+
+		key := entity.LowerCamelPlural() + " " + method + " " + path
+		routes[key] = append(routes[key], op.Method+" "+ginPath(segments, strings.FieldsFunc(path, func(r rune) bool { return r == '/' })))
+	}
+
+	return w.fitRoutes(routes)
+}
+
+// generatedRoute returns the method and path, within the entity's route
+// group, of the generated route serving an operation
+func generatedRoute(op schema.Operation) (method, path string) {
+	related := NewEntityNames(op.Related)
+	switch op.Action {
+	case schema.ActionCreate:
+		return "POST", ""
+	case schema.ActionList:
+		return "GET", ""
+	case schema.ActionGet:
+		return "GET", "/:id"
+	case schema.ActionUpdate:
+		return "PUT", "/:id"
+	case schema.ActionDelete:
+		return "DELETE", "/:id"
+	case schema.ActionListRelated:
+		return "GET", "/:id/" + related.SnakePlural
+	case schema.ActionLink:
+		return "POST", fmt.Sprintf("/:id/%s/:%s_id", related.SnakePlural, related.Snake)
+	case schema.ActionUnlink:
+		return "DELETE", fmt.Sprintf("/:id/%s/:%s_id", related.SnakePlural, related.Snake)
+	}
+	return "", ""
+}
+
+// ginPath turns the document's path below the collection into a gin path,
+// naming parameters as the generated path does; without one the first
+// parameter is :id
+func ginPath(segments, generated []string) string {
+	var b strings.Builder
+	for i, segment := range segments {
+		b.WriteByte('/')
+		switch {
+		case !isPathParam(segment):
+			b.WriteString(segment)
+		case i < len(generated) && strings.HasPrefix(generated[i], ":"):
+			b.WriteString(generated[i])
+		case i == 0:
+			b.WriteString(":id")
+		default:
+			b.WriteString(":" + NewEntityNames(strings.Trim(segment, "{}")).Snake)
+		}
+	}
+	return b.String()
+}
+
+func isPathParam(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
+// fitRoutes rewrites the base path, the entities' route groups and their
+// routes; routes of other groups are left alone
+func (w *APIWriter) fitRoutes(routes map[string][]string) error {
+	path := filepath.Join(w.projectDir, RoutesFile)
+	src, err := w.read(path)
+	if err != nil {
+		return err
+	}
+
+	groups := make(map[string]string)
+	for _, entity := range w.entities {
+		groups[NewEntityNames(entity).LowerCamelPlural()] = w.api.Segments[entity]
+	}
+
+	var out []string
+	for _, line := range strings.Split(string(src), "\n") {
+		if m := apiGroup.FindStringSubmatch(line); m != nil {
+			line = fmt.Sprintf("%s%q%s", m[1], w.api.BasePath, m[2])
+		}
+		if m := routeGroup.FindStringSubmatch(line); m != nil {
+			if segment, ok := groups[m[2]]; ok {
+				line = fmt.Sprintf("%s%s := api.Group(%q)", m[1], m[2], "/"+segment)
+			}
+		}
+		if m := routeLine.FindStringSubmatch(line); m != nil {
+			if _, ok := groups[m[2]]; ok {
+				for _, route := range routes[m[2]+" "+m[3]+" "+m[4]] {
+					method, path, _ := strings.Cut(route, " ")
+					out = append(out, fmt.Sprintf("%s%s.%s(%q, %s)", m[1], m[2], method, path, m[5]))
+				}
+				continue
+			}
+		}
+		out = append(out, line)
+	}
+
+	w.files[path] = []byte(strings.Join(out, "\n"))
+	return w.write()
+}
+
+// addStub adds a handler answering 501 for an operation and returns its name
+func (w *APIWriter) addStub(entity EntityNames, op schema.Operation) (string, error) {
+	path := w.packageFile(entity, "handler.go")
+	src, err := w.read(path)
+	if err != nil {
+		return "", err
+	}
+
+	name := goIdentifier(op.ID)
+	taken := func(name string) bool {
+		return name == "" || strings.Contains(string(src), "func (h *Handler) "+name+"(")
+	}
+	if taken(name) {
+		name = goIdentifier(strings.ToLower(op.Method) + " " + op.Path)
+	}
+	for n := 2; taken(name); n++ {
+		name = fmt.Sprintf("%s%d", strings.TrimRight(name, "0123456789"), n)
+	}
+
+	return name, w.addCode(path, "", "", stubHandlerCode, stub{Name: name, Operation: op.Method + " " + w.api.BasePath + op.Path})
+}
+
+// goIdentifier turns an operationId or path into an exported Go name,
+// e.g. refundOrder or "post /orders/{id}/refund"
+func goIdentifier(s string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(s, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	name := b.String()
+	if name != "" && !unicode.IsLetter(rune(name[0])) {
+		name = "Op" + name
+	}
+	return name
+}
+
+const stubHandlerCode = `
+// {{.Name}} serves {{.Operation}}, which doesn't map to CRUD
+func (h *Handler) {{.Name}}(c *gin.Context) {
+	// TODO: implement
+	c.JSON(501, gin.H{"error": "Not implemented"})
+}
+`
@@ -0,0 +1,93 @@
+package ddd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/darkphotonKN/go-template-generator/internal/schema"
+)
+
+const crudRoutes = `package config
+
+func SetupRoutes(router *gin.Engine) {
+	api := router.Group("/api")
+	{
+		// Item endpoints
+		items := api.Group("/items")
+		{
+			items.POST("", itemHandler.CreateItem)
+			items.GET("", itemHandler.ListItems)
+			items.GET("/:id", itemHandler.GetItem)
+			items.PUT("/:id", itemHandler.UpdateItem)
+			items.DELETE("/:id", itemHandler.DeleteItem)
+		}
+
+		uploads := api.Group("/uploads")
+		uploads.POST("/presigned-url", uploadHandler.PresignUpload)
+	}
+}
+`
+
+func TestAPIWriterFitsRoutes(t *testing.T) {
+	dir := t.TempDir()
+	routesPath := filepath.Join(dir, RoutesFile)
+	if err := os.MkdirAll(filepath.Dir(routesPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	api := &schema.API{
+		BasePath: "/v2",
+		Segments: map[string]string{"line_item": "line-items"},
+		Operations: []schema.Operation{
+			{Method: "GET", Path: "/line-items", Entity: "line_item", Action: schema.ActionList},
+			{Method: "PATCH", Path: "/line-items/{lineItemId}", Entity: "line_item", Action: schema.ActionUpdate},
+			{Method: "PUT", Path: "/line-items/{lineItemId}", Entity: "line_item", Action: schema.ActionUpdate},
+		},
+	}
+	routes, err := NewEntityRenamer(TemplateEntity, "line_item").RenameGoSource(RoutesFile, []byte(crudRoutes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(routesPath, routes, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NewAPIWriter(dir, []string{"line_item"}, api).Apply(); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	out, err := os.ReadFile(routesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`api := router.Group("/v2")`,
+		`lineItems := api.Group("/line-items")`,
+		"lineItems.GET(\"\", lineItemHandler.ListLineItems)\n" +
+			"\t\t\tlineItems.PATCH(\"/:id\", lineItemHandler.UpdateLineItem)\n" +
+			"\t\t\tlineItems.PUT(\"/:id\", lineItemHandler.UpdateLineItem)\n\t\t}",
+		`uploads.POST("/presigned-url", uploadHandler.PresignUpload)`,
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("Expected %q in the routes, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(string(out), "CreateLineItem") || strings.Contains(string(out), "DeleteLineItem") {
+		t.Errorf("Expected routes missing from the document to be dropped, got:\n%s", out)
+	}
+}
+
+func TestGoIdentifier(t *testing.T) {
+	for in, want := range map[string]string{
+		"refundOrder":              "RefundOrder",
+		"orders.refund":            "OrdersRefund",
+		"post /orders/{id}/refund": "PostOrdersIdRefund",
+		"2fa-verify":               "Op2faVerify",
+	} {
+		if got := goIdentifier(in); got != want {
+			t.Errorf("goIdentifier(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,577 @@
+package ddd
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// PackagePruner removes every reference to template packages that were left
+// out of a generated project (see the manifest's file rules) so the remaining
+// code still compiles: the imports, the declarations and statements using
+// them, anything only those statements declared, route groups left empty and
+// imports nothing uses anymore.
+type PackagePruner struct {
+	dirs []string // excluded directories relative to the project root
+}
+
+func NewPackagePruner(excluded []string) *PackagePruner {
+	return &PackagePruner{dirs: excluded}
+}
+
+// Apply prunes every Go file under projectPath, a Go module; other projects
+// are left as they are
+func (p *PackagePruner) Apply(projectPath string) error {
+	if len(p.dirs) == 0 {
+		return nil
+	}
+
+	goMod, err := os.ReadFile(filepath.Join(projectPath, "go.mod"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil // not a Go project, as the frontend
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read go.mod: %w", err)
+	}
+	modulePath := modfile.ModulePath(goMod)
+
+	return filepath.Walk(projectPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() || !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read file %s: %w", path, err)
+		}
+
+		out, changed, err := p.PruneSource(path, content, modulePath)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			return nil
+		}
+
+		if err := os.WriteFile(path, out, info.Mode()); err != nil {
+			return fmt.Errorf("failed to write file %s: %w", path, err)
+		}
+
+		return nil
+	})
+}
+
+// PruneSource prunes a single Go source file. Removal works on whole source
+// lines and the file is re-parsed until nothing else needs to go, so each
+// round sees the identifiers the previous round left unresolved.
+func (p *PackagePruner) PruneSource(filename string, src []byte, modulePath string) ([]byte, bool, error) {
+	state := &pruneState{
+		packages:     make(map[string]bool),
+		removedNames: make(map[string]bool),
+		removedVars:  make(map[string]bool),
+	}
+
+	changed := false
+	for first := true; ; first = false {
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to parse %s: %w", filename, err)
+		}
+
+		if first {
+			state.usedVars = usedVars(file)
+			for _, spec := range file.Imports {
+				importPath, _ := strconv.Unquote(spec.Path.Value)
+				if p.isExcluded(importPath, modulePath) {
+					state.packages[importName(importPath)] = true
+					if spec.Name != nil {
+						state.packages[spec.Name.Name] = true
+					}
+				}
+			}
+			if len(state.packages) == 0 {
+				return src, false, nil
+			}
+		}
+
+		lines := state.collect(fset, file, func(importPath string) bool {
+			return p.isExcluded(importPath, modulePath)
+		})
+		if len(lines) == 0 {
+			break
+		}
+
+		src = deleteLines(src, lines)
+		changed = true
+	}
+
+	out, err := format.Source(src)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to format %s after pruning: %w", filename, err)
+	}
+
+	return out, changed, nil
+}
+
+func (p *PackagePruner) isExcluded(importPath, modulePath string) bool {
+	for _, dir := range p.dirs {
+		excluded := path.Join(modulePath, dir)
+		if importPath == excluded || strings.HasPrefix(importPath, excluded+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// pruneState carries what has been removed across parse rounds
+type pruneState struct {
+	packages     map[string]bool // local names of excluded packages
+	removedNames map[string]bool // package-level declarations removed
+	removedVars  map[string]bool // "func.name" of local variables removed
+	usedVars     map[string]bool // "func.name" of local variables used in the original source
+}
+
+// collect returns the source lines to delete in this round
+func (s *pruneState) collect(fset *token.FileSet, file *ast.File, excludedImport func(string) bool) map[int]bool {
+	lines := make(map[int]bool)
+	remove := func(from, to token.Pos) {
+		for line := fset.Position(from).Line; line <= fset.Position(to).Line; line++ {
+			lines[line] = true
+		}
+	}
+	removed := func(pos token.Pos) bool {
+		return lines[fset.Position(pos).Line]
+	}
+
+	// Imports of excluded packages and imports nothing references anymore
+	used := usedPackages(file)
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+
+		kept := 0
+		for _, spec := range gen.Specs {
+			imp := spec.(*ast.ImportSpec)
+			importPath, _ := strconv.Unquote(imp.Path.Value)
+			name := importName(importPath)
+			if imp.Name != nil {
+				name = imp.Name.Name
+			}
+
+			if excludedImport(importPath) || (name != "_" && name != "." && !used[name]) {
+				remove(imp.Pos(), imp.End())
+				continue
+			}
+			kept++
+		}
+
+		if kept == 0 {
+			remove(gen.Pos(), gen.End())
+		}
+	}
+
+	// Package-level declarations using removed packages or declarations
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			continue
+		}
+		if !s.declRefers(decl) {
+			continue
+		}
+
+		remove(declStart(decl), decl.End())
+		for _, name := range declNames(decl) {
+			s.removedNames[name] = true
+		}
+	}
+
+	// Statements inside function bodies
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil || removed(fn.Pos()) {
+			continue
+		}
+		scope := funcKey(fn)
+		uses := objectUses(fn.Body)
+
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			if n != nil && n != fn.Body && removed(n.Pos()) {
+				return false
+			}
+
+			list := stmtList(n)
+			for i, stmt := range list {
+				if removed(stmt.Pos()) {
+					continue
+				}
+
+				switch {
+				case s.refers(stmt, scope):
+				case isEmptyBlock(stmt):
+				case s.isOrphan(stmt, scope, uses):
+				default:
+					continue
+				}
+
+				remove(stmtStart(fset, file, stmt), stmt.End())
+				declared := declaredVars(stmt)
+				for _, name := range declared {
+					s.removedVars[scope+"."+name] = true
+				}
+
+				// The error check right after a removed call goes with it
+				if i+1 < len(list) {
+					if next, ok := list[i+1].(*ast.IfStmt); ok && mentions(next.Cond, assignedNames(stmt)) {
+						remove(next.Pos(), next.End())
+					}
+				}
+			}
+			return true
+		})
+	}
+
+	return lines
+}
+
+// refers reports whether node uses a removed package, declaration or variable.
+// Nested blocks, including function literal bodies, are not looked at: their
+// statements are pruned one by one instead of taking the whole node with them.
+func (s *pruneState) refers(node ast.Node, scope string) bool {
+	found := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		if found {
+			return false
+		}
+
+		switch x := n.(type) {
+		case *ast.BlockStmt:
+			return false
+		case *ast.SelectorExpr:
+			// Only the left side can refer to a package or variable
+			if id, ok := x.X.(*ast.Ident); ok && id.Obj == nil && s.packages[id.Name] {
+				found = true
+				return false
+			}
+			found = s.refers(x.X, scope)
+			return false
+		case *ast.KeyValueExpr:
+			if _, isIdent := x.Key.(*ast.Ident); !isIdent {
+				found = s.refers(x.Key, scope)
+			}
+			found = found || s.refers(x.Value, scope)
+			return false
+		case *ast.Ident:
+			// Identifiers whose declaration was removed no longer resolve
+			if x.Obj == nil && (s.removedNames[x.Name] || s.removedVars[scope+"."+x.Name]) {
+				found = true
+			}
+		}
+		return true
+	})
+	return found
+}
+
+// declRefers reports whether a package-level declaration can't survive the
+// pruning. Functions only go when their signature does; their bodies are
+// pruned statement by statement.
+func (s *pruneState) declRefers(decl ast.Decl) bool {
+	fn, ok := decl.(*ast.FuncDecl)
+	if !ok {
+		return s.refers(decl, "")
+	}
+	if fn.Recv != nil && s.refers(fn.Recv, "") {
+		return true
+	}
+	return s.refers(fn.Type, "")
+}
+
+// isOrphan reports whether stmt only declares variables that were used in the
+// original source but are no longer used after pruning
+func (s *pruneState) isOrphan(stmt ast.Stmt, scope string, uses map[*ast.Object]int) bool {
+	assign, ok := stmt.(*ast.AssignStmt)
+	if !ok || assign.Tok != token.DEFINE {
+		return false
+	}
+
+	declared := 0
+	for _, lhs := range assign.Lhs {
+		id, ok := lhs.(*ast.Ident)
+		if !ok || id.Obj == nil || id.Obj.Decl != assign {
+			continue
+		}
+		if uses[id.Obj] > 0 || !s.usedVars[scope+"."+id.Name] {
+			return false
+		}
+		declared++
+	}
+
+	return declared > 0
+}
+
+// usedVars records which := variables of every function have at least one use
+func usedVars(file *ast.File) map[string]bool {
+	used := make(map[string]bool)
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		for obj, count := range objectUses(fn.Body) {
+			if count > 0 {
+				used[funcKey(fn)+"."+obj.Name] = true
+			}
+		}
+	}
+	return used
+}
+
+// objectUses counts references to each local object, excluding its declaration
+func objectUses(body *ast.BlockStmt) map[*ast.Object]int {
+	uses := make(map[*ast.Object]int)
+	ast.Inspect(body, func(n ast.Node) bool {
+		id, ok := n.(*ast.Ident)
+		if !ok || id.Obj == nil || id.Obj.Kind != ast.Var {
+			return true
+		}
+		if _, counted := uses[id.Obj]; !counted {
+			uses[id.Obj] = 0
+		}
+		if decl, ok := id.Obj.Decl.(ast.Node); !ok || !isDeclIdent(decl, id) {
+			uses[id.Obj]++
+		}
+		return true
+	})
+	return uses
+}
+
+func isDeclIdent(decl ast.Node, id *ast.Ident) bool {
+	switch d := decl.(type) {
+	case *ast.AssignStmt:
+		for _, lhs := range d.Lhs {
+			if lhs == id {
+				return true
+			}
+		}
+	case *ast.ValueSpec:
+		for _, name := range d.Names {
+			if name == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// usedPackages returns the names used as package qualifiers in file
+func usedPackages(file *ast.File) map[string]bool {
+	used := make(map[string]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok && id.Obj == nil {
+				used[id.Name] = true
+			}
+		}
+		return true
+	})
+	return used
+}
+
+func stmtList(n ast.Node) []ast.Stmt {
+	switch x := n.(type) {
+	case *ast.BlockStmt:
+		return x.List
+	case *ast.CaseClause:
+		return x.Body
+	case *ast.CommClause:
+		return x.Body
+	}
+	return nil
+}
+
+func isEmptyBlock(stmt ast.Stmt) bool {
+	block, ok := stmt.(*ast.BlockStmt)
+	return ok && len(block.List) == 0
+}
+
+// declaredVars returns the variables a statement declares
+func declaredVars(stmt ast.Stmt) []string {
+	var names []string
+	switch s := stmt.(type) {
+	case *ast.AssignStmt:
+		if s.Tok != token.DEFINE {
+			return nil
+		}
+		for _, lhs := range s.Lhs {
+			if id, ok := lhs.(*ast.Ident); ok && id.Obj != nil && id.Obj.Decl == s {
+				names = append(names, id.Name)
+			}
+		}
+	case *ast.DeclStmt:
+		if gen, ok := s.Decl.(*ast.GenDecl); ok {
+			for _, spec := range gen.Specs {
+				if vs, ok := spec.(*ast.ValueSpec); ok {
+					for _, name := range vs.Names {
+						names = append(names, name.Name)
+					}
+				}
+			}
+		}
+	}
+	return names
+}
+
+// assignedNames returns every variable a statement assigns to
+func assignedNames(stmt ast.Stmt) map[string]bool {
+	names := make(map[string]bool)
+	if assign, ok := stmt.(*ast.AssignStmt); ok {
+		for _, lhs := range assign.Lhs {
+			if id, ok := lhs.(*ast.Ident); ok && id.Name != "_" {
+				names[id.Name] = true
+			}
+		}
+	}
+	for _, name := range declaredVars(stmt) {
+		names[name] = true
+	}
+	return names
+}
+
+func mentions(expr ast.Expr, names map[string]bool) bool {
+	found := false
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && names[id.Name] {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+func declNames(decl ast.Decl) []string {
+	var names []string
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		if d.Recv == nil {
+			names = append(names, d.Name.Name)
+		}
+	case *ast.GenDecl:
+		for _, spec := range d.Specs {
+			switch s := spec.(type) {
+			case *ast.TypeSpec:
+				names = append(names, s.Name.Name)
+			case *ast.ValueSpec:
+				for _, name := range s.Names {
+					names = append(names, name.Name)
+				}
+			}
+		}
+	}
+	return names
+}
+
+func declStart(decl ast.Decl) token.Pos {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		if d.Doc != nil {
+			return d.Doc.Pos()
+		}
+	case *ast.GenDecl:
+		if d.Doc != nil {
+			return d.Doc.Pos()
+		}
+	}
+	return decl.Pos()
+}
+
+// stmtStart extends a statement upwards over the comment lines directly above it
+func stmtStart(fset *token.FileSet, file *ast.File, stmt ast.Stmt) token.Pos {
+	start := stmt.Pos()
+	for i := len(file.Comments) - 1; i >= 0; i-- {
+		group := file.Comments[i]
+		if group.End() >= start {
+			continue
+		}
+		if fset.Position(group.End()).Line != fset.Position(start).Line-1 {
+			break
+		}
+		// Only comments on lines of their own, not trailing ones
+		if fset.Position(group.Pos()).Column > fset.Position(stmt.Pos()).Column {
+			break
+		}
+		start = group.Pos()
+	}
+	return start
+}
+
+func funcKey(fn *ast.FuncDecl) string {
+	if fn.Recv != nil && len(fn.Recv.List) > 0 {
+		return fmt.Sprintf("%s.%s", types(fn.Recv.List[0].Type), fn.Name.Name)
+	}
+	return fn.Name.Name
+}
+
+func types(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return types(t.X)
+	case *ast.Ident:
+		return t.Name
+	case *ast.IndexExpr:
+		return types(t.X)
+	}
+	return ""
+}
+
+// deleteLines drops the given 1-based source lines, along with blank lines the
+// deletion leaves at the start or end of a block
+func deleteLines(src []byte, lines map[int]bool) []byte {
+	all := strings.SplitAfter(string(src), "\n")
+
+	type keptLine struct {
+		text     string
+		adjacent bool // next to a deleted line
+	}
+	var kept []keptLine
+	for i, line := range all {
+		if lines[i+1] {
+			continue
+		}
+		kept = append(kept, keptLine{text: line, adjacent: lines[i] || lines[i+2]})
+	}
+
+	var out strings.Builder
+	for i, line := range kept {
+		if line.adjacent && strings.TrimSpace(line.text) == "" {
+			prev, next := "", ""
+			if i > 0 {
+				prev = strings.TrimSpace(kept[i-1].text)
+			}
+			if i+1 < len(kept) {
+				next = strings.TrimSpace(kept[i+1].text)
+			}
+			if strings.HasSuffix(prev, "{") || strings.HasPrefix(next, "}") {
+				continue
+			}
+		}
+		out.WriteString(line.text)
+	}
+	return []byte(out.String())
+}
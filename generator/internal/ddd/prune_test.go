@@ -0,0 +1,111 @@
+package ddd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPruneSource(t *testing.T) {
+	src := `package main
+
+import (
+	"log"
+	"os"
+
+	"example.com/app/internal/cache"
+	"example.com/app/internal/item"
+)
+
+func main() {
+	repo := item.NewRepository()
+
+	rdb, err := cache.NewRedis()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer rdb.Close()
+
+	{
+		// Item endpoints
+		items := repo.List()
+		items.Print()
+
+		// Cache endpoints
+		stats := cache.Stats(rdb)
+		stats.Print()
+	}
+
+	os.Exit(0)
+}
+
+// warm fills the cache
+func warm(rdb *cache.Client) {
+	rdb.Set()
+}
+`
+
+	pruner := NewPackagePruner([]string{"internal/cache"})
+	out, changed, err := pruner.PruneSource("main.go", []byte(src), "example.com/app")
+	if err != nil {
+		t.Fatalf("PruneSource failed: %v", err)
+	}
+	if !changed {
+		t.Fatal("Expected source to change")
+	}
+
+	expected := `package main
+
+import (
+	"os"
+
+	"example.com/app/internal/item"
+)
+
+func main() {
+	repo := item.NewRepository()
+
+	{
+		// Item endpoints
+		items := repo.List()
+		items.Print()
+	}
+
+	os.Exit(0)
+}
+`
+	if string(out) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, out)
+	}
+}
+
+func TestPruneSourceRemovesUnusedImports(t *testing.T) {
+	src := `package config
+
+import (
+	"context"
+
+	"example.com/app/internal/s3"
+)
+
+func Setup() {
+	svc, err := s3.NewService(context.Background())
+	if err != nil {
+		return
+	}
+	svc.Run()
+}
+`
+
+	pruner := NewPackagePruner([]string{"internal/s3"})
+	out, _, err := pruner.PruneSource("routes.go", []byte(src), "example.com/app")
+	if err != nil {
+		t.Fatalf("PruneSource failed: %v", err)
+	}
+
+	result := string(out)
+	for _, unwanted := range []string{"context", "s3", "svc", "err"} {
+		if strings.Contains(result, unwanted) {
+			t.Errorf("Expected %q to be pruned, got:\n%s", unwanted, result)
+		}
+	}
+}
@@ -0,0 +1,542 @@
+package ddd
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/darkphotonKN/go-template-generator/internal/schema"
+)
+
+// selectColumns finds the column list of a repository's SELECT queries
+var selectColumns = regexp.MustCompile(`(?m)^\s*SELECT (id, .*)$`)
+
+// RelationWriter adds what relations need beyond their foreign key columns,
+// which the FieldRewriter renders: join tables, repository, service and
+// handler methods listing related rows, and nested routes
+type RelationWriter struct {
+	projectDir string
+	entities   []string // declaration order, which is migration order
+	files      map[string][]byte
+}
+
+func NewRelationWriter(projectDir string, entities []string) *RelationWriter {
+	return &RelationWriter{
+		projectDir: projectDir,
+		entities:   entities,
+		files:      make(map[string][]byte),
+	}
+}
+
+// listing lists the rows of Child related to one row of Parent
+type listing struct {
+	Child   EntityNames
+	Parent  EntityNames
+	Columns string // Child's columns, qualified by its table
+	From    string
+	Where   string // condition on the parent's id, $1
+}
+
+// link attaches and detaches Target rows to and from an Owner row through
+// a join table
+type link struct {
+	Owner  EntityNames
+	Target EntityNames
+	Table  string
+}
+
+// Apply renders every relation into the project
+func (w *RelationWriter) Apply(relations []schema.Relation) error {
+	for _, r := range relations {
+		var err error
+		switch r.Kind {
+		case schema.BelongsTo:
+			err = w.belongsTo(r)
+		case schema.ManyToMany:
+			err = w.manyToMany(r)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to render relation %s: %w", r, err)
+		}
+	}
+
+	return w.write()
+}
+
+// write formats and writes every changed file
+func (w *RelationWriter) write() error {
+	for path, content := range w.files {
+		if strings.HasSuffix(path, ".go") {
+			formatted, err := format.Source(content)
+			if err != nil {
+				return fmt.Errorf("failed to format %s: %w", path, err)
+			}
+			content = formatted
+		}
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// belongsTo lists the entity's rows under its target, e.g.
+// GET /customers/:id/orders for an order belonging to a customer
+func (w *RelationWriter) belongsTo(r schema.Relation) error {
+	child, parent := NewEntityNames(r.Entity), NewEntityNames(r.Target)
+
+	columns, err := w.columns(child)
+	if err != nil {
+		return err
+	}
+	return w.addListing(listing{
+		Child:   child,
+		Parent:  parent,
+		Columns: columns,
+		From:    child.SnakePlural,
+		Where:   fmt.Sprintf("%s.%s = $1", child.SnakePlural, r.ForeignKey()),
+	})
+}
+
+// manyToMany adds the join table, listings in both directions and routes
+// on the owner to attach and detach targets
+func (w *RelationWriter) manyToMany(r schema.Relation) error {
+	owner, target := NewEntityNames(r.Entity), NewEntityNames(r.Target)
+	table := owner.Snake + "_" + target.SnakePlural
+	if err := w.addJoinTable(owner, target, table); err != nil {
+		return err
+	}
+
+	for _, pair := range [][2]EntityNames{{target, owner}, {owner, target}} {
+		child, parent := pair[0], pair[1]
+		columns, err := w.columns(child)
+		if err != nil {
+			return err
+		}
+		err = w.addListing(listing{
+			Child:   child,
+			Parent:  parent,
+			Columns: columns,
+			From:    fmt.Sprintf("%s JOIN %s ON %s.%s_id = %s.id", child.SnakePlural, table, table, child.Snake, child.SnakePlural),
+			Where:   fmt.Sprintf("%s.%s_id = $1", table, parent.Snake),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return w.addLink(link{Owner: owner, Target: target, Table: table})
+}
+
+// columns returns an entity's columns as its repository selects them,
+// qualified by its table
+func (w *RelationWriter) columns(entity EntityNames) (string, error) {
+	src, err := w.read(w.packageFile(entity, "repository.go"))
+	if err != nil {
+		return "", err
+	}
+	match := selectColumns.FindSubmatch(src)
+	if match == nil {
+		return "", fmt.Errorf("no SELECT column list in the %s repository", entity.Snake)
+	}
+
+	columns := strings.Split(string(match[1]), ", ")
+	for i, column := range columns {
+		columns[i] = entity.SnakePlural + "." + strings.TrimSpace(column)
+	}
+	return strings.Join(columns, ", "), nil
+}
+
+func (w *RelationWriter) addListing(l listing) error {
+	signature := fmt.Sprintf("(ctx context.Context, %sID uuid.UUID, limit, offset int) ([]*%s, int64, error)", l.Parent.LowerCamel(), l.Child.Camel)
+
+	edits := []struct {
+		file, iface, method, code string
+	}{
+		{"repository.go", "", "", listRepositoryCode},
+		{"service.go", "Repository", "ListBy" + l.Parent.Camel + signature, listServiceCode},
+		{"handler.go", "Service", "List" + l.Child.CamelPlural + "By" + l.Parent.Camel + signature, listHandlerCode},
+	}
+	for _, edit := range edits {
+		if err := w.addCode(w.packageFile(l.Child, edit.file), edit.iface, edit.method, edit.code, l); err != nil {
+			return err
+		}
+	}
+
+	route := fmt.Sprintf("%s.GET(\"/:id/%s\", %sHandler.List%s%s)", l.Parent.LowerCamelPlural(), l.Child.SnakePlural, l.Child.LowerCamel(), l.Parent.Camel, l.Child.CamelPlural)
+	return w.addRoutes(l.Parent, route)
+}
+
+func (w *RelationWriter) addLink(l link) error {
+	signature := fmt.Sprintf("(ctx context.Context, %sID, %sID uuid.UUID) error", l.Owner.LowerCamel(), l.Target.LowerCamel())
+
+	for _, verb := range []string{"Add", "Remove"} {
+		edits := []struct {
+			file, iface, method, code string
+		}{
+			{"repository.go", "", "", linkRepositoryCode[verb]},
+			{"service.go", "Repository", verb + l.Target.Camel + signature, linkServiceCode},
+			{"handler.go", "Service", verb + l.Owner.Camel + l.Target.Camel + signature, linkHandlerCode},
+		}
+		data := struct {
+			link
+			Verb string
+		}{l, verb}
+		for _, edit := range edits {
+			if err := w.addCode(w.packageFile(l.Owner, edit.file), edit.iface, edit.method, edit.code, data); err != nil {
+				return err
+			}
+		}
+	}
+
+	path := fmt.Sprintf("/:id/%s/:%s_id", l.Target.SnakePlural, l.Target.Snake)
+	return w.addRoutes(l.Owner,
+		fmt.Sprintf("%s.POST(%q, %sHandler.Add%s%s)", l.Owner.LowerCamelPlural(), path, l.Owner.LowerCamel(), l.Owner.Camel, l.Target.Camel),
+		fmt.Sprintf("%s.DELETE(%q, %sHandler.Remove%s%s)", l.Owner.LowerCamelPlural(), path, l.Owner.LowerCamel(), l.Owner.Camel, l.Target.Camel),
+	)
+}
+
+// addJoinTable creates the join table in the migration of whichever of the
+// two entities is created last, so both tables exist
+func (w *RelationWriter) addJoinTable(owner, target EntityNames, table string) error {
+	last := owner
+	if slices.Index(w.entities, target.Snake) > slices.Index(w.entities, owner.Snake) {
+		last = target
+	}
+
+	up, err := w.migration(last, "up")
+	if err != nil {
+		return err
+	}
+	down, err := w.migration(last, "down")
+	if err != nil {
+		return err
+	}
+
+	var sql bytes.Buffer
+	if err := template.Must(template.New("").Parse(joinTableSQL)).Execute(&sql, link{Owner: owner, Target: target, Table: table}); err != nil {
+		return err
+	}
+	w.files[up] = append(bytes.TrimRight(w.files[up], "\n"), sql.Bytes()...)
+	w.files[down] = append([]byte(fmt.Sprintf("-- Drop %s join table\nDROP TABLE IF EXISTS %s;\n\n", table, table)), w.files[down]...)
+	return nil
+}
+
+func (w *RelationWriter) migration(entity EntityNames, direction string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(w.projectDir, "migrations", "*_create_"+entity.SnakePlural+"_table."+direction+".sql"))
+	if err != nil {
+		return "", err
+	}
+	if len(matches) != 1 {
+		return "", fmt.Errorf("expected one %s migration creating %s, found %d", direction, entity.SnakePlural, len(matches))
+	}
+	if _, err := w.read(matches[0]); err != nil {
+		return "", err
+	}
+	return matches[0], nil
+}
+
+// addCode appends rendered code to a file and, when iface is set, adds
+// method to that interface
+func (w *RelationWriter) addCode(path, iface, method, code string, data any) error {
+	src, err := w.read(path)
+	if err != nil {
+		return err
+	}
+
+	if iface != "" {
+		if src, err = addInterfaceMethod(src, iface, method); err != nil {
+			return fmt.Errorf("failed to extend %s in %s: %w", iface, path, err)
+		}
+	}
+
+	var rendered bytes.Buffer
+	if err := template.Must(template.New("").Parse(code)).Execute(&rendered, data); err != nil {
+		return err
+	}
+	w.files[path] = append(append(bytes.TrimRight(src, "\n"), '\n'), rendered.Bytes()...)
+	return nil
+}
+
+// addRoutes adds routes to the end of an entity's route group
+func (w *RelationWriter) addRoutes(entity EntityNames, routes ...string) error {
+	path := filepath.Join(w.projectDir, RoutesFile)
+	src, err := w.read(path)
+	if err != nil {
+		return err
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, RoutesFile, src, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+
+	group := entity.LowerCamelPlural()
+	line := 0
+	ast.Inspect(file, func(n ast.Node) bool {
+		block, ok := n.(*ast.BlockStmt)
+		if !ok || line != 0 {
+			return line == 0
+		}
+		for i, stmt := range block.List {
+			assign, ok := stmt.(*ast.AssignStmt)
+			if !ok || len(assign.Lhs) != 1 {
+				continue
+			}
+			if ident, ok := assign.Lhs[0].(*ast.Ident); !ok || ident.Name != group {
+				continue
+			}
+			// Routes sit in the block following the group, or right after it
+			line = fset.Position(assign.End()).Line + 1
+			if i+1 < len(block.List) {
+				if routesBlock, ok := block.List[i+1].(*ast.BlockStmt); ok {
+					line = fset.Position(routesBlock.Rbrace).Line
+				}
+			}
+			return false
+		}
+		return true
+	})
+	if line == 0 {
+		return fmt.Errorf("route group %s not found in %s", group, RoutesFile)
+	}
+
+	w.files[path] = applyLineEdits(src, []lineEdit{{from: line, to: line - 1, text: strings.Join(routes, "\n")}})
+	return nil
+}
+
+func (w *RelationWriter) packageFile(entity EntityNames, name string) string {
+	return filepath.Join(w.projectDir, "internal", entity.Package, name)
+}
+
+// read returns a file's pending content, loading it on first use
+func (w *RelationWriter) read(path string) ([]byte, error) {
+	if src, ok := w.files[path]; ok {
+		return src, nil
+	}
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	w.files[path] = src
+	return src, nil
+}
+
+// addInterfaceMethod adds a method line at the end of an interface type
+func addInterfaceMethod(src []byte, iface, method string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			it, ok := ts.Type.(*ast.InterfaceType)
+			if !ok || ts.Name.Name != iface {
+				continue
+			}
+			line := fset.Position(it.Methods.Closing).Line
+			return applyLineEdits(src, []lineEdit{{from: line, to: line - 1, text: method}}), nil
+		}
+	}
+	return nil, fmt.Errorf("interface %s not found", iface)
+}
+
+const joinTableSQL = `
+
+-- Create {{.Table}} join table
+CREATE TABLE {{.Table}} (
+    {{.Owner.Snake}}_id UUID NOT NULL REFERENCES {{.Owner.SnakePlural}}(id) ON DELETE CASCADE,
+    {{.Target.Snake}}_id UUID NOT NULL REFERENCES {{.Target.SnakePlural}}(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY ({{.Owner.Snake}}_id, {{.Target.Snake}}_id)
+);
+
+CREATE INDEX idx_{{.Table}}_{{.Target.Snake}}_id ON {{.Table}}({{.Target.Snake}}_id);
+`
+
+const listRepositoryCode = `
+// ListBy{{.Parent.Camel}} lists the {{.Child.SnakePlural}} of a {{.Parent.Snake}}
+func (r *repository) ListBy{{.Parent.Camel}}(ctx context.Context, {{.Parent.LowerCamel}}ID uuid.UUID, limit, offset int) ([]*{{.Child.Camel}}, int64, error) {
+	// Get total count
+	var total int64
+	countQuery := ` + "`SELECT COUNT(*) FROM {{.From}} WHERE {{.Where}}`" + `
+	err := r.db.GetContext(ctx, &total, countQuery, {{.Parent.LowerCamel}}ID)
+	if err != nil {
+		return nil, 0, errorutils.AnalyzeDBErr(err)
+	}
+
+	// Get {{.Child.SnakePlural}}
+	query := ` + "`" + `
+		SELECT {{.Columns}}
+		FROM {{.From}}
+		WHERE {{.Where}}
+		ORDER BY {{.Child.SnakePlural}}.created_at DESC
+		LIMIT $2 OFFSET $3
+	` + "`" + `
+
+	var {{.Child.LowerCamelPlural}} []*{{.Child.Camel}}
+	err = r.db.SelectContext(ctx, &{{.Child.LowerCamelPlural}}, query, {{.Parent.LowerCamel}}ID, limit, offset)
+	if err != nil {
+		return nil, 0, errorutils.AnalyzeDBErr(err)
+	}
+
+	return {{.Child.LowerCamelPlural}}, total, nil
+}
+`
+
+const listServiceCode = `
+func (s *service) List{{.Child.CamelPlural}}By{{.Parent.Camel}}(ctx context.Context, {{.Parent.LowerCamel}}ID uuid.UUID, limit, offset int) ([]*{{.Child.Camel}}, int64, error) {
+	{{.Child.LowerCamelPlural}}, total, err := s.repo.ListBy{{.Parent.Camel}}(ctx, {{.Parent.LowerCamel}}ID, limit, offset)
+	if err != nil {
+		s.logger.Error("failed to list {{.Parent.Snake}} {{.Child.SnakePlural}}",
+			slog.String("error", err.Error()),
+			slog.String("{{.Parent.Snake}}_id", {{.Parent.LowerCamel}}ID.String()))
+		return nil, 0, fmt.Errorf("failed to list {{.Parent.Snake}} {{.Child.SnakePlural}}: %w", err)
+	}
+
+	return {{.Child.LowerCamelPlural}}, total, nil
+}
+`
+
+const listHandlerCode = `
+func (h *Handler) List{{.Parent.Camel}}{{.Child.CamelPlural}}(c *gin.Context) {
+	{{.Parent.LowerCamel}}ID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid {{.Parent.Snake}} ID"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil {
+		limit = 20
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		offset = 0
+	}
+
+	{{.Child.LowerCamelPlural}}, total, err := h.service.List{{.Child.CamelPlural}}By{{.Parent.Camel}}(c.Request.Context(), {{.Parent.LowerCamel}}ID, limit, offset)
+	if err != nil {
+		h.logger.Error("failed to list {{.Parent.Snake}} {{.Child.SnakePlural}}", slog.String("error", err.Error()))
+		c.JSON(500, gin.H{"error": "Failed to list {{.Child.SnakePlural}}"})
+		return
+	}
+
+	response := List{{.Child.CamelPlural}}Response{
+		{{.Child.CamelPlural}}: {{.Child.LowerCamelPlural}},
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	c.JSON(200, response)
+}
+`
+
+var linkRepositoryCode = map[string]string{
+	"Add": `
+// Add{{.Target.Camel}} links a {{.Target.Snake}} to a {{.Owner.Snake}}
+func (r *repository) Add{{.Target.Camel}}(ctx context.Context, {{.Owner.LowerCamel}}ID, {{.Target.LowerCamel}}ID uuid.UUID) error {
+	query := ` + "`" + `
+		INSERT INTO {{.Table}} ({{.Owner.Snake}}_id, {{.Target.Snake}}_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	` + "`" + `
+
+	_, err := r.db.ExecContext(ctx, query, {{.Owner.LowerCamel}}ID, {{.Target.LowerCamel}}ID)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	return nil
+}
+`,
+	"Remove": `
+// Remove{{.Target.Camel}} unlinks a {{.Target.Snake}} from a {{.Owner.Snake}}
+func (r *repository) Remove{{.Target.Camel}}(ctx context.Context, {{.Owner.LowerCamel}}ID, {{.Target.LowerCamel}}ID uuid.UUID) error {
+	query := ` + "`DELETE FROM {{.Table}} WHERE {{.Owner.Snake}}_id = $1 AND {{.Target.Snake}}_id = $2`" + `
+
+	result, err := r.db.ExecContext(ctx, query, {{.Owner.LowerCamel}}ID, {{.Target.LowerCamel}}ID)
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errorutils.AnalyzeDBErr(err)
+	}
+
+	if rowsAffected == 0 {
+		return errorutils.ErrNotFound
+	}
+
+	return nil
+}
+`,
+}
+
+const linkServiceCode = `
+func (s *service) {{.Verb}}{{.Owner.Camel}}{{.Target.Camel}}(ctx context.Context, {{.Owner.LowerCamel}}ID, {{.Target.LowerCamel}}ID uuid.UUID) error {
+	if err := s.repo.{{.Verb}}{{.Target.Camel}}(ctx, {{.Owner.LowerCamel}}ID, {{.Target.LowerCamel}}ID); err != nil {
+		s.logger.Error("failed to {{if eq .Verb "Add"}}add{{else}}remove{{end}} {{.Owner.Snake}} {{.Target.Snake}}",
+			slog.String("error", err.Error()),
+			slog.String("{{.Owner.Snake}}_id", {{.Owner.LowerCamel}}ID.String()),
+			slog.String("{{.Target.Snake}}_id", {{.Target.LowerCamel}}ID.String()))
+		return fmt.Errorf("failed to {{if eq .Verb "Add"}}add{{else}}remove{{end}} {{.Owner.Snake}} {{.Target.Snake}}: %w", err)
+	}
+
+	return nil
+}
+`
+
+const linkHandlerCode = `
+func (h *Handler) {{.Verb}}{{.Owner.Camel}}{{.Target.Camel}}(c *gin.Context) {
+	{{.Owner.LowerCamel}}ID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid {{.Owner.Snake}} ID"})
+		return
+	}
+
+	{{.Target.LowerCamel}}ID, err := uuid.Parse(c.Param("{{.Target.Snake}}_id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid {{.Target.Snake}} ID"})
+		return
+	}
+
+	if err := h.service.{{.Verb}}{{.Owner.Camel}}{{.Target.Camel}}(c.Request.Context(), {{.Owner.LowerCamel}}ID, {{.Target.LowerCamel}}ID); err != nil {
+		switch {
+{{- if eq .Verb "Add"}}
+		case errors.Is(err, errorutils.ErrForeignKeyViolation):
+			c.JSON(422, gin.H{"error": "{{.Owner.Camel}} or {{.Target.Snake}} does not exist"})
+{{- else}}
+		case errors.Is(err, errorutils.ErrNotFound):
+			c.JSON(404, gin.H{"error": "{{.Target.Camel}} is not linked to this {{.Owner.Snake}}"})
+{{- end}}
+		default:
+			h.logger.Error("failed to {{if eq .Verb "Add"}}add{{else}}remove{{end}} {{.Owner.Snake}} {{.Target.Snake}}", slog.String("error", err.Error()))
+			c.JSON(500, gin.H{"error": "Failed to {{if eq .Verb "Add"}}add{{else}}remove{{end}} {{.Target.Snake}}"})
+		}
+		return
+	}
+
+	c.Status(204)
+}
+`
@@ -0,0 +1,50 @@
+package ddd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRelationWriterAddRoutes(t *testing.T) {
+	dir := t.TempDir()
+	routesPath := filepath.Join(dir, RoutesFile)
+	if err := os.MkdirAll(filepath.Dir(routesPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(routesPath, []byte(templateRoutes), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w := NewRelationWriter(dir, []string{"item"})
+	if err := w.addRoutes(NewEntityNames("item"), `items.GET("/:id/tags", tagHandler.ListItemTags)`); err != nil {
+		t.Fatalf("addRoutes failed: %v", err)
+	}
+
+	// Formatting happens when the files are written
+	expected := "items.GET(\"\", itemHandler.ListItems)\nitems.GET(\"/:id/tags\", tagHandler.ListItemTags)\n\t\t}\n"
+	if got := string(w.files[routesPath]); !strings.Contains(got, expected) {
+		t.Errorf("Expected route at the end of the items group, got:\n%s", got)
+	}
+
+	if err := w.addRoutes(NewEntityNames("order"), "x"); err == nil {
+		t.Error("Expected a missing route group to be reported")
+	}
+}
+
+func TestAddInterfaceMethod(t *testing.T) {
+	src := `package item
+
+type Repository interface {
+	Delete(id string) error
+}
+`
+	out, err := addInterfaceMethod([]byte(src), "Repository", "ListByTag(tagID string) error")
+	if err != nil {
+		t.Fatalf("addInterfaceMethod failed: %v", err)
+	}
+	if !strings.Contains(string(out), "\tDelete(id string) error\nListByTag(tagID string) error\n}") {
+		t.Errorf("Expected method before the closing brace, got:\n%s", out)
+	}
+}
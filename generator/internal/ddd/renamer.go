@@ -0,0 +1,653 @@
+package ddd
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// TemplateEntity is the sample aggregate shipped with templates/ddd-api.
+// Every reference to it is rewritten to the requested entity after copying.
+const TemplateEntity = "item"
+
+// EntityNames holds every spelling of an entity name used in generated code
+type EntityNames struct {
+	Snake       string // order_item  (tables, routes, json tags)
+	SnakePlural string // order_items
+	Camel       string // OrderItem   (exported identifiers)
+	CamelPlural string // OrderItems
+	Package     string // orderitem   (Go package and directory name)
+}
+
+// NewEntityNames derives all spellings from an entity name such as
+// "product", "order_item" or "blog-post"
+func NewEntityNames(entity string) EntityNames {
+	words := strings.FieldsFunc(strings.ToLower(entity), func(r rune) bool {
+		return r == '_' || r == '-' || unicode.IsSpace(r)
+	})
+	if len(words) == 0 {
+		return EntityNames{}
+	}
+
+	_, lastPlural := GenerateEntityNames(words[len(words)-1])
+	pluralWords := append(append([]string{}, words[:len(words)-1]...), lastPlural)
+
+	return EntityNames{
+		Snake:       strings.Join(words, "_"),
+		SnakePlural: strings.Join(pluralWords, "_"),
+		Camel:       camelJoin(words),
+		CamelPlural: camelJoin(pluralWords),
+		Package:     strings.Join(words, ""),
+	}
+}
+
+// TableName returns the table the generated code keeps an entity in
+func TableName(entity string) string {
+	return NewEntityNames(entity).SnakePlural
+}
+
+// LowerCamel returns the unexported identifier form (orderItem)
+func (n EntityNames) LowerCamel() string {
+	return lowerFirst(n.Camel)
+}
+
+// LowerCamelPlural returns the unexported plural identifier form (orderItems)
+func (n EntityNames) LowerCamelPlural() string {
+	return lowerFirst(n.CamelPlural)
+}
+
+// EntityRenamer rewrites every reference to a single-word source entity
+// (normally TemplateEntity) inside a copied template: Go identifiers, package
+// names, import paths, string literals, comments, SQL identifiers, TypeScript
+// sources and file names.
+type EntityRenamer struct {
+	from  EntityNames
+	to    EntityNames
+	word  *regexp.Regexp // words containing the source entity
+	token *regexp.Regexp // words and kebab-case tokens containing the source entity
+}
+
+// cssUtility matches Tailwind utilities that contain the word "items"
+// (items-center, place-items-start); they must survive renaming the "item"
+// sample entity in frontend sources
+var cssUtility = regexp.MustCompile(`^(?:justify-|place-)?items-(?:start|end|center|baseline|stretch)$`)
+
+func NewEntityRenamer(from, to string) *EntityRenamer {
+	fromNames := NewEntityNames(from)
+	entity := `(?:` + regexp.QuoteMeta(fromNames.Snake) + `|` + regexp.QuoteMeta(fromNames.SnakePlural) + `)`
+	return &EntityRenamer{
+		from:  fromNames,
+		to:    NewEntityNames(to),
+		word:  regexp.MustCompile(`(?i)\b\w*` + entity + `\w*\b`),
+		token: regexp.MustCompile(`(?i)\b[\w-]*` + entity + `[\w-]*\b`),
+	}
+}
+
+// IsNoop reports whether the source and target entity are the same
+func (r *EntityRenamer) IsNoop() bool {
+	return r.from == r.to
+}
+
+// Apply rewrites file contents and then file and directory names under projectPath
+func (r *EntityRenamer) Apply(projectPath string) error {
+	if r.IsNoop() {
+		return nil
+	}
+
+	err := filepath.Walk(projectPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if info.Name() == ".git" || info.Name() == "node_modules" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		switch filepath.Ext(path) {
+		case ".go":
+			return r.RenameGoFile(path)
+		case ".sql":
+			return r.RenameSQLFile(path)
+		case ".md":
+			return r.RenameTextFile(path)
+		case ".ts", ".tsx":
+			return r.RenameScriptFile(path)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.RenamePaths(projectPath)
+}
+
+// RenameGoFile rewrites a Go source file through its AST
+func (r *EntityRenamer) RenameGoFile(filePath string) error {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read file %s: %w", filePath, err)
+	}
+
+	out, err := r.RenameGoSource(filePath, content)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filePath, out, 0644); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", filePath, err)
+	}
+
+	return nil
+}
+
+// RenameGoSource rewrites Go source code and returns the formatted result
+func (r *EntityRenamer) RenameGoSource(filename string, src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+
+	// Identifiers that must not go through the generic identifier rewrite
+	handled := make(map[*ast.Ident]bool)
+	literals := make(map[*ast.BasicLit]bool)
+
+	// Package names imported by this file, mapped to whether they are the entity package
+	imports := make(map[string]bool)
+	for _, spec := range file.Imports {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+		isEntity := path.Base(importPath) == r.from.Package && path.Base(path.Dir(importPath)) == "internal"
+		if isEntity {
+			importPath = path.Join(path.Dir(importPath), r.to.Package)
+			spec.Path.Value = strconv.Quote(importPath)
+		}
+		literals[spec.Path] = true
+
+		name := importName(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+			handled[spec.Name] = true
+		} else if isEntity {
+			name = r.from.Package
+		}
+		imports[name] = isEntity
+	}
+
+	if file.Name.Name == r.from.Package {
+		file.Name.Name = r.to.Package
+	}
+	handled[file.Name] = true
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.SelectorExpr:
+			pkg, ok := node.X.(*ast.Ident)
+			if !ok || pkg.Obj != nil {
+				return true
+			}
+			isEntity, imported := imports[pkg.Name]
+			if !imported {
+				return true
+			}
+			handled[pkg] = true
+			if isEntity {
+				pkg.Name = r.to.Package
+			} else {
+				// Identifiers from third-party packages are never ours to rename
+				handled[node.Sel] = true
+			}
+		case *ast.Ident:
+			if !handled[node] {
+				node.Name = r.renameIdent(node.Name)
+			}
+		case *ast.BasicLit:
+			if node.Kind == token.STRING && !literals[node] {
+				node.Value = r.renameStringLit(node.Value)
+			}
+		}
+		return true
+	})
+
+	for _, group := range file.Comments {
+		for _, comment := range group.List {
+			comment.Text = r.RenameText(comment.Text)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return nil, fmt.Errorf("failed to format %s: %w", filename, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// renameIdent rewrites the entity words of a camelCase identifier,
+// e.g. ListItemsResponse -> ListProductsResponse and item -> product
+func (r *EntityRenamer) renameIdent(name string) string {
+	words := splitCamel(name)
+	changed := false
+
+	for i, word := range words {
+		lower := strings.ToLower(word)
+		if lower != r.from.Snake && lower != r.from.SnakePlural {
+			continue
+		}
+		plural := lower == r.from.SnakePlural
+
+		switch {
+		case isUpper(word) && len(word) > 1:
+			words[i] = strings.ToUpper(r.pick(r.to.Snake, r.to.SnakePlural, plural))
+		case unicode.IsUpper(rune(word[0])):
+			words[i] = r.pick(r.to.Camel, r.to.CamelPlural, plural)
+		case i == 0:
+			words[i] = r.pick(r.to.LowerCamel(), r.to.LowerCamelPlural(), plural)
+		default:
+			continue
+		}
+		changed = true
+	}
+
+	if !changed {
+		return name
+	}
+	return strings.Join(words, "")
+}
+
+// renameStringLit rewrites the contents of a quoted Go string literal
+func (r *EntityRenamer) renameStringLit(lit string) string {
+	if strings.HasPrefix(lit, "`") {
+		return r.RenameText(lit)
+	}
+
+	value, err := strconv.Unquote(lit)
+	if err != nil {
+		return lit
+	}
+
+	renamed := r.RenameText(value)
+	if renamed == value {
+		return lit
+	}
+	return strconv.Quote(renamed)
+}
+
+// RenameText rewrites entity references in free text such as log messages,
+// route paths, SQL inside strings and comments. Whole words use the snake
+// spellings, snake_case tokens are rewritten per segment and camelCase
+// tokens like ListItems are rewritten as identifiers.
+func (r *EntityRenamer) RenameText(text string) string {
+	return r.word.ReplaceAllStringFunc(text, r.renameWord)
+}
+
+func (r *EntityRenamer) renameWord(match string) string {
+	lower := strings.ToLower(match)
+	if lower != r.from.Snake && lower != r.from.SnakePlural {
+		if strings.Contains(match, "_") {
+			return r.renameSnake(match)
+		}
+		return r.renameIdent(match)
+	}
+
+	plural := lower == r.from.SnakePlural
+	switch {
+	case isUpper(match):
+		return strings.ToUpper(r.pick(r.to.Snake, r.to.SnakePlural, plural))
+	case unicode.IsUpper(rune(match[0])):
+		return r.pick(r.to.Camel, r.to.CamelPlural, plural)
+	default:
+		return r.pick(r.to.Snake, r.to.SnakePlural, plural)
+	}
+}
+
+// RenameScript rewrites entity references in TypeScript sources. It works like
+// RenameText, and additionally rewrites kebab-case module and component names
+// (use-item, item-create-dialog) while leaving Tailwind classes alone.
+func (r *EntityRenamer) RenameScript(text string) string {
+	return r.token.ReplaceAllStringFunc(text, func(match string) string {
+		if !strings.Contains(match, "-") {
+			return r.renameWord(match)
+		}
+		if cssUtility.MatchString(match) {
+			return match
+		}
+		return r.renameKebab(match)
+	})
+}
+
+// RenameScriptFile applies RenameScript to a whole file
+func (r *EntityRenamer) RenameScriptFile(filePath string) error {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read file %s: %w", filePath, err)
+	}
+
+	if err := os.WriteFile(filePath, []byte(r.RenameScript(string(content))), 0644); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", filePath, err)
+	}
+
+	return nil
+}
+
+// RenameTextFile applies RenameText to a whole file
+func (r *EntityRenamer) RenameTextFile(filePath string) error {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read file %s: %w", filePath, err)
+	}
+
+	if err := os.WriteFile(filePath, []byte(r.RenameText(string(content))), 0644); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", filePath, err)
+	}
+
+	return nil
+}
+
+// RenameSQLFile rewrites identifiers and comments in a SQL migration
+func (r *EntityRenamer) RenameSQLFile(filePath string) error {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read file %s: %w", filePath, err)
+	}
+
+	if err := os.WriteFile(filePath, []byte(r.RenameSQL(string(content))), 0644); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", filePath, err)
+	}
+
+	return nil
+}
+
+// RenameSQL tokenizes SQL and rewrites entity segments of identifiers
+// (items, idx_items_name, item_id). String literals and dollar-quoted
+// bodies are left untouched; comments are treated as free text.
+func (r *EntityRenamer) RenameSQL(sql string) string {
+	var out strings.Builder
+
+	for i := 0; i < len(sql); {
+		c := sql[i]
+
+		switch {
+		case strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				end = len(sql) - i
+			}
+			out.WriteString(r.RenameText(sql[i : i+end]))
+			i += end
+
+		case strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				end = len(sql) - i
+			} else {
+				end += 4
+			}
+			out.WriteString(r.RenameText(sql[i : i+end]))
+			i += end
+
+		case c == '\'':
+			end := i + 1
+			for end < len(sql) {
+				if sql[end] == '\'' {
+					if end+1 < len(sql) && sql[end+1] == '\'' {
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			end = min(end+1, len(sql))
+			out.WriteString(sql[i:end])
+			i = end
+
+		case c == '$':
+			tag := dollarTag(sql[i:])
+			if tag == "" {
+				out.WriteByte(c)
+				i++
+				continue
+			}
+			end := strings.Index(sql[i+len(tag):], tag)
+			if end < 0 {
+				end = len(sql)
+			} else {
+				end = i + len(tag) + end + len(tag)
+			}
+			out.WriteString(sql[i:end])
+			i = end
+
+		case c == '"':
+			end := strings.IndexByte(sql[i+1:], '"')
+			if end < 0 {
+				out.WriteString(sql[i:])
+				i = len(sql)
+				continue
+			}
+			out.WriteByte('"')
+			out.WriteString(r.renameSnake(sql[i+1 : i+1+end]))
+			out.WriteByte('"')
+			i += end + 2
+
+		case isIdentStart(c):
+			end := i + 1
+			for end < len(sql) && (isIdentStart(sql[end]) || (sql[end] >= '0' && sql[end] <= '9')) {
+				end++
+			}
+			out.WriteString(r.renameSnake(sql[i:end]))
+			i = end
+
+		default:
+			out.WriteByte(c)
+			i++
+		}
+	}
+
+	return out.String()
+}
+
+// renameSnake rewrites the entity segments of a snake_case identifier
+func (r *EntityRenamer) renameSnake(ident string) string {
+	parts := strings.Split(ident, "_")
+	for i, part := range parts {
+		lower := strings.ToLower(part)
+		if lower != r.from.Snake && lower != r.from.SnakePlural {
+			continue
+		}
+		replacement := r.pick(r.to.Snake, r.to.SnakePlural, lower == r.from.SnakePlural)
+		if isUpper(part) {
+			replacement = strings.ToUpper(replacement)
+		}
+		parts[i] = replacement
+	}
+	return strings.Join(parts, "_")
+}
+
+// renameKebab rewrites the entity segments of a kebab-case name
+func (r *EntityRenamer) renameKebab(name string) string {
+	parts := strings.Split(name, "-")
+	for i, part := range parts {
+		lower := strings.ToLower(part)
+		if lower == r.from.Snake || lower == r.from.SnakePlural {
+			parts[i] = strings.ReplaceAll(r.pick(r.to.Snake, r.to.SnakePlural, lower == r.from.SnakePlural), "_", "-")
+		} else if strings.Contains(lower, r.from.Snake) {
+			parts[i] = r.renameWord(part)
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// RenamePaths renames the entity's Go package directories, frontend route
+// directories and any file whose name contains the entity, e.g.
+// migrations/000002_create_items_table.up.sql or components/item-list.tsx
+func (r *EntityRenamer) RenamePaths(projectPath string) error {
+	type rename struct{ from, to string }
+	var renames []rename
+
+	err := filepath.Walk(projectPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == projectPath {
+			return nil
+		}
+		if info.IsDir() && (info.Name() == ".git" || info.Name() == "node_modules") {
+			return filepath.SkipDir
+		}
+
+		name := info.Name()
+		newName := name
+		if info.IsDir() {
+			switch {
+			case name == r.from.Package && hasGoFiles(path):
+				newName = r.to.Package
+			case name == r.from.Snake:
+				newName = r.to.Snake
+			case name == r.from.SnakePlural:
+				newName = r.to.SnakePlural
+			}
+		} else {
+			base, ext, _ := strings.Cut(name, ".")
+			if strings.Contains(base, "-") {
+				newName = r.renameKebab(base)
+			} else {
+				newName = r.renameSnake(base)
+			}
+			if ext != "" {
+				newName += "." + ext
+			}
+		}
+
+		if newName != name {
+			renames = append(renames, rename{from: path, to: filepath.Join(filepath.Dir(path), newName)})
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to walk directory: %w", err)
+	}
+
+	// Rename deepest paths first so parent renames don't invalidate children
+	for i := len(renames) - 1; i >= 0; i-- {
+		if _, err := os.Stat(renames[i].to); err == nil {
+			return fmt.Errorf("cannot rename %s: %s already exists", renames[i].from, renames[i].to)
+		}
+		if err := os.Rename(renames[i].from, renames[i].to); err != nil {
+			return fmt.Errorf("failed to rename %s to %s: %w", renames[i].from, renames[i].to, err)
+		}
+	}
+
+	return nil
+}
+
+// hasGoFiles reports whether dir directly contains Go source files
+func hasGoFiles(dir string) bool {
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	return len(matches) > 0
+}
+
+func (r *EntityRenamer) pick(singular, plural string, isPlural bool) string {
+	if isPlural {
+		return plural
+	}
+	return singular
+}
+
+// importName guesses the package name of an import path without loading it
+func importName(importPath string) string {
+	name := path.Base(importPath)
+	if len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = path.Base(path.Dir(importPath))
+	}
+	if dot := strings.Index(name, ".v"); dot > 0 {
+		name = name[:dot]
+	}
+	name = strings.TrimPrefix(name, "go-")
+	return strings.ReplaceAll(name, "-", "")
+}
+
+// splitCamel splits an identifier into camelCase words, keeping acronyms
+// together and preserving underscores and digits as their own words:
+// "ListItemsResponse" -> [List Items Response], "itemID" -> [item ID]
+func splitCamel(name string) []string {
+	runes := []rune(name)
+	var words []string
+	start := 0
+
+	for i := 1; i < len(runes); i++ {
+		prev, cur := runes[i-1], runes[i]
+		boundary := false
+
+		switch {
+		case cur == '_' || prev == '_':
+			boundary = true
+		case unicode.IsDigit(cur) != unicode.IsDigit(prev):
+			boundary = true
+		case unicode.IsLower(prev) && unicode.IsUpper(cur):
+			boundary = true
+		case unicode.IsUpper(prev) && unicode.IsUpper(cur) && i+1 < len(runes) && unicode.IsLower(runes[i+1]):
+			boundary = true
+		}
+
+		if boundary {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+
+	return append(words, string(runes[start:]))
+}
+
+func camelJoin(words []string) string {
+	var b strings.Builder
+	for _, word := range words {
+		if word == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+func isUpper(s string) bool {
+	return strings.ToUpper(s) == s && strings.ToLower(s) != s
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// dollarTag returns the opening $tag$ of a Postgres dollar-quoted string, if any
+func dollarTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '$':
+			return s[:i+1]
+		case !isIdentStart(s[i]) && !(s[i] >= '0' && s[i] <= '9'):
+			return ""
+		}
+	}
+	return ""
+}
@@ -0,0 +1,132 @@
+package ddd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewEntityNames(t *testing.T) {
+	names := NewEntityNames("order_item")
+
+	expected := EntityNames{
+		Snake:       "order_item",
+		SnakePlural: "order_items",
+		Camel:       "OrderItem",
+		CamelPlural: "OrderItems",
+		Package:     "orderitem",
+	}
+	if names != expected {
+		t.Errorf("Expected %+v, got %+v", expected, names)
+	}
+	if names.LowerCamelPlural() != "orderItems" {
+		t.Errorf("Expected lower camel plural orderItems, got %s", names.LowerCamelPlural())
+	}
+}
+
+func TestRenameGoSource(t *testing.T) {
+	src := `package item
+
+import "github.com/example/app/internal/item"
+
+// ListItems returns all items
+func ListItems(item *item.Item) ListItemsResponse {
+	items := []*Item{item}
+	return ListItemsResponse{Items: items, Message: "Item not found"}
+}
+
+const query = ` + "`SELECT id FROM items WHERE id = $1`" + `
+`
+
+	renamer := NewEntityRenamer(TemplateEntity, "category")
+	out, err := renamer.RenameGoSource("item.go", []byte(src))
+	if err != nil {
+		t.Fatalf("RenameGoSource failed: %v", err)
+	}
+
+	result := string(out)
+	for _, want := range []string{
+		"package category",
+		`"github.com/example/app/internal/category"`,
+		"// ListCategories returns all categories",
+		"func ListCategories(category *category.Category) ListCategoriesResponse",
+		"categories := []*Category{category}",
+		`Message: "Category not found"`,
+		"SELECT id FROM categories WHERE id = $1",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, result)
+		}
+	}
+}
+
+func TestRenameSQL(t *testing.T) {
+	sql := `-- Create items table
+CREATE TABLE items (id UUID, note TEXT DEFAULT 'items');
+CREATE INDEX idx_items_name ON items(name);
+CREATE FUNCTION f() RETURNS TRIGGER AS $$ BEGIN RETURN items; END; $$ LANGUAGE plpgsql;`
+
+	expected := `-- Create products table
+CREATE TABLE products (id UUID, note TEXT DEFAULT 'items');
+CREATE INDEX idx_products_name ON products(name);
+CREATE FUNCTION f() RETURNS TRIGGER AS $$ BEGIN RETURN items; END; $$ LANGUAGE plpgsql;`
+
+	renamer := NewEntityRenamer(TemplateEntity, "product")
+	if got := renamer.RenameSQL(sql); got != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestRenameScript(t *testing.T) {
+	src := `import { useItemList } from "@/features/item/hooks/use-item";
+import { ItemCreateDialog } from "@/features/item/components/item-create-dialog";
+
+const QUERY_KEY = "items";
+<div className="flex justify-between items-center">Add Item</div>`
+
+	expected := `import { useOrderItemList } from "@/features/order_item/hooks/use-order-item";
+import { OrderItemCreateDialog } from "@/features/order_item/components/order-item-create-dialog";
+
+const QUERY_KEY = "order_items";
+<div className="flex justify-between items-center">Add OrderItem</div>`
+
+	renamer := NewEntityRenamer(TemplateEntity, "order_item")
+	if got := renamer.RenameScript(src); got != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestRenamePaths(t *testing.T) {
+	dir := t.TempDir()
+	for _, file := range []string{
+		"internal/item/model.go",
+		"migrations/000002_create_items_table.up.sql",
+		"src/features/item/components/item-list.tsx",
+		"src/app/items/page.tsx",
+	} {
+		path := filepath.Join(dir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	renamer := NewEntityRenamer(TemplateEntity, "blog-post")
+	if err := renamer.RenamePaths(dir); err != nil {
+		t.Fatalf("RenamePaths failed: %v", err)
+	}
+
+	for _, file := range []string{
+		"internal/blogpost/model.go",
+		"migrations/000002_create_blog_posts_table.up.sql",
+		"src/features/blog_post/components/blog-post-list.tsx",
+		"src/app/blog_posts/page.tsx",
+	} {
+		if _, err := os.Stat(filepath.Join(dir, file)); err != nil {
+			t.Errorf("Expected %s to exist: %v", file, err)
+		}
+	}
+}
@@ -4,37 +4,40 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"text/template"
-)
 
-type TemplateVars struct {
-	ProjectName        string
-	ModuleName         string
-	PrimaryEntity      string
-	EntityCapitalized  string
-	EntityPlural       string
-	APIPort            string
-	DBPort             string
-	RedisPort          string
-	DBName             string
-	DBUser             string
-	DBPassword         string
-	IncludeAuth        bool
-	IncludeS3          bool
-	IncludeRedis       bool
-	ProjectDescription string
-}
+	"github.com/darkphotonKN/go-template-generator/internal/manifest"
+)
 
 type Replacer struct {
-	vars *TemplateVars
+	vars manifest.Vars
 }
 
-func NewReplacer(vars *TemplateVars) *Replacer {
+func NewReplacer(vars manifest.Vars) *Replacer {
 	return &Replacer{vars: vars}
 }
 
+// TemplateFuncs are the helpers available to template files and to derive
+// expressions in template manifests
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"camel":   func(s string) string { return NewEntityNames(s).Camel },
+		"plural":  func(s string) string { return NewEntityNames(s).SnakePlural },
+		"snake":   func(s string) string { return NewEntityNames(s).Snake },
+		"package": func(s string) string { return NewEntityNames(s).Package },
+		"title": func(s string) string {
+			words := strings.FieldsFunc(s, func(r rune) bool { return r == '-' || r == '_' || r == ' ' })
+			for i, word := range words {
+				words[i] = strings.ToUpper(word[:1]) + word[1:]
+			}
+			return strings.Join(words, " ")
+		},
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+	}
+}
+
 // ProcessFile processes a single file with template variables
 func (r *Replacer) ProcessFile(filePath string) error {
 	// Read the file
@@ -44,7 +47,8 @@ func (r *Replacer) ProcessFile(filePath string) error {
 	}
 
 	// Parse and execute template
-	tmpl, err := template.New("file").Parse(string(content))
+	// Referencing a variable the manifest doesn't declare is an error
+	tmpl, err := template.New("file").Funcs(TemplateFuncs()).Option("missingkey=error").Parse(string(content))
 	if err != nil {
 		return fmt.Errorf("failed to parse template in %s: %w", filePath, err)
 	}
@@ -85,6 +89,19 @@ func (r *Replacer) ProcessDirectory(dirPath string) error {
 
 // shouldProcessFile determines if a file should be processed
 func (r *Replacer) shouldProcessFile(path string) bool {
+	return isTemplateFile(path)
+}
+
+// renderedFiles are the files rendered despite having no .tmpl extension
+var renderedFiles = []string{
+	"docker-compose.yml",
+	".env.example",
+	"CLAUDE.md",
+}
+
+// isTemplateFile reports whether a template file is rendered with the
+// template variables rather than copied as is
+func isTemplateFile(path string) bool {
 	// Process .tmpl files and certain other files
 	if strings.HasSuffix(path, ".tmpl") {
 		return true
@@ -92,13 +109,7 @@ func (r *Replacer) shouldProcessFile(path string) bool {
 
 	// Also process specific files without .tmpl extension
 	fileName := filepath.Base(path)
-	processableFiles := []string{
-		"docker-compose.yml",
-		".env.example",
-		"CLAUDE.md",
-	}
-
-	for _, file := range processableFiles {
+	for _, file := range renderedFiles {
 		if fileName == file {
 			return true
 		}
@@ -139,24 +150,6 @@ func (r *Replacer) RenameTemplateFiles(dirPath string) error {
 	return nil
 }
 
-// RenameEntityDirectory renames the generic "entity" directory to the actual entity name
-func (r *Replacer) RenameEntityDirectory(projectPath string) error {
-	entityPath := filepath.Join(projectPath, "internal", "entity")
-	newEntityPath := filepath.Join(projectPath, "internal", r.vars.PrimaryEntity)
-
-	// Check if entity directory exists
-	if _, err := os.Stat(entityPath); os.IsNotExist(err) {
-		return nil // Nothing to rename
-	}
-
-	// Rename the directory
-	if err := os.Rename(entityPath, newEntityPath); err != nil {
-		return fmt.Errorf("failed to rename entity directory: %w", err)
-	}
-
-	return nil
-}
-
 // GenerateEntityName generates plural and capitalized forms of entity
 func GenerateEntityNames(entity string) (capitalized, plural string) {
 	// Capitalize first letter
@@ -176,44 +169,3 @@ func GenerateEntityNames(entity string) (capitalized, plural string) {
 
 	return capitalized, plural
 }
-
-// CleanGoImports fixes import paths after template processing
-func (r *Replacer) CleanGoImports(projectPath string) error {
-	return filepath.Walk(projectPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		if info.IsDir() || !strings.HasSuffix(path, ".go") {
-			return nil
-		}
-
-		content, err := os.ReadFile(path)
-		if err != nil {
-			return err
-		}
-
-		// Fix import paths that might have been broken during template processing
-		contentStr := string(content)
-
-		// Replace any remaining template artifacts
-		contentStr = r.fixImportPaths(contentStr)
-
-		if err := os.WriteFile(path, []byte(contentStr), info.Mode()); err != nil {
-			return fmt.Errorf("failed to write cleaned file %s: %w", path, err)
-		}
-
-		return nil
-	})
-}
-
-// fixImportPaths cleans up import paths
-func (r *Replacer) fixImportPaths(content string) string {
-	// Remove any duplicate quotes or malformed import paths
-	importRegex := regexp.MustCompile(`"([^"]+/[^"]+)"`)
-	return importRegex.ReplaceAllStringFunc(content, func(match string) string {
-		// Clean up any template artifacts in import paths
-		cleaned := strings.ReplaceAll(match, "//", "/")
-		return cleaned
-	})
-}
\ No newline at end of file
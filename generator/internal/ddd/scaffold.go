@@ -0,0 +1,507 @@
+package ddd
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/darkphotonKN/go-template-generator/internal/schema"
+	"golang.org/x/mod/modfile"
+)
+
+// RoutesFile is where the API template wires its entities into the router
+const RoutesFile = "config/routes.go"
+
+// migrationName splits a migration file name into its number and the rest
+var migrationName = regexp.MustCompile(`^(\d+)_(.+)$`)
+
+// EntityScaffolder adds entities to a rendered API project by cloning the
+// template's sample entity: its domain package, its migration pair and its
+// wiring in config/routes.go. Nothing that already exists is overwritten.
+type EntityScaffolder struct {
+	templates   fs.FS
+	templateDir string
+	projectDir  string
+}
+
+func NewEntityScaffolder(templates fs.FS, templateDir, projectDir string) *EntityScaffolder {
+	return &EntityScaffolder{
+		templates:   templates,
+		templateDir: templateDir,
+		projectDir:  projectDir,
+	}
+}
+
+// Add renders entity into the project with the given fields, or the sample
+// entity's fields when there are none. Its routes are registered next to
+// those of the existing entities, which must still be wired the way the
+// template wires them.
+func (s *EntityScaffolder) Add(entity string, fields []schema.Field, existing []string) error {
+	names := NewEntityNames(entity)
+	renamer := NewEntityRenamer(TemplateEntity, entity)
+	fieldRewriter := NewFieldRewriter(entity, fields)
+
+	goMod, err := os.ReadFile(filepath.Join(s.projectDir, "go.mod"))
+	if err != nil {
+		return fmt.Errorf("failed to read go.mod: %w", err)
+	}
+	modulePath := modfile.ModulePath(goMod)
+
+	templateGoMod, err := fs.ReadFile(s.templates, path.Join(s.templateDir, "go.mod.tmpl"))
+	if err != nil {
+		return fmt.Errorf("failed to read template go.mod: %w", err)
+	}
+	rewriter := NewModuleRewriter(modulePath)
+	templateModule := modfile.ModulePath(templateGoMod)
+
+	// Everything is rendered before anything is written so a conflict
+	// leaves the project untouched
+	files := make(map[string][]byte)
+
+	// Domain package
+	pkgDir := filepath.Join("internal", names.Package)
+	if _, err := os.Stat(filepath.Join(s.projectDir, pkgDir)); err == nil {
+		return fmt.Errorf("%s already exists", pkgDir)
+	}
+
+	sampleDir := path.Join(s.templateDir, "internal", TemplateEntity)
+	entries, err := fs.ReadDir(s.templates, sampleDir)
+	if err != nil {
+		return fmt.Errorf("failed to read template entity: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") {
+			continue
+		}
+
+		src, err := fs.ReadFile(s.templates, path.Join(sampleDir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		src, _, err = rewriter.RewriteSource(entry.Name(), src, templateModule)
+		if err != nil {
+			return err
+		}
+		src, err = renamer.RenameGoSource(entry.Name(), src)
+		if err != nil {
+			return err
+		}
+		if len(fields) > 0 {
+			if src, err = fieldRewriter.RewriteFile(entry.Name(), src); err != nil {
+				return err
+			}
+		}
+
+		base := strings.TrimSuffix(entry.Name(), ".go")
+		files[filepath.Join(pkgDir, renamer.renameSnake(base)+".go")] = src
+	}
+
+	// Migrations, numbered after the project's latest one
+	migrations, err := s.renderMigrations(renamer, names)
+	if err != nil {
+		return err
+	}
+	for name, content := range migrations {
+		if len(fields) > 0 {
+			if content, err = fieldRewriter.RewriteFile(filepath.Base(name), content); err != nil {
+				return err
+			}
+		}
+		files[name] = content
+	}
+
+	// Route registration
+	routesPath := filepath.Join(s.projectDir, RoutesFile)
+	routes, err := os.ReadFile(routesPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", RoutesFile, err)
+	}
+	templateRoutes, err := fs.ReadFile(s.templates, path.Join(s.templateDir, RoutesFile))
+	if err != nil {
+		return fmt.Errorf("failed to read template routes: %w", err)
+	}
+	templateRoutes, _, err = rewriter.RewriteSource(RoutesFile, templateRoutes, templateModule)
+	if err != nil {
+		return err
+	}
+	routes, err = wireEntity(routes, templateRoutes, modulePath, entity, existing)
+	if err != nil {
+		return fmt.Errorf("failed to register %s routes: %w", entity, err)
+	}
+
+	for name, content := range files {
+		target := filepath.Join(s.projectDir, name)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, content, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+
+	if err := os.WriteFile(routesPath, routes, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", RoutesFile, err)
+	}
+
+	return nil
+}
+
+// renderMigrations renders the sample entity's migrations for an entity,
+// numbered after the newest migration in the project
+func (s *EntityScaffolder) renderMigrations(renamer *EntityRenamer, names EntityNames) (map[string][]byte, error) {
+	projectMigrations, err := os.ReadDir(filepath.Join(s.projectDir, "migrations"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	next := 1
+	for _, entry := range projectMigrations {
+		match := migrationName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		if n, _ := strconv.Atoi(match[1]); n >= next {
+			next = n + 1
+		}
+		if createsTable(match[2], names.SnakePlural) {
+			return nil, fmt.Errorf("migration %s already exists", entry.Name())
+		}
+	}
+
+	templateMigrations, err := fs.ReadDir(s.templates, path.Join(s.templateDir, "migrations"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template migrations: %w", err)
+	}
+
+	files := make(map[string][]byte)
+	from := NewEntityNames(TemplateEntity)
+	for _, entry := range templateMigrations {
+		match := migrationName.FindStringSubmatch(entry.Name())
+		if match == nil || !createsTable(match[2], from.SnakePlural) {
+			continue
+		}
+
+		content, err := fs.ReadFile(s.templates, path.Join(s.templateDir, "migrations", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		base, ext, _ := strings.Cut(match[2], ".")
+		name := fmt.Sprintf("%0*d_%s.%s", len(match[1]), next, renamer.renameSnake(base), ext)
+		files[filepath.Join("migrations", name)] = []byte(renamer.RenameSQL(string(content)))
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("template has no migrations for %s", from.SnakePlural)
+	}
+
+	return files, nil
+}
+
+// createsTable reports whether a migration, named without its number, is
+// the one creating table, e.g. create_items_table.up.sql for items
+func createsTable(migration, table string) bool {
+	base, _, _ := strings.Cut(migration, ".")
+	return base == "create_"+table+"_table"
+}
+
+// wiringRun is a sequence of statements wiring one entity, e.g. its
+// repository, service and handler or its route group
+type wiringRun struct {
+	list  []ast.Stmt
+	start int // index of the first statement in list
+	end   int // index of the last statement in list
+	doc   token.Pos
+}
+
+// wireEntity adds the wiring of entity to a routes file, copied from the
+// template's wiring of its sample entity. Every piece is inserted after the
+// matching piece of the last existing entity found.
+func wireEntity(src, templateSrc []byte, modulePath, entity string, existing []string) ([]byte, error) {
+	names := NewEntityNames(entity)
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, RoutesFile, src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", RoutesFile, err)
+	}
+	for _, spec := range file.Imports {
+		if importPath, _ := strconv.Unquote(spec.Path.Value); importPath == path.Join(modulePath, "internal", names.Package) {
+			return nil, fmt.Errorf("%s already imports %s", RoutesFile, importPath)
+		}
+	}
+
+	// The wiring to insert, as the template would have it for entity
+	newFset, newFile, newSrc, err := renderRoutes(templateSrc, entity)
+	if err != nil {
+		return nil, err
+	}
+	newRuns := wiringRuns(newFset, newFile, names.Package)
+	if len(newRuns) == 0 {
+		return nil, fmt.Errorf("template %s has no entity wiring", RoutesFile)
+	}
+
+	// Where each run goes: after the same run of the last existing entity
+	insertAt := make([]int, len(newRuns))
+	importLine := 0
+	for _, other := range existing {
+		otherNames := NewEntityNames(other)
+		otherFset, otherFile, _, err := renderRoutes(templateSrc, other)
+		if err != nil {
+			return nil, err
+		}
+		otherRuns := wiringRuns(otherFset, otherFile, otherNames.Package)
+		if len(otherRuns) != len(newRuns) {
+			continue
+		}
+
+		for i, run := range otherRuns {
+			want := nodeString(otherFset, run.list[run.start])
+			list, idx := findStmt(fset, file, want)
+			if list == nil {
+				continue
+			}
+			end := min(idx+run.end-run.start, len(list)-1)
+			insertAt[i] = max(insertAt[i], fset.Position(list[end].End()).Line)
+		}
+
+		for _, spec := range file.Imports {
+			if importPath, _ := strconv.Unquote(spec.Path.Value); importPath == path.Join(modulePath, "internal", otherNames.Package) {
+				importLine = max(importLine, fset.Position(spec.End()).Line)
+			}
+		}
+	}
+
+	if importLine == 0 {
+		return nil, fmt.Errorf("%s doesn't import any of the existing entities", RoutesFile)
+	}
+	for i, line := range insertAt {
+		if line == 0 {
+			return nil, fmt.Errorf("couldn't find where %s wires %s", RoutesFile,
+				strings.Split(nodeString(newFset, newRuns[i].list[newRuns[i].start]), "\n")[0])
+		}
+	}
+
+	// Insert bottom-up so earlier line numbers stay valid
+	type insertion struct {
+		line int
+		text string
+	}
+	insertions := []insertion{{
+		line: importLine,
+		text: strconv.Quote(path.Join(modulePath, "internal", names.Package)) + "\n",
+	}}
+	newLines := strings.SplitAfter(string(newSrc), "\n")
+	for i, run := range newRuns {
+		from := newFset.Position(run.list[run.start].Pos()).Line
+		if run.doc.IsValid() {
+			from = newFset.Position(run.doc).Line
+		}
+		to := newFset.Position(run.list[run.end].End()).Line
+
+		text := strings.Join(newLines[from-1:to], "")
+		if run.doc.IsValid() {
+			text = "\n" + text
+		}
+		insertions = append(insertions, insertion{line: insertAt[i], text: text})
+	}
+	sort.SliceStable(insertions, func(i, j int) bool { return insertions[i].line > insertions[j].line })
+
+	lines := strings.SplitAfter(string(src), "\n")
+	for _, ins := range insertions {
+		lines = append(lines[:ins.line], append([]string{ins.text}, lines[ins.line:]...)...)
+	}
+
+	out, err := format.Source([]byte(strings.Join(lines, "")))
+	if err != nil {
+		return nil, fmt.Errorf("failed to format %s: %w", RoutesFile, err)
+	}
+
+	return out, nil
+}
+
+// renderRoutes renames the template's routes to entity and parses the result
+func renderRoutes(templateSrc []byte, entity string) (*token.FileSet, *ast.File, []byte, error) {
+	src, err := NewEntityRenamer(TemplateEntity, entity).RenameGoSource(RoutesFile, templateSrc)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, RoutesFile, src, parser.ParseComments)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to parse %s: %w", RoutesFile, err)
+	}
+
+	return fset, file, src, nil
+}
+
+// wiringRuns finds the runs of statements that use the package pkg or the
+// variables those statements declare. A bare block whose own statements use
+// them forms a run together with the statement before it when it also uses
+// what that statement declares (a gin route group). A comment right above a run
+// is kept with it when it names the entity.
+func wiringRuns(fset *token.FileSet, file *ast.File, pkg string) []wiringRun {
+	var runs []wiringRun
+	declared := make(map[*ast.Object]bool)
+
+	// uses looks at a statement without its nested blocks
+	uses := func(stmt ast.Stmt) bool {
+		found := false
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			if found {
+				return false
+			}
+			switch x := n.(type) {
+			case *ast.BlockStmt:
+				return false
+			case *ast.SelectorExpr:
+				if id, ok := x.X.(*ast.Ident); ok && id.Obj == nil && id.Name == pkg {
+					found = true
+				}
+			case *ast.Ident:
+				if x.Obj != nil && declared[x.Obj] {
+					found = true
+				}
+			}
+			return !found
+		})
+		return found
+	}
+
+	var visit func(list []ast.Stmt)
+	visit = func(list []ast.Stmt) {
+		var run *wiringRun
+		for i, stmt := range list {
+			inRun := false
+			if block, ok := stmt.(*ast.BlockStmt); ok && i > 0 {
+				previous := make(map[*ast.Object]bool)
+				for _, obj := range definedObjects(list[i-1]) {
+					previous[obj] = true
+				}
+				if deepUses(block, previous) && (run != nil || slices.ContainsFunc(block.List, uses)) {
+					inRun = true
+					if run == nil {
+						// The group variable starts the run
+						runs = append(runs, wiringRun{list: list, start: i - 1})
+						run = &runs[len(runs)-1]
+						run.doc = docAbove(fset, file, list[i-1], pkg)
+					}
+				}
+			} else if !ok {
+				inRun = uses(stmt)
+			}
+
+			if !inRun {
+				run = nil
+				ast.Inspect(stmt, func(n ast.Node) bool {
+					if inner := stmtList(n); inner != nil {
+						visit(inner)
+						return false
+					}
+					return true
+				})
+				continue
+			}
+
+			for _, obj := range definedObjects(stmt) {
+				declared[obj] = true
+			}
+			if run == nil {
+				runs = append(runs, wiringRun{list: list, start: i})
+				run = &runs[len(runs)-1]
+				run.doc = docAbove(fset, file, stmt, pkg)
+			}
+			run.end = i
+		}
+	}
+
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body != nil {
+			visit(fn.Body.List)
+		}
+	}
+
+	return runs
+}
+
+// deepUses reports whether any identifier under node refers to objs
+func deepUses(node ast.Node, objs map[*ast.Object]bool) bool {
+	found := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && id.Obj != nil && objs[id.Obj] {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// definedObjects returns the objects a statement declares
+func definedObjects(stmt ast.Stmt) []*ast.Object {
+	var objs []*ast.Object
+	if assign, ok := stmt.(*ast.AssignStmt); ok && assign.Tok == token.DEFINE {
+		for _, lhs := range assign.Lhs {
+			if id, ok := lhs.(*ast.Ident); ok && id.Obj != nil && id.Obj.Decl == assign {
+				objs = append(objs, id.Obj)
+			}
+		}
+	}
+	return objs
+}
+
+// docAbove returns the comment on the lines right above stmt when it mentions
+// the entity ("// OrderItem endpoints" for package orderitem)
+func docAbove(fset *token.FileSet, file *ast.File, stmt ast.Stmt, pkg string) token.Pos {
+	line := fset.Position(stmt.Pos()).Line
+	for _, group := range file.Comments {
+		if fset.Position(group.End()).Line != line-1 {
+			continue
+		}
+		text := strings.ToLower(strings.ReplaceAll(group.Text(), " ", ""))
+		if strings.Contains(text, pkg) {
+			return group.Pos()
+		}
+	}
+	return token.NoPos
+}
+
+// findStmt finds a statement whose source matches want in any function of file
+func findStmt(fset *token.FileSet, file *ast.File, want string) ([]ast.Stmt, int) {
+	var foundList []ast.Stmt
+	foundIdx := -1
+	ast.Inspect(file, func(n ast.Node) bool {
+		if foundList != nil {
+			return false
+		}
+		for i, stmt := range stmtList(n) {
+			if nodeString(fset, stmt) == want {
+				foundList, foundIdx = stmtList(n), i
+				return false
+			}
+		}
+		return true
+	})
+	return foundList, foundIdx
+}
+
+func nodeString(fset *token.FileSet, node ast.Node) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, node); err != nil {
+		return ""
+	}
+	return buf.String()
+}
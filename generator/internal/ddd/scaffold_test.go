@@ -0,0 +1,119 @@
+package ddd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/darkphotonKN/go-template-generator/templates"
+)
+
+const templateRoutes = `package config
+
+import (
+	"example.com/app/internal/item"
+	"github.com/gin-gonic/gin"
+)
+
+func SetupRoutes(router *gin.Engine) {
+	// Initialize services
+	itemRepo := item.NewRepository()
+	itemHandler := item.NewHandler(itemRepo)
+
+	api := router.Group("/api")
+	{
+		// Item endpoints
+		items := api.Group("/items")
+		{
+			items.GET("", itemHandler.ListItems)
+		}
+	}
+}
+`
+
+func TestWireEntity(t *testing.T) {
+	project, err := NewEntityRenamer(TemplateEntity, "product").RenameGoSource(RoutesFile, []byte(templateRoutes))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := wireEntity(project, []byte(templateRoutes), "example.com/app", "order_item", []string{"product"})
+	if err != nil {
+		t.Fatalf("wireEntity failed: %v", err)
+	}
+
+	expected := `package config
+
+import (
+	"example.com/app/internal/orderitem"
+	"example.com/app/internal/product"
+	"github.com/gin-gonic/gin"
+)
+
+func SetupRoutes(router *gin.Engine) {
+	// Initialize services
+	productRepo := product.NewRepository()
+	productHandler := product.NewHandler(productRepo)
+	orderItemRepo := orderitem.NewRepository()
+	orderItemHandler := orderitem.NewHandler(orderItemRepo)
+
+	api := router.Group("/api")
+	{
+		// Product endpoints
+		products := api.Group("/products")
+		{
+			products.GET("", productHandler.ListProducts)
+		}
+
+		// OrderItem endpoints
+		orderItems := api.Group("/order_items")
+		{
+			orderItems.GET("", orderItemHandler.ListOrderItems)
+		}
+	}
+}
+`
+	if string(out) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, out)
+	}
+
+	// Wiring the same entity twice is refused
+	if _, err := wireEntity(out, []byte(templateRoutes), "example.com/app", "order_item", []string{"product"}); err == nil || !strings.Contains(err.Error(), "already imports") {
+		t.Errorf("Expected an 'already imports' error, got %v", err)
+	}
+}
+
+func TestRenderMigrationsOfEntityEndingWithAnother(t *testing.T) {
+	projectDir := t.TempDir()
+	migrations := filepath.Join(projectDir, "migrations")
+	if err := os.MkdirAll(migrations, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"000001_init_schema.up.sql", "000002_create_line_items_table.up.sql", "000002_create_line_items_table.down.sql"} {
+		if err := os.WriteFile(filepath.Join(migrations, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scaffolder := NewEntityScaffolder(templates.FS, APITemplate, projectDir)
+	files, err := scaffolder.renderMigrations(NewEntityRenamer(TemplateEntity, "item"), NewEntityNames("item"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for name := range files {
+		names = append(names, filepath.ToSlash(name))
+	}
+	slices.Sort(names)
+	want := []string{"migrations/000003_create_items_table.down.sql", "migrations/000003_create_items_table.up.sql"}
+	if !slices.Equal(names, want) {
+		t.Errorf("migrations = %v, want %v", names, want)
+	}
+
+	// The entity's own table still can't be created twice
+	if _, err := NewEntityScaffolder(templates.FS, APITemplate, projectDir).renderMigrations(NewEntityRenamer(TemplateEntity, "line_item"), NewEntityNames("line_item")); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("expected line_items to exist already, got %v", err)
+	}
+}
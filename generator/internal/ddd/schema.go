@@ -0,0 +1,85 @@
+package ddd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/darkphotonKN/go-template-generator/internal/config"
+	"github.com/darkphotonKN/go-template-generator/internal/registry"
+	"github.com/darkphotonKN/go-template-generator/internal/schema"
+)
+
+// Source is what the file a project's entities come from declares
+type Source struct {
+	Schema         *schema.Schema
+	Migrations     map[string]string // from SQL, each entity's create-table DDL
+	DownMigrations map[string]string // from SQL, what each entity's down migration drops besides its tables
+	API            *schema.API       // from OpenAPI, the routes to fit
+	SchemaName     string            // name the file is kept under, schema.StoredName when empty
+	Warnings       []string
+}
+
+// LoadSource reads the entities from a schema file, Postgres DDL or an
+// OpenAPI document, whichever path is set
+func LoadSource(schemaFile, sqlFile, openAPIFile string) (*Source, error) {
+	switch {
+	case schemaFile != "":
+		domain, err := schema.Load(schemaFile)
+		if err != nil {
+			return nil, err
+		}
+		return &Source{Schema: domain}, nil
+	case sqlFile != "":
+		imp, err := schema.LoadSQL(sqlFile, TableName)
+		if err != nil {
+			return nil, err
+		}
+		return &Source{Schema: imp.Schema, Migrations: imp.Migrations(), DownMigrations: imp.DownMigrations(), Warnings: imp.Warnings}, nil
+	}
+
+	imp, err := schema.LoadOpenAPI(openAPIFile, TableName)
+	if err != nil {
+		return nil, err
+	}
+	name := "openapi" + strings.ToLower(filepath.Ext(openAPIFile))
+	if name == "openapi.yml" {
+		name = "openapi.yaml"
+	}
+	return &Source{Schema: imp.Schema, API: imp.API, SchemaName: name, Warnings: imp.Warnings}, nil
+}
+
+// SchemaChanges compares a schema with the one a registered project was
+// generated from and returns what would change, along with the stored
+// schema's path. Nothing is written.
+func SchemaChanges(cfg *config.Config, projectName string, s *schema.Schema) ([]schema.Change, string, error) {
+	project, err := registry.NewManager(cfg.ProjectsRegistry).Get(projectName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	projectDir := project.Path
+	if projectDir == "" {
+		// Registered before paths were recorded: look in the working directory
+		projectDir = projectName
+		if serverDir := filepath.Join(projectName, projectName+"-server"); isDir(serverDir) {
+			projectDir = serverDir
+		}
+	}
+
+	storedPath, err := schema.FindStored(projectDir)
+	if err != nil {
+		return nil, "", err
+	}
+	stored, err := schema.LoadStored(storedPath, TableName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return schema.Diff(stored, s), storedPath, nil
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,175 @@
+package ddd
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	generator "github.com/darkphotonKN/go-template-generator"
+	"github.com/darkphotonKN/go-template-generator/internal/config"
+)
+
+func TestLoadSourceFromSQLBuilds(t *testing.T) {
+	ddl := `
+CREATE TABLE products (
+    id UUID PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    rating numeric(3,1) DEFAULT 0,
+    stock integer DEFAULT 10,
+    note text,
+    released_at timestamptz DEFAULT now(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+`
+	path := filepath.Join(t.TempDir(), "shop.sql")
+	if err := os.WriteFile(path, []byte(ddl), 0644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadSource("", path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := loaded.Schema.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	buildProject(t, loaded.Schema, loaded.Migrations)
+}
+
+// migrationObject matches a statement creating or dropping a table, type or
+// function
+var migrationObject = regexp.MustCompile(`(?i)\b(CREATE|DROP)\s+(OR\s+REPLACE\s+)?(TABLE|TYPE|FUNCTION)\s+(IF\s+(?:NOT\s+)?EXISTS\s+)?(\w+)`)
+
+// migrateDatabase stands in for Postgres running migrations: it tracks the
+// tables, types and functions they create and drop, and fails as Postgres
+// would on creating what exists or dropping what a table still uses
+type migrateDatabase map[string]string // "kind name" to the statements defining it
+
+func (db migrateDatabase) run(file, sql string) error {
+	matches := migrationObject.FindAllStringSubmatchIndex(sql, -1)
+	for i, m := range matches {
+		end := len(sql)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		verb, kind, name := strings.ToUpper(sql[m[2]:m[3]]), strings.ToUpper(sql[m[6]:m[7]]), sql[m[10]:m[11]]
+		replace, ifExists := m[4] >= 0, m[8] >= 0
+		key := kind + " " + name
+		_, exists := db[key]
+
+		if verb == "CREATE" {
+			if exists && !replace && !ifExists {
+				return fmt.Errorf("%s: %s %s already exists", file, strings.ToLower(kind), name)
+			}
+			db[key] = sql[m[0]:end]
+			continue
+		}
+		for other, definition := range db {
+			if !strings.HasPrefix(other, "TABLE ") || other == key {
+				continue
+			}
+			if kind == "TYPE" && regexp.MustCompile(`\b`+name+`\b`).MatchString(definition) ||
+				kind == "TABLE" && strings.Contains(definition, "REFERENCES "+name+"(") {
+				return fmt.Errorf("%s: can't drop %s %s, %s depends on it", file, strings.ToLower(kind), name, strings.ToLower(other))
+			}
+		}
+		if !exists && !ifExists {
+			return fmt.Errorf("%s: %s %s doesn't exist", file, strings.ToLower(kind), name)
+		}
+		delete(db, key)
+	}
+	return nil
+}
+
+func TestSQLImportMigrationsRoundTrip(t *testing.T) {
+	ddl := `
+CREATE TYPE order_status AS ENUM ('pending', 'paid', 'shipped');
+
+CREATE OR REPLACE FUNCTION touch() RETURNS trigger AS $$
+BEGIN
+    NEW.updated_at = NOW();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TABLE customers (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE orders (
+    id UUID PRIMARY KEY,
+    customer_id UUID NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    status order_status NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+`
+	path := filepath.Join(t.TempDir(), "shop.sql")
+	if err := os.WriteFile(path, []byte(ddl), 0644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadSource("", path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Parse(generator.DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Hooks = nil
+	opts := &GeneratorOptions{
+		ProjectName:    "shop",
+		Entities:       loaded.Schema.EntityNames(),
+		Fields:         loaded.Schema.Fields(),
+		Relations:      loaded.Schema.Relations(),
+		Migrations:     loaded.Migrations,
+		DownMigrations: loaded.DownMigrations,
+		Config:         cfg,
+		APIPort:        8000,
+		DBPort:         5432,
+		RedisPort:      6379,
+	}
+	dir := t.TempDir()
+	if err := Render(opts, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	migrations := filepath.Join(dir, "shop", "migrations")
+	ups, _ := filepath.Glob(filepath.Join(migrations, "*.up.sql"))
+	downs, _ := filepath.Glob(filepath.Join(migrations, "*.down.sql"))
+	slices.Reverse(downs)
+	db := migrateDatabase{}
+	migrate := func(files []string) {
+		t.Helper()
+		for _, file := range files {
+			sql, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := db.run(filepath.Base(file), string(sql)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// make migrate-up, migrate-down and migrate-up again
+	migrate(ups)
+	for _, want := range []string{"TYPE order_status", "FUNCTION touch", "TABLE orders"} {
+		if _, ok := db[want]; !ok {
+			t.Errorf("migrating up doesn't create %s", strings.ToLower(want))
+		}
+	}
+	migrate(downs)
+	if len(db) > 0 {
+		t.Errorf("migrating down leaves %v", slices.Sorted(maps.Keys(db)))
+	}
+	migrate(ups)
+}
@@ -0,0 +1,714 @@
+package ddd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// SpecFile is the OpenAPI document generated projects serve at /openapi.json
+const SpecFile = "internal/docs/openapi.json"
+
+var (
+	basePath   = regexp.MustCompile(`api := router\.Group\("([^"]*)"\)`)
+	handlerVar = regexp.MustCompile(`(\w+) := (\w+)\.NewHandler\(`)
+)
+
+// SpecInfo is the info object of the OpenAPI document
+type SpecInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// SpecWriter derives a project's OpenAPI document from its source: the
+// routes, the bodies and status codes each handler answers with, and the
+// models and requests behind them. Projects without the docs package are
+// left alone.
+type SpecWriter struct {
+	projectDir string
+	info       *SpecInfo // nil keeps the info of the current document
+
+	packages   map[string]*goPackage
+	schemas    map[string]*specSchema
+	components map[string]string // component name by package-qualified type
+}
+
+func NewSpecWriter(projectDir string, info *SpecInfo) *SpecWriter {
+	return &SpecWriter{
+		projectDir: projectDir,
+		info:       info,
+		packages:   make(map[string]*goPackage),
+		schemas:    make(map[string]*specSchema),
+		components: make(map[string]string),
+	}
+}
+
+// Apply writes the document to SpecFile
+func (w *SpecWriter) Apply() error {
+	path := filepath.Join(w.projectDir, SpecFile)
+	if !isDir(filepath.Dir(path)) {
+		return nil
+	}
+
+	info := w.info
+	if info == nil {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read OpenAPI document: %w", err)
+		}
+		var current struct{ Info SpecInfo }
+		if err := json.Unmarshal(data, &current); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", SpecFile, err)
+		}
+		info = &current.Info
+	}
+
+	doc, err := w.document(*info)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode OpenAPI document: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write OpenAPI document: %w", err)
+	}
+	return nil
+}
+
+type specDocument struct {
+	OpenAPI    string                        `json:"openapi"`
+	Info       SpecInfo                      `json:"info"`
+	Security   []map[string][]string         `json:"security,omitempty"`
+	Paths      map[string]map[string]*specOp `json:"paths"`
+	Components specComponents                `json:"components"`
+}
+
+type specComponents struct {
+	Schemas         map[string]*specSchema       `json:"schemas"`
+	SecuritySchemes map[string]map[string]string `json:"securitySchemes,omitempty"`
+}
+
+type specOp struct {
+	OperationID string                  `json:"operationId"`
+	Summary     string                  `json:"summary"`
+	Tags        []string                `json:"tags"`
+	Parameters  []specParameter         `json:"parameters,omitempty"`
+	RequestBody *specBody               `json:"requestBody,omitempty"`
+	Responses   map[string]specResponse `json:"responses"`
+	Security    *[]map[string][]string  `json:"security,omitempty"` // an empty list makes a route public
+}
+
+type specParameter struct {
+	Name     string      `json:"name"`
+	In       string      `json:"in"`
+	Required bool        `json:"required,omitempty"`
+	Schema   *specSchema `json:"schema"`
+}
+
+type specBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]specMedia `json:"content"`
+}
+
+type specResponse struct {
+	Description string               `json:"description"`
+	Content     map[string]specMedia `json:"content,omitempty"`
+}
+
+type specMedia struct {
+	Schema *specSchema `json:"schema"`
+}
+
+// specSchema is the subset of JSON Schema the generated models need
+type specSchema struct {
+	Ref              string         `json:"$ref,omitempty"`
+	Type             any            `json:"type,omitempty"` // a name, or [name, "null"] for pointers
+	Format           string         `json:"format,omitempty"`
+	Enum             []string       `json:"enum,omitempty"`
+	MinLength        *float64       `json:"minLength,omitempty"`
+	MaxLength        *float64       `json:"maxLength,omitempty"`
+	Minimum          *float64       `json:"minimum,omitempty"`
+	Maximum          *float64       `json:"maximum,omitempty"`
+	ExclusiveMinimum *float64       `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum *float64       `json:"exclusiveMaximum,omitempty"`
+	Default          any            `json:"default,omitempty"`
+	Items            *specSchema    `json:"items,omitempty"`
+	Properties       specProperties `json:"properties,omitempty"`
+	Required         []string       `json:"required,omitempty"`
+}
+
+// specProperties keeps the properties in struct field order
+type specProperties []specProperty
+
+type specProperty struct {
+	Name   string
+	Schema *specSchema
+}
+
+func (p specProperties) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, prop := range p {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		name, err := json.Marshal(prop.Name)
+		if err != nil {
+			return nil, err
+		}
+		schema, err := json.Marshal(prop.Schema)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(name)
+		b.WriteByte(':')
+		b.Write(schema)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// errorSchema is the body of every error the handlers answer with
+var errorSchema = &specSchema{
+	Type: "object",
+	Properties: specProperties{
+		{Name: "error", Schema: &specSchema{Type: "string"}},
+		{Name: "details", Schema: &specSchema{Type: "string"}},
+	},
+	Required: []string{"error"},
+}
+
+// document builds the OpenAPI document from the route table
+func (w *SpecWriter) document(info SpecInfo) (*specDocument, error) {
+	routesPath := filepath.Join(w.projectDir, RoutesFile)
+	src, err := os.ReadFile(routesPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read routes: %w", err)
+	}
+
+	doc := &specDocument{
+		OpenAPI:    "3.1.0",
+		Info:       info,
+		Paths:      make(map[string]map[string]*specOp),
+		Components: specComponents{Schemas: w.schemas},
+	}
+	w.schemas["Error"] = errorSchema
+
+	// Every API route sits behind the JWT middleware when it's wired in,
+	// except those registered before it, such as the login
+	auth := strings.Contains(string(src), "auth.Middleware(")
+	protected := false
+	if auth {
+		doc.Security = []map[string][]string{{"bearerAuth": {}}}
+		doc.Components.SecuritySchemes = map[string]map[string]string{
+			"bearerAuth": {"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+		}
+	}
+
+	base := ""
+	if m := basePath.FindSubmatch(src); m != nil {
+		base = string(m[1])
+	}
+	handlers := make(map[string]string) // package by handler variable
+	for _, m := range handlerVar.FindAllSubmatch(src, -1) {
+		handlers[string(m[1])] = string(m[2])
+	}
+
+	groups := map[string]string{"api": ""}
+	for _, line := range strings.Split(string(src), "\n") {
+		if strings.Contains(line, "auth.Middleware(") {
+			protected = true
+			continue
+		}
+		if m := routeGroup.FindStringSubmatch(line); m != nil {
+			groups[m[2]] = m[3]
+			continue
+		}
+		m := routeLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		group, ok := groups[m[2]]
+		recv, method, found := strings.Cut(m[5], ".")
+		if !ok || !found || handlers[recv] == "" {
+			continue
+		}
+
+		pkg, err := w.loadPackage(handlers[recv])
+		if err != nil {
+			return nil, err
+		}
+		handler, ok := pkg.handlers[method]
+		if !ok {
+			return nil, fmt.Errorf("handler %s.%s not found", pkg.name, method)
+		}
+
+		tag := strings.Trim(group, "/")
+		if tag == "" {
+			tag = pkg.name
+		}
+		path, params := specPath(base + group + m[4])
+		op := w.operation(pkg, method, handler, params, auth && protected)
+		op.Tags = []string{tag}
+		if auth && !protected {
+			op.Security = &[]map[string][]string{}
+		}
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*specOp)
+		}
+		doc.Paths[path][strings.ToLower(m[3])] = op
+	}
+
+	return doc, nil
+}
+
+// specPath turns a gin path into an OpenAPI one, returning its parameters
+func specPath(path string) (string, []string) {
+	var params []string
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			params = append(params, segment[1:])
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// operation describes what a handler reads and answers with
+func (w *SpecWriter) operation(pkg *goPackage, name string, handler *handlerInfo, params []string, auth bool) *specOp {
+	op := &specOp{
+		OperationID: lowerFirst(name),
+		Summary:     summary(name),
+		Responses:   make(map[string]specResponse),
+	}
+
+	for _, param := range params {
+		schema := &specSchema{Type: "string"}
+		if param == "id" || strings.HasSuffix(param, "_id") {
+			schema.Format = "uuid"
+		}
+		op.Parameters = append(op.Parameters, specParameter{Name: param, In: "path", Required: true, Schema: schema})
+	}
+	for _, q := range handler.query {
+		schema := &specSchema{Type: "string"}
+		if n, err := strconv.Atoi(q.def); err == nil {
+			schema = &specSchema{Type: "integer", Default: n}
+		} else if q.def != "" {
+			schema.Default = q.def
+		}
+		op.Parameters = append(op.Parameters, specParameter{Name: q.name, In: "query", Schema: schema})
+	}
+
+	if handler.request != nil {
+		op.RequestBody = &specBody{
+			Required: true,
+			Content:  map[string]specMedia{"application/json": {Schema: w.schemaOf(pkg, handler.request, true)}},
+		}
+	}
+
+	responses := handler.responses
+	if auth {
+		responses = append(responses, response{code: 401, errorBody: true})
+	}
+	for _, r := range responses {
+		res := specResponse{Description: http.StatusText(r.code)}
+		switch {
+		case r.errorBody:
+			res.Content = map[string]specMedia{"application/json": {Schema: &specSchema{Ref: "#/components/schemas/Error"}}}
+		case r.body != nil:
+			res.Content = map[string]specMedia{"application/json": {Schema: w.schemaOf(pkg, r.body, false)}}
+		}
+		op.Responses[strconv.Itoa(r.code)] = res
+	}
+	return op
+}
+
+// summary spells out a handler name, e.g. ListCustomerOrders -> List customer orders
+func summary(name string) string {
+	isUpper := func(i int) bool { return i < len(name) && unicode.IsUpper(rune(name[i])) }
+	var words []string
+	start := 0
+	for i := 1; i <= len(name); i++ {
+		if i < len(name) && !(isUpper(i) && (!isUpper(i-1) || i+1 < len(name) && !isUpper(i+1))) {
+			continue
+		}
+		word := name[start:i]
+		if len(word) == 1 || strings.ToUpper(word) != word {
+			word = strings.ToLower(word)
+		}
+		words = append(words, word)
+		start = i
+	}
+	if len(words) == 0 {
+		return name
+	}
+	words[0] = strings.ToUpper(words[0][:1]) + words[0][1:]
+	return strings.Join(words, " ")
+}
+
+// schemaOf maps a Go type to a schema; pointers in responses are nullable,
+// in requests they only tell a zero value from a missing one
+func (w *SpecWriter) schemaOf(pkg *goPackage, expr ast.Expr, request bool) *specSchema {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		schema := w.schemaOf(pkg, t.X, request)
+		if name, ok := schema.Type.(string); ok && !request {
+			schema.Type = []string{name, "null"}
+		}
+		return schema
+	case *ast.ArrayType:
+		return &specSchema{Type: "array", Items: w.schemaOf(pkg, t.Elt, request)}
+	case *ast.MapType:
+		return &specSchema{Type: "object"}
+	case *ast.SelectorExpr:
+		switch exprString(t) {
+		case "time.Time":
+			return &specSchema{Type: "string", Format: "date-time"}
+		case "uuid.UUID":
+			return &specSchema{Type: "string", Format: "uuid"}
+		}
+	case *ast.Ident:
+		switch t.Name {
+		case "string":
+			return &specSchema{Type: "string"}
+		case "bool":
+			return &specSchema{Type: "boolean"}
+		case "int", "int32", "int16", "int8", "uint", "uint32", "uint16", "uint8":
+			return &specSchema{Type: "integer"}
+		case "int64", "uint64":
+			return &specSchema{Type: "integer", Format: "int64"}
+		case "float64", "float32":
+			return &specSchema{Type: "number"}
+		}
+		if st, ok := pkg.structs[t.Name]; ok {
+			return &specSchema{Ref: "#/components/schemas/" + w.component(pkg, t.Name, st, request)}
+		}
+	}
+	return &specSchema{}
+}
+
+// component adds a struct to the document's schemas and returns its name
+func (w *SpecWriter) component(pkg *goPackage, name string, st *ast.StructType, request bool) string {
+	qualified := pkg.name + "." + name
+	if component, ok := w.components[qualified]; ok {
+		return component
+	}
+	component := name
+	if _, taken := w.schemas[component]; taken {
+		component = NewEntityNames(pkg.name).Camel + name
+	}
+	w.components[qualified] = component
+
+	schema := &specSchema{Type: "object"}
+	w.schemas[component] = schema
+	for _, field := range st.Fields.List {
+		if len(field.Names) == 0 || !field.Names[0].IsExported() {
+			continue
+		}
+		var tag reflect.StructTag
+		if field.Tag != nil {
+			tag = reflect.StructTag(strings.Trim(field.Tag.Value, "`"))
+		}
+		jsonName, options, _ := strings.Cut(tag.Get("json"), ",")
+		if jsonName == "-" {
+			continue
+		}
+		if jsonName == "" {
+			jsonName = field.Names[0].Name
+		}
+
+		prop := w.schemaOf(pkg, field.Type, request)
+		required := !request && !slices.Contains(strings.Split(options, ","), "omitempty")
+		if request {
+			required = applyBinding(prop, tag.Get("binding"))
+		}
+		schema.Properties = append(schema.Properties, specProperty{Name: jsonName, Schema: prop})
+		if required {
+			schema.Required = append(schema.Required, jsonName)
+		}
+	}
+	return component
+}
+
+// applyBinding adds a field's binding rules to its schema and reports
+// whether the field is required
+func applyBinding(schema *specSchema, binding string) bool {
+	required := false
+	for _, rule := range strings.Split(binding, ",") {
+		name, value, _ := strings.Cut(rule, "=")
+		n, err := strconv.ParseFloat(value, 64)
+		isNumber := err == nil
+		isString := schema.Type == "string"
+		switch {
+		case name == "required":
+			required = true
+		case name == "oneof":
+			schema.Enum = strings.Fields(value)
+		case name == "email":
+			schema.Format = "email"
+		case name == "url" || name == "uri":
+			schema.Format = "uri"
+		case name == "uuid":
+			schema.Format = "uuid"
+		case !isNumber:
+		case name == "max" && isString:
+			schema.MaxLength = &n
+		case name == "min" && isString:
+			schema.MinLength = &n
+		case name == "max" || name == "lte":
+			schema.Maximum = &n
+		case name == "min" || name == "gte":
+			schema.Minimum = &n
+		case name == "lt":
+			schema.ExclusiveMaximum = &n
+		case name == "gt":
+			schema.ExclusiveMinimum = &n
+		}
+	}
+	return required
+}
+
+// goPackage is what the document needs from a domain package
+type goPackage struct {
+	name     string
+	structs  map[string]*ast.StructType
+	results  map[string][]ast.Expr // Service method results
+	handlers map[string]*handlerInfo
+}
+
+type handlerInfo struct {
+	request   ast.Expr // bound request body type
+	query     []queryParam
+	responses []response
+}
+
+type queryParam struct {
+	name, def string
+}
+
+type response struct {
+	code      int
+	body      ast.Expr // nil without a body
+	errorBody bool
+}
+
+// loadPackage parses a package under internal/ once
+func (w *SpecWriter) loadPackage(name string) (*goPackage, error) {
+	if pkg, ok := w.packages[name]; ok {
+		return pkg, nil
+	}
+
+	pkg := &goPackage{
+		name:     name,
+		structs:  make(map[string]*ast.StructType),
+		results:  make(map[string][]ast.Expr),
+		handlers: make(map[string]*handlerInfo),
+	}
+	files, err := filepath.Glob(filepath.Join(w.projectDir, "internal", name, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	var methods []*ast.FuncDecl
+	for _, path := range files {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		for _, decl := range file.Decls {
+			switch d := decl.(type) {
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					ts, ok := spec.(*ast.TypeSpec)
+					if !ok {
+						continue
+					}
+					switch t := ts.Type.(type) {
+					case *ast.StructType:
+						pkg.structs[ts.Name.Name] = t
+					case *ast.InterfaceType:
+						if ts.Name.Name == "Service" {
+							pkg.addResults(t)
+						}
+					}
+				}
+			case *ast.FuncDecl:
+				if d.Recv != nil && exprString(d.Recv.List[0].Type) == "*Handler" {
+					methods = append(methods, d)
+				}
+			}
+		}
+	}
+
+	for _, method := range methods {
+		pkg.handlers[method.Name.Name] = pkg.inspect(method)
+	}
+	w.packages[name] = pkg
+	return pkg, nil
+}
+
+// addResults records the results of the Service methods
+func (p *goPackage) addResults(iface *ast.InterfaceType) {
+	for _, method := range iface.Methods.List {
+		fn, ok := method.Type.(*ast.FuncType)
+		if !ok || len(method.Names) == 0 || fn.Results == nil {
+			continue
+		}
+		var results []ast.Expr
+		for _, result := range fn.Results.List {
+			for range max(len(result.Names), 1) {
+				results = append(results, result.Type)
+			}
+		}
+		p.results[method.Names[0].Name] = results
+	}
+}
+
+// inspect walks a handler for the request it binds, the query parameters it
+// reads and the responses it writes
+func (p *goPackage) inspect(fn *ast.FuncDecl) *handlerInfo {
+	info := &handlerInfo{}
+	if fn.Body == nil || len(fn.Type.Params.List) == 0 || len(fn.Type.Params.List[0].Names) == 0 {
+		return info
+	}
+	ctx := fn.Type.Params.List[0].Names[0].Name
+	vars := make(map[string]ast.Expr) // types of local variables
+	seen := make(map[int]bool)
+
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.ValueSpec:
+			for _, name := range n.Names {
+				if n.Type != nil {
+					vars[name.Name] = n.Type
+				}
+			}
+		case *ast.AssignStmt:
+			if len(n.Rhs) != 1 {
+				break
+			}
+			for i, lhs := range n.Lhs {
+				if ident, ok := lhs.(*ast.Ident); ok {
+					if t := p.typeOf(n.Rhs[0], i); t != nil {
+						vars[ident.Name] = t
+					}
+				}
+			}
+		case *ast.CallExpr:
+			sel, ok := n.Fun.(*ast.SelectorExpr)
+			if !ok || exprString(sel.X) != ctx {
+				break
+			}
+			switch sel.Sel.Name {
+			case "ShouldBindJSON", "BindJSON", "ShouldBind", "Bind":
+				if arg, ok := n.Args[0].(*ast.UnaryExpr); ok {
+					if ident, ok := arg.X.(*ast.Ident); ok {
+						info.request = vars[ident.Name]
+					}
+				}
+			case "Query", "DefaultQuery":
+				q := queryParam{name: stringLit(n.Args[0])}
+				if len(n.Args) > 1 {
+					q.def = stringLit(n.Args[1])
+				}
+				if q.name != "" && !slices.Contains(info.query, q) {
+					info.query = append(info.query, q)
+				}
+			case "JSON", "AbortWithStatusJSON", "Status":
+				code, err := strconv.Atoi(exprString(n.Args[0]))
+				if err != nil || seen[code] {
+					break
+				}
+				seen[code] = true
+				r := response{code: code}
+				if len(n.Args) > 1 {
+					switch body := n.Args[1].(type) {
+					case *ast.Ident:
+						r.body = vars[body.Name]
+					case *ast.CompositeLit:
+						if exprString(body.Type) == "gin.H" {
+							r.errorBody = code >= 400
+							break
+						}
+						r.body = body.Type
+					default:
+						r.body = p.typeOf(body, 0)
+					}
+				}
+				info.responses = append(info.responses, r)
+			}
+		}
+		return true
+	})
+
+	slices.SortFunc(info.responses, func(a, b response) int { return a.code - b.code })
+	return info
+}
+
+// typeOf returns the type of the i-th value of an expression when it's
+// a composite literal or a Service call
+func (p *goPackage) typeOf(expr ast.Expr, i int) ast.Expr {
+	switch e := expr.(type) {
+	case *ast.CompositeLit:
+		return e.Type
+	case *ast.UnaryExpr:
+		if lit, ok := e.X.(*ast.CompositeLit); ok {
+			return lit.Type
+		}
+	case *ast.CallExpr:
+		if sel, ok := e.Fun.(*ast.SelectorExpr); ok {
+			if results := p.results[sel.Sel.Name]; i < len(results) {
+				return results[i]
+			}
+		}
+	}
+	return nil
+}
+
+// exprString renders the identifiers, selectors and literals the
+// document is derived from
+func exprString(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.BasicLit:
+		return e.Value
+	case *ast.StarExpr:
+		return "*" + exprString(e.X)
+	case *ast.SelectorExpr:
+		return exprString(e.X) + "." + e.Sel.Name
+	}
+	return ""
+}
+
+func stringLit(expr ast.Expr) string {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return ""
+	}
+	s, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return ""
+	}
+	return s
+}
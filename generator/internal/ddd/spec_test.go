@@ -0,0 +1,197 @@
+package ddd
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/darkphotonKN/go-template-generator/templates"
+)
+
+// copyTemplateSource copies the API template's Go files and OpenAPI
+// document, which is all SpecWriter reads
+func copyTemplateSource(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	err := fs.WalkDir(templates.FS, APITemplate, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel := strings.TrimPrefix(name, APITemplate+"/")
+		if path.Ext(rel) != ".go" && rel != SpecFile {
+			return nil
+		}
+		data, err := fs.ReadFile(templates.FS, name)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestTemplateSpecIsCurrent(t *testing.T) {
+	dir := copyTemplateSource(t)
+	if err := NewSpecWriter(dir, nil).Apply(); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, SpecFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := fs.ReadFile(templates.FS, path.Join(APITemplate, SpecFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("templates/%s/%s is out of date with the template's handlers, want:\n%s", APITemplate, SpecFile, got)
+	}
+}
+
+func TestSpecWriter(t *testing.T) {
+	dir := copyTemplateSource(t)
+	if err := NewEntityRenamer(TemplateEntity, "task").Apply(dir); err != nil {
+		t.Fatal(err)
+	}
+	info := &SpecInfo{Title: "tasks", Version: "1.0.0"}
+	if err := NewSpecWriter(dir, info).Apply(); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, SpecFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Info     SpecInfo
+		Security []map[string][]string
+		Paths    map[string]map[string]struct {
+			OperationID string
+			Parameters  []struct{ Name, In string }
+			Security    *[]map[string][]string
+			RequestBody *struct {
+				Content map[string]struct{ Schema map[string]any }
+			}
+			Responses map[string]struct {
+				Content map[string]struct{ Schema map[string]any }
+			}
+		}
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]map[string]any
+				Required   []string
+			}
+		}
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid document: %v\n%s", err, data)
+	}
+
+	if doc.Info.Title != "tasks" || len(doc.Security) != 1 {
+		t.Errorf("info = %+v, security = %v", doc.Info, doc.Security)
+	}
+
+	get := doc.Paths["/api/tasks/{id}"]["get"]
+	if get.OperationID != "getTask" || len(get.Parameters) != 1 || get.Parameters[0].In != "path" {
+		t.Errorf("GET /api/tasks/{id} = %+v", get)
+	}
+	var codes []string
+	for code := range get.Responses {
+		codes = append(codes, code)
+	}
+	for _, code := range []string{"200", "400", "401", "404", "500"} {
+		if _, ok := get.Responses[code]; !ok {
+			t.Errorf("GET /api/tasks/{id} lacks a %s response, has %v", code, codes)
+		}
+	}
+	if ref := get.Responses["200"].Content["application/json"].Schema["$ref"]; ref != "#/components/schemas/Task" {
+		t.Errorf("GET /api/tasks/{id} answers %v, want the Task schema", ref)
+	}
+	if ref := get.Responses["404"].Content["application/json"].Schema["$ref"]; ref != "#/components/schemas/Error" {
+		t.Errorf("GET /api/tasks/{id} 404 answers %v, want the Error schema", ref)
+	}
+
+	// The login is registered before the JWT middleware, so it's public
+	if login := doc.Paths["/api/auth/login"]["post"]; login.Security == nil || len(*login.Security) != 0 {
+		t.Errorf("POST /api/auth/login security = %v, want an empty list", login.Security)
+	}
+	if get.Security != nil {
+		t.Errorf("GET /api/tasks/{id} overrides the global security with %v", *get.Security)
+	}
+
+	list := doc.Paths["/api/tasks"]["get"]
+	if ref := list.Responses["200"].Content["application/json"].Schema["$ref"]; ref != "#/components/schemas/ListTasksResponse" {
+		t.Errorf("GET /api/tasks answers %v, want ListTasksResponse", ref)
+	}
+	if len(list.Parameters) != 2 || list.Parameters[0].Name != "limit" || list.Parameters[1].In != "query" {
+		t.Errorf("GET /api/tasks parameters = %+v", list.Parameters)
+	}
+
+	create := doc.Paths["/api/tasks"]["post"]
+	if ref := create.RequestBody.Content["application/json"].Schema["$ref"]; ref != "#/components/schemas/CreateTaskRequest" {
+		t.Errorf("POST /api/tasks reads %v, want CreateTaskRequest", ref)
+	}
+	if _, ok := doc.Paths["/api/tasks/{id}"]["delete"].Responses["204"]; !ok {
+		t.Errorf("DELETE /api/tasks/{id} lacks its 204 response")
+	}
+
+	schemas := doc.Components.Schemas
+	if got := schemas["CreateTaskRequest"].Required; len(got) != 1 || got[0] != "name" {
+		t.Errorf("CreateTaskRequest required = %v, want [name]", got)
+	}
+	if got := schemas["Task"].Properties["created_at"]["format"]; got != "date-time" {
+		t.Errorf("Task.created_at format = %v, want date-time", got)
+	}
+	if got := schemas["ListTasksResponse"].Properties["tasks"]["type"]; got != "array" {
+		t.Errorf("ListTasksResponse.tasks type = %v, want array", got)
+	}
+}
+
+func TestApplyBinding(t *testing.T) {
+	schema := &specSchema{Type: "string"}
+	if !applyBinding(schema, "required,max=320,email") {
+		t.Error("expected required")
+	}
+	if schema.MaxLength == nil || *schema.MaxLength != 320 || schema.Format != "email" {
+		t.Errorf("string schema = %+v", schema)
+	}
+
+	schema = &specSchema{Type: "integer"}
+	if applyBinding(schema, "omitempty,gte=1,lte=99") {
+		t.Error("expected optional")
+	}
+	if schema.Minimum == nil || *schema.Minimum != 1 || schema.Maximum == nil || *schema.Maximum != 99 {
+		t.Errorf("integer schema = %+v", schema)
+	}
+
+	schema = &specSchema{Type: "string"}
+	applyBinding(schema, "oneof=open done")
+	if strings.Join(schema.Enum, ",") != "open,done" {
+		t.Errorf("enum = %v", schema.Enum)
+	}
+}
+
+func TestSummary(t *testing.T) {
+	for name, want := range map[string]string{
+		"ListItems":          "List items",
+		"ListCustomerOrders": "List customer orders",
+		"PresignUpload":      "Presign upload",
+		"GetAPIKey":          "Get API key",
+	} {
+		if got := summary(name); got != want {
+			t.Errorf("summary(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,445 @@
+package ddd
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/darkphotonKN/go-template-generator/internal/config"
+	"github.com/darkphotonKN/go-template-generator/internal/git"
+	"github.com/darkphotonKN/go-template-generator/internal/lockfile"
+	"github.com/darkphotonKN/go-template-generator/internal/registry"
+	"github.com/darkphotonKN/go-template-generator/internal/schema"
+	"github.com/darkphotonKN/go-template-generator/templates"
+)
+
+// TemplateRef is the git ref holding the project as the template last
+// rendered it: the base upgrades merge template changes from
+const TemplateRef = "refs/go-gen/template"
+
+var moduleLine = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+
+type UpgradeOptions struct {
+	// ProjectDir is the generated API project, or the folder holding
+	// <name>-server for full-stack projects
+	ProjectDir string
+	Config     *config.Config
+
+	// Templates holds one directory per template; nil uses the templates
+	// embedded in the binary
+	Templates fs.FS
+}
+
+// UpgradeReport lists what an upgrade did to the project's files
+type UpgradeReport struct {
+	Branch    string // empty when the project is up to date
+	From, To  string // template versions
+	Updated   []string
+	Added     []string
+	Removed   []string
+	Conflicts []string // left with conflict markers
+	Skipped   []string // template changes not applied, with the reason
+	Warnings  []string
+}
+
+// Upgrade merges what changed in the templates since the project was last
+// rendered into it. The project is re-rendered with the options it was
+// created with and the difference to the recorded render (TemplateRef, or
+// the first commit for projects created before it was kept) is merged into
+// each file three ways. The result is committed on a new branch, conflicts
+// included, and becomes the recorded render.
+func Upgrade(opts *UpgradeOptions) (*UpgradeReport, error) {
+	templatesFS := opts.Templates
+	if templatesFS == nil {
+		templatesFS = templates.FS
+	}
+
+	registryMgr := registry.NewManager(opts.Config.ProjectsRegistry)
+	project, err := findProject(registryMgr, opts.ProjectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// The repository covers the client too in full-stack projects
+	root, serverPrefix := project.Root, ""
+	if root != project.Dir {
+		serverPrefix = filepath.Base(project.Dir) + "/"
+	}
+
+	var warned warnings
+	gitMgr := git.NewManager(root)
+	if !gitMgr.IsGitAvailable() {
+		return nil, fmt.Errorf("git not found, it's needed to merge and commit the upgrade")
+	}
+	clean, err := gitMgr.IsClean()
+	if err != nil {
+		return nil, err
+	}
+	if !clean {
+		return nil, fmt.Errorf("project has %w; commit or stash them first", ErrUncommitted)
+	}
+
+	base, err := gitMgr.ResolveRef(TemplateRef)
+	if err != nil {
+		if base, err = gitMgr.RootCommit(); err != nil {
+			return nil, err
+		}
+		warned.add("no template render recorded, merging from the first commit")
+	}
+	baseFiles, err := gitMgr.Files(base)
+	if err != nil {
+		return nil, err
+	}
+	// The lockfile is written next to the render, it isn't part of it
+	delete(baseFiles, lockfile.FileName)
+
+	genOpts, err := upgradeOptions(project.Project, baseFiles, serverPrefix, opts.Config, &warned)
+	if err != nil {
+		return nil, err
+	}
+	genOpts.Templates = templatesFS
+
+	renderDir, err := os.MkdirTemp("", "go-gen-upgrade-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(renderDir)
+	if genOpts.SchemaFile != "" {
+		stored := filepath.Join(renderDir, genOpts.SchemaName)
+		if err := os.WriteFile(stored, baseFiles[serverPrefix+genOpts.SchemaName].Data, 0644); err != nil {
+			return nil, err
+		}
+		genOpts.SchemaFile = stored
+	}
+
+	// Render the current templates as the project was created
+	g := NewGenerator(genOpts)
+	g.targetDir = filepath.Join(renderDir, g.targetDir)
+	if g.clientDir != "" {
+		g.clientDir = filepath.Join(renderDir, g.clientDir)
+	}
+	fmt.Fprintf(os.Stderr, "🔧 Rendering the current templates...\n")
+	if err := g.render(); err != nil {
+		return nil, err
+	}
+	rendered := filepath.Join(renderDir, project.Name)
+	theirFiles, err := readFiles(rendered)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := templateVersion(templatesFS, APITemplate)
+	if err != nil {
+		return nil, err
+	}
+	report := &UpgradeReport{From: project.TemplateVersion, To: version, Warnings: append(warned, g.warnings...)}
+	changed := changedFiles(baseFiles, theirFiles)
+	if len(changed) == 0 {
+		return report, nil
+	}
+
+	labels := [3]string{"project", APITemplate + " " + orUnknown(report.From), APITemplate + " " + orUnknown(version)}
+	for _, name := range changed {
+		baseFile, inBase := baseFiles[name]
+		theirFile, inTheirs := theirFiles[name]
+		if err := mergeFile(root, name, baseFile, theirFile, inBase, inTheirs, labels, report); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := upgradeLock(project, g, baseFiles, theirFiles); err != nil {
+		return nil, err
+	}
+
+	// The merged files move to the new branch with it; when the project
+	// already had every change there is nothing to commit
+	if clean, err := gitMgr.IsClean(); err != nil {
+		return nil, err
+	} else if !clean {
+		report.Branch = "go-gen/upgrade"
+		if version != "" {
+			report.Branch += "-" + version
+		}
+		if err := gitMgr.CreateBranch(report.Branch); err != nil {
+			return nil, err
+		}
+		if err := gitMgr.CommitAll(report.commitMessage()); err != nil {
+			return nil, err
+		}
+	}
+
+	snapshot, err := gitMgr.SnapshotDir(rendered, base, fmt.Sprintf("Render %s %s", APITemplate, orUnknown(version)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to record the template render: %w", err)
+	}
+	if err := gitMgr.UpdateRef(TemplateRef, snapshot); err != nil {
+		return nil, err
+	}
+
+	if project.Registered {
+		if err := registryMgr.Update(project.Name, func(p *registry.Project) {
+			p.Template, p.TemplateVersion = APITemplate, version
+		}); err != nil {
+			return nil, fmt.Errorf("failed to record template version: %w", err)
+		}
+	}
+	return report, nil
+}
+
+// upgradeLock records the new render in the project's lockfile, writing one
+// for projects generated before lockfiles were kept
+func upgradeLock(project *localProject, g *Generator, base, theirs map[string]git.File) error {
+	rendered, err := g.lock()
+	if err != nil {
+		return err
+	}
+
+	lock := project.Lock
+	if lock == nil {
+		lock = rendered
+		lock.Entities = lockEntities(project.EntityList(), project.Fields, project.Relations)
+		return lock.Save(project.Root)
+	}
+
+	// Files the template no longer has are no longer go-gen's; entities
+	// added since keep theirs
+	lock.Templates = rendered.Templates
+	for name := range base {
+		if _, ok := theirs[name]; !ok {
+			delete(lock.Files, name)
+		}
+	}
+	for name, sum := range rendered.Files {
+		lock.Files[name] = sum
+	}
+	return lock.Save(project.Root)
+}
+
+// upgradeOptions rebuilds the options a project was generated with from its
+// registry record and its recorded render
+func upgradeOptions(project *registry.Project, base map[string]git.File, serverPrefix string, cfg *config.Config, warned *warnings) (*GeneratorOptions, error) {
+	opts := &GeneratorOptions{
+		ProjectName:        project.Name,
+		Fields:             project.Fields,
+		Relations:          project.Relations,
+		ProjectDescription: project.Description,
+		APIPort:            project.APIPort,
+		DBPort:             project.DBPort,
+		RedisPort:          project.RedisPort,
+		FrontendPort:       project.FrontendPort,
+		IncludeFrontend:    project.FrontendPort != 0,
+	}
+
+	has := func(name string) bool {
+		for file := range base {
+			if strings.HasPrefix(file, serverPrefix+name) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Entities added later weren't part of the render
+	for _, entity := range project.EntityList() {
+		if has(path.Join("internal", NewEntityNames(entity).Package, "handler.go")) {
+			opts.Entities = append(opts.Entities, entity)
+		}
+	}
+	if len(opts.Entities) == 0 {
+		return nil, fmt.Errorf("none of the entities of '%s' are in its recorded render", project.Name)
+	}
+
+	// Disabled features left their package out
+	opts.IncludeAuth = has("internal/auth/")
+	opts.IncludeRedis = has("internal/cache/")
+	opts.IncludeS3 = has("internal/s3/")
+
+	// The module prefix is whatever precedes the project name in go.mod
+	projectCfg := *cfg
+	if m := moduleLine.FindSubmatch(base[serverPrefix+"go.mod"].Data); m != nil {
+		projectCfg.Defaults.ModulePrefix = strings.TrimSuffix(string(m[1]), project.Name)
+	}
+	opts.Config = &projectCfg
+
+	// Imports are read again from the schema kept in the project
+	recorded := project.TemplateVersion != "" || project.Template != ""
+	for _, name := range schema.StoredNames {
+		file, ok := base[serverPrefix+name]
+		if !ok {
+			continue
+		}
+		opts.SchemaFile, opts.SchemaName = name, name
+
+		var domain *schema.Schema
+		var err error
+		switch {
+		case name == "schema.sql":
+			var imp *schema.SQLImport
+			if imp, err = schema.FromSQL(string(file.Data), TableName); err == nil {
+				domain, opts.Migrations, opts.DownMigrations = imp.Schema, imp.Migrations(), imp.DownMigrations()
+			}
+		case strings.HasPrefix(name, "openapi."):
+			var imp *schema.OpenAPIImport
+			if imp, err = schema.FromOpenAPI(file.Data, TableName); err == nil {
+				domain, opts.API = imp.Schema, imp.API
+			}
+		default:
+			domain, err = schema.Parse(file.Data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the project's %s: %w", name, err)
+		}
+		if !recorded {
+			opts.Fields, opts.Relations = domain.Fields(), domain.Relations()
+		}
+		break
+	}
+
+	if !recorded && opts.SchemaFile == "" {
+		warned.add("'%s' was created before go-gen recorded fields and relations; the sample fields are assumed", project.Name)
+	}
+	return opts, nil
+}
+
+// readFiles returns the files under dir keyed by their slash-separated path
+func readFiles(dir string) (map[string]git.File, error) {
+	files := make(map[string]git.File)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = git.File{Data: data, Mode: git.FileMode(info.Mode())}
+		return nil
+	})
+	return files, err
+}
+
+// changedFiles returns the paths that differ between two renders
+func changedFiles(base, theirs map[string]git.File) []string {
+	var changed []string
+	for name, file := range base {
+		if other, ok := theirs[name]; !ok || !bytes.Equal(file.Data, other.Data) || file.Mode != other.Mode {
+			changed = append(changed, name)
+		}
+	}
+	for name := range theirs {
+		if _, ok := base[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
+
+// mergeFile applies the template's change of one file to the project
+func mergeFile(root, name string, base, theirs git.File, inBase, inTheirs bool, labels [3]string, report *UpgradeReport) error {
+	target := filepath.Join(root, filepath.FromSlash(name))
+	ours, err := os.ReadFile(target)
+	exists := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	switch {
+	case !inTheirs:
+		switch {
+		case !exists:
+		case bytes.Equal(ours, base.Data):
+			report.Removed = append(report.Removed, name)
+			return os.Remove(target)
+		default:
+			report.Skipped = append(report.Skipped, name+": removed from the template but changed in the project")
+		}
+		return nil
+
+	case !exists && inBase:
+		report.Skipped = append(report.Skipped, name+": changed in the template but deleted from the project")
+		return nil
+
+	case !exists:
+		report.Added = append(report.Added, name)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		return os.WriteFile(target, theirs.Data, theirs.Mode)
+
+	case bytes.Equal(ours, theirs.Data):
+		return nil
+
+	case bytes.Equal(ours, base.Data):
+		report.Updated = append(report.Updated, name)
+		return writeKeepingMode(target, theirs.Data)
+
+	case bytes.IndexByte(ours, 0) >= 0 || bytes.IndexByte(theirs.Data, 0) >= 0:
+		report.Skipped = append(report.Skipped, name+": binary file changed in both the template and the project")
+		return nil
+	}
+
+	merged, conflict, err := git.MergeFile(ours, base.Data, theirs.Data, labels)
+	if err != nil {
+		return fmt.Errorf("failed to merge %s: %w", name, err)
+	}
+	if conflict {
+		report.Conflicts = append(report.Conflicts, name)
+	} else {
+		report.Updated = append(report.Updated, name)
+	}
+	return writeKeepingMode(target, merged)
+}
+
+func writeKeepingMode(path string, data []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, info.Mode().Perm())
+}
+
+func (r *UpgradeReport) commitMessage() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Upgrade %s template to %s\n", APITemplate, orUnknown(r.To))
+	if len(r.Conflicts) > 0 {
+		b.WriteString("\nConflicts to resolve:\n")
+		for _, name := range r.Conflicts {
+			fmt.Fprintf(&b, "  %s\n", name)
+		}
+	}
+	if len(r.Skipped) > 0 {
+		b.WriteString("\nNot applied:\n")
+		for _, skipped := range r.Skipped {
+			fmt.Fprintf(&b, "  %s\n", skipped)
+		}
+	}
+	return b.String()
+}
+
+func orUnknown(version string) string {
+	if version == "" {
+		return "(unversioned)"
+	}
+	return version
+}
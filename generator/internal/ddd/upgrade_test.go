@@ -0,0 +1,146 @@
+package ddd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/darkphotonKN/go-template-generator/internal/config"
+	"github.com/darkphotonKN/go-template-generator/internal/git"
+	"github.com/darkphotonKN/go-template-generator/internal/registry"
+)
+
+func TestMergeFile(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+
+	root := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	file := func(content string) git.File { return git.File{Data: []byte(content), Mode: 0644} }
+
+	write("untouched", "a\nb\nc\n")
+	write("edited", "a\nB\nc\n")
+	write("clash", "a\nmine\nc\n")
+	write("removed", "old\n")
+
+	labels := [3]string{"project", "base", "theirs"}
+	report := &UpgradeReport{}
+	for _, m := range []struct {
+		name             string
+		base, theirs     string
+		inBase, inTheirs bool
+	}{
+		{"untouched", "a\nb\nc\n", "a\nb\nc\nd\n", true, true},
+		{"edited", "a\nb\nc\n", "a\nb\nc\nd\n", true, true},
+		{"clash", "a\nb\nc\n", "a\ntheirs\nc\n", true, true},
+		{"removed", "old\n", "", true, false},
+		{"added", "", "new\n", false, true},
+		{"deleted", "x\n", "y\n", true, true},
+	} {
+		if err := mergeFile(root, m.name, file(m.base), file(m.theirs), m.inBase, m.inTheirs, labels, report); err != nil {
+			t.Fatalf("merging %s: %v", m.name, err)
+		}
+	}
+
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+	if got := read("untouched"); got != "a\nb\nc\nd\n" {
+		t.Errorf("untouched = %q, want the template's version", got)
+	}
+	if got := read("edited"); got != "a\nB\nc\nd\n" {
+		t.Errorf("edited = %q, want both changes", got)
+	}
+	if got := read("clash"); !strings.Contains(got, "<<<<<<< project\nmine\n=======\ntheirs\n>>>>>>> theirs\n") {
+		t.Errorf("clash = %q, want conflict markers", got)
+	}
+	if got := read("added"); got != "new\n" {
+		t.Errorf("added = %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(root, "removed")); !os.IsNotExist(err) {
+		t.Errorf("expected removed to be deleted")
+	}
+
+	want := &UpgradeReport{
+		Updated:   []string{"untouched", "edited"},
+		Added:     []string{"added"},
+		Removed:   []string{"removed"},
+		Conflicts: []string{"clash"},
+		Skipped:   []string{"deleted: changed in the template but deleted from the project"},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("report = %+v, want %+v", report, want)
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	base := map[string]git.File{
+		"same":    {Data: []byte("x"), Mode: 0644},
+		"edited":  {Data: []byte("x"), Mode: 0644},
+		"mode":    {Data: []byte("x"), Mode: 0644},
+		"removed": {Data: []byte("x"), Mode: 0644},
+	}
+	theirs := map[string]git.File{
+		"same":   {Data: []byte("x"), Mode: 0644},
+		"edited": {Data: []byte("y"), Mode: 0644},
+		"mode":   {Data: []byte("x"), Mode: 0755},
+		"added":  {Data: []byte("x"), Mode: 0644},
+	}
+	if got, want := changedFiles(base, theirs), []string{"added", "edited", "mode", "removed"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("changedFiles = %v, want %v", got, want)
+	}
+}
+
+func TestUpgradeOptions(t *testing.T) {
+	project := &registry.Project{
+		Name:            "shop",
+		APIPort:         8010,
+		FrontendPort:    3010,
+		Entities:        []string{"customer", "order", "invoice"},
+		TemplateVersion: "1.0.0",
+	}
+	base := map[string]git.File{
+		"shop-server/go.mod":                       {Data: []byte("module example.com/acme/shop\n\ngo 1.23\n")},
+		"shop-server/internal/customer/handler.go": {},
+		"shop-server/internal/order/handler.go":    {},
+		"shop-server/internal/cache/redis.go":      {},
+		"shop-server/schema.yaml":                  {Data: []byte("entities:\n  - name: customer\n  - name: order\n")},
+		"shop-client/package.json":                 {},
+	}
+	cfg := &config.Config{}
+	cfg.Defaults.ModulePrefix = "github.com/someone/"
+
+	opts, err := upgradeOptions(project, base, "shop-server/", cfg, new(warnings))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// invoice was added after the render, auth and S3 were left out
+	if !reflect.DeepEqual(opts.Entities, []string{"customer", "order"}) {
+		t.Errorf("entities = %v", opts.Entities)
+	}
+	if opts.IncludeAuth || !opts.IncludeRedis || opts.IncludeS3 || !opts.IncludeFrontend {
+		t.Errorf("features = auth %v, redis %v, s3 %v, frontend %v", opts.IncludeAuth, opts.IncludeRedis, opts.IncludeS3, opts.IncludeFrontend)
+	}
+	if got := opts.Config.Defaults.ModulePrefix; got != "example.com/acme/" {
+		t.Errorf("module prefix = %q, want example.com/acme/", got)
+	}
+	if cfg.Defaults.ModulePrefix != "github.com/someone/" {
+		t.Errorf("the shared config was modified")
+	}
+	if opts.SchemaName != "schema.yaml" || opts.APIPort != 8010 {
+		t.Errorf("schema = %q, API port = %d", opts.SchemaName, opts.APIPort)
+	}
+}
@@ -0,0 +1,295 @@
+package ddd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/darkphotonKN/go-template-generator/internal/config"
+	"github.com/darkphotonKN/go-template-generator/internal/registry"
+	"github.com/darkphotonKN/go-template-generator/templates"
+)
+
+// Checks verification runs, in order. Vet is skipped when the build fails.
+const (
+	CheckBuild      = "build"      // go build ./... in the API project
+	CheckVet        = "vet"        // go vet ./...
+	CheckUnrendered = "unrendered" // template actions left in any file
+	CheckLeftover   = "leftover"   // the template's module path or sample entity left in
+)
+
+// VerifyChecks lists every check in the order they run
+var VerifyChecks = []string{CheckBuild, CheckVet, CheckUnrendered, CheckLeftover}
+
+type VerifyOptions struct {
+	// ProjectDir is the generated API project, or the folder holding
+	// <name>-server for full-stack projects
+	ProjectDir string
+	Config     *config.Config
+
+	// Templates holds one directory per template; nil uses the templates
+	// embedded in the binary
+	Templates fs.FS
+}
+
+// VerifyProblem is something a check found, at a file and line of the
+// project when it knows one
+type VerifyProblem struct {
+	Check   string
+	File    string // slash-separated, relative to the project root
+	Line    int
+	Message string
+}
+
+func (p VerifyProblem) String() string {
+	switch {
+	case p.File == "":
+		return fmt.Sprintf("%s: %s", p.Check, p.Message)
+	case p.Line == 0:
+		return fmt.Sprintf("%s: %s: %s", p.File, p.Check, p.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s: %s", p.File, p.Line, p.Check, p.Message)
+}
+
+// VerifyError lists every problem verification found in a project
+type VerifyError struct {
+	Dir      string
+	Problems []VerifyProblem
+}
+
+func (e *VerifyError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "project at %s failed verification:", e.Dir)
+	for _, problem := range e.Problems {
+		fmt.Fprintf(&b, "\n  %s", problem)
+	}
+	return b.String()
+}
+
+// Verify checks that a generated project builds, vets and has nothing of
+// the templates left in it; the problems found come back as a *VerifyError
+func Verify(opts *VerifyOptions) error {
+	templatesFS := opts.Templates
+	if templatesFS == nil {
+		templatesFS = templates.FS
+	}
+
+	project, err := findProject(registry.NewManager(opts.Config.ProjectsRegistry), opts.ProjectDir)
+	if err != nil {
+		return err
+	}
+	return verifyProject(templatesFS, APITemplate, project.Root, project.Dir, project.EntityList())
+}
+
+// verifyProject runs the checks on a project whose API is in apiDir under
+// root; the rest of root is only scanned
+func verifyProject(templates fs.FS, templateDir, root, apiDir string, entities []string) error {
+	fmt.Fprintf(os.Stderr, "🔍 Verifying '%s'...\n", root)
+	prefix, err := filepath.Rel(root, apiDir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve project path: %w", err)
+	}
+
+	problems, err := goCheck(apiDir, prefix, CheckBuild, "build", "./...")
+	if err != nil {
+		return err
+	}
+	if len(problems) == 0 {
+		if problems, err = goCheck(apiDir, prefix, CheckVet, "vet", "./..."); err != nil {
+			return err
+		}
+	}
+
+	scanned, err := scanProject(root, newLeftovers(templates, templateDir, entities))
+	if err != nil {
+		return err
+	}
+	problems = append(problems, scanned...)
+
+	if len(problems) > 0 {
+		return &VerifyError{Dir: root, Problems: problems}
+	}
+	return nil
+}
+
+// goOutputLine matches a compiler or vet diagnostic: file:line[:col]: message
+var goOutputLine = regexp.MustCompile(`^(?:vet: )?(\S+\.go):(\d+)(?::\d+)?: (.*)$`)
+
+// goCheck runs a go command in dir, turning its diagnostics into problems
+func goCheck(dir, prefix, check string, args ...string) ([]VerifyProblem, error) {
+	fmt.Fprintf(os.Stderr, "   go %s\n", strings.Join(args, " "))
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil, nil
+	}
+	if _, ok := err.(*exec.ExitError); !ok {
+		return nil, fmt.Errorf("failed to run 'go %s': %w", strings.Join(args, " "), err)
+	}
+
+	var problems []VerifyProblem
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		problem := VerifyProblem{Check: check, Message: line}
+		if m := goOutputLine.FindStringSubmatch(line); m != nil {
+			problem.File = filepath.ToSlash(filepath.Join(prefix, m[1]))
+			problem.Line, _ = strconv.Atoi(m[2])
+			problem.Message = m[3]
+		}
+		problems = append(problems, problem)
+	}
+	if len(problems) == 0 {
+		problems = append(problems, VerifyProblem{Check: check, Message: fmt.Sprintf("'go %s' failed: %v", strings.Join(args, " "), err)})
+	}
+	return problems, nil
+}
+
+// leftovers is what a render must not leave behind
+type leftovers struct {
+	module string // the template's own module path
+	sample bool   // the sample entity's name, unless an entity keeps it
+}
+
+// sampleIdentifier matches the sample entity as a word of a Go identifier:
+// item, items, Item, listItems, ItemID
+var sampleIdentifier = regexp.MustCompile(`(?:^|[a-z0-9_])[Ii]tems?(?:[A-Z0-9_]|$)`)
+
+// sampleSQLWord matches the sample entity as a word of SQL
+var sampleSQLWord = regexp.MustCompile(`(?i)\bitems?\b`)
+
+func newLeftovers(templates fs.FS, templateDir string, entities []string) *leftovers {
+	l := &leftovers{}
+	for _, name := range []string{"go.mod", "go.mod.tmpl"} {
+		data, err := fs.ReadFile(templates, path.Join(templateDir, name))
+		if err != nil {
+			continue
+		}
+		if m := moduleLine.FindSubmatch(data); m != nil {
+			l.module = string(m[1])
+		}
+		break
+	}
+
+	// An entity with the sample's name, or one containing it such as
+	// line_item, legitimately keeps it
+	sample := NewEntityNames(TemplateEntity).Snake
+	if !slices.ContainsFunc(entities, func(entity string) bool {
+		return slices.Contains(strings.Split(NewEntityNames(entity).Snake, "_"), sample)
+	}) {
+		l.sample = true
+	}
+	return l
+}
+
+// unrenderedAction matches the start of a text/template action: a field,
+// variable, comment, keyword or one of the template functions
+var unrenderedAction = func() *regexp.Regexp {
+	words := []string{"if", "else", "end", "range", "with", "define", "template", "block", "not", "and", "or", "eq", "ne", "len", "index", "printf"}
+	for name := range TemplateFuncs() {
+		words = append(words, name)
+	}
+	slices.Sort(words)
+	return regexp.MustCompile(`\{\{-?\s*(?:[.$]|/\*|(?:` + strings.Join(words, "|") + `)\b)`)
+}()
+
+// scanProject looks through every text file under root for unrendered
+// template actions and leftovers
+func scanProject(root string, l *leftovers) ([]VerifyProblem, error) {
+	var problems []VerifyProblem
+	err := filepath.WalkDir(root, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			switch d.Name() {
+			case ".git", "node_modules", ".next":
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0 {
+			return nil // binary
+		}
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		for i, line := range strings.Split(string(data), "\n") {
+			if loc := unrenderedAction.FindStringIndex(line); loc != nil {
+				problems = append(problems, VerifyProblem{Check: CheckUnrendered, File: rel, Line: i + 1, Message: fmt.Sprintf("template action %q was not rendered", action(line[loc[0]:]))})
+			}
+			if l.module != "" && strings.Contains(line, l.module) {
+				problems = append(problems, VerifyProblem{Check: CheckLeftover, File: rel, Line: i + 1, Message: fmt.Sprintf("the template's module path %s is still used", l.module)})
+			}
+			if l.sample && path.Ext(rel) == ".sql" && sampleSQLWord.MatchString(line) {
+				problems = append(problems, VerifyProblem{Check: CheckLeftover, File: rel, Line: i + 1, Message: fmt.Sprintf("the sample entity %q is still used", sampleSQLWord.FindString(line))})
+			}
+		}
+		if l.sample && path.Ext(rel) == ".go" {
+			problems = append(problems, sampleIdentifiers(rel, data)...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan project: %w", err)
+	}
+	return problems, nil
+}
+
+// sampleIdentifiers finds the sample entity's identifiers in a Go file; files
+// that don't parse are the build check's to report
+func sampleIdentifiers(file string, data []byte) []VerifyProblem {
+	fset := token.NewFileSet()
+	parsed, err := parser.ParseFile(fset, file, data, parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+
+	var problems []VerifyProblem
+	seen := make(map[string]bool)
+	report := func(pos token.Pos, what, name string) {
+		line := fset.Position(pos).Line
+		if key := fmt.Sprint(line, name); !seen[key] {
+			seen[key] = true
+			problems = append(problems, VerifyProblem{Check: CheckLeftover, File: file, Line: line, Message: fmt.Sprintf("%s %s still names the sample entity '%s'", what, name, TemplateEntity)})
+		}
+	}
+	ast.Inspect(parsed, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Ident:
+			if sampleIdentifier.MatchString(n.Name) {
+				report(n.Pos(), "identifier", n.Name)
+			}
+		case *ast.ImportSpec:
+			if importPath, err := strconv.Unquote(n.Path.Value); err == nil && slices.Contains(strings.Split(importPath, "/"), TemplateEntity) {
+				report(n.Pos(), "import", importPath)
+			}
+		}
+		return true
+	})
+	return problems
+}
@@ -0,0 +1,69 @@
+package ddd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestScanProject(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"Makefile":    "build:\n\tgo build -o bin/{{.ProjectName}} ./cmd\n",
+		"README.md":   "# {{ title .ProjectName }}\n\nRun {{- if .IncludeAuth}} with auth{{end}}.\n",
+		"ci.yml":      "ref: ${{ github.ref }}\n",
+		"page.tsx":    "export const Page = () => <div style={{ color: 'red' }} />\n",
+		"go.mod":      "module example.com/shop\n",
+		"cmd/main.go": "package main\n\nimport _ \"github.com/acme/templates/ddd-api/internal/auth\"\n\nfunc main() {}\n",
+		"internal/product/model.go": "package product\n\n" +
+			"type Product struct{}\n\n" +
+			"var itemCount, listItems, Items int\n\n" +
+			"var items = []Product{{}}\n",
+		"migrations/000002_create_products_table.up.sql": "CREATE TABLE products (id UUID);\nCREATE INDEX idx_items_name ON items(name);\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	templates := fstest.MapFS{"ddd-api/go.mod.tmpl": {Data: []byte("module github.com/acme/templates/ddd-api\n")}}
+	problems, err := scanProject(root, newLeftovers(templates, "ddd-api", []string{"product"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, p := range problems {
+		got = append(got, p.String())
+	}
+	want := []string{
+		"Makefile:2: unrendered: template action \"{{.ProjectName}}\" was not rendered",
+		"README.md:1: unrendered: template action \"{{ title .ProjectName }}\" was not rendered",
+		"README.md:3: unrendered: template action \"{{- if .IncludeAuth}}\" was not rendered",
+		"cmd/main.go:3: leftover: the template's module path github.com/acme/templates/ddd-api is still used",
+		"internal/product/model.go:5: leftover: identifier itemCount still names the sample entity 'item'",
+		"internal/product/model.go:5: leftover: identifier listItems still names the sample entity 'item'",
+		"internal/product/model.go:5: leftover: identifier Items still names the sample entity 'item'",
+		"internal/product/model.go:7: leftover: identifier items still names the sample entity 'item'",
+		"migrations/000002_create_products_table.up.sql:2: leftover: the sample entity \"items\" is still used",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("problems:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestNewLeftoversKeepsSampleInEntityNames(t *testing.T) {
+	templates := fstest.MapFS{"ddd-api/go.mod.tmpl": {Data: []byte("module github.com/acme/templates/ddd-api\n")}}
+	for entities, want := range map[string]bool{"product": true, "item": false, "line_item": false, "itemset": true} {
+		if got := newLeftovers(templates, "ddd-api", []string{"order", entities}).sample; got != want {
+			t.Errorf("entities order, %s: sample checked = %v, want %v", entities, got, want)
+		}
+	}
+}
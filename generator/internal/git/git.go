@@ -1,10 +1,16 @@
 package git
 
 import (
+	"archive/tar"
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
 )
 
 type Manager struct {
@@ -60,7 +66,186 @@ func (m *Manager) IsGitAvailable() bool {
 
 func runCommand(name string, args ...string) error {
 	cmd := exec.Command(name, args...)
-	cmd.Stdout = os.Stdout
+	cmd.Stdout = os.Stderr
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
-}
\ No newline at end of file
+}
+
+// File is a file of a commit or rendered tree
+type File struct {
+	Data []byte
+	Mode os.FileMode
+}
+
+// FileMode returns the mode git records for a file: executable or not
+func FileMode(mode os.FileMode) os.FileMode {
+	if mode&0111 != 0 {
+		return 0755
+	}
+	return 0644
+}
+
+// IsClean reports whether the working tree has no uncommitted changes
+func (m *Manager) IsClean() (bool, error) {
+	out, err := m.output(nil, "status", "--porcelain")
+	if err != nil {
+		return false, err
+	}
+	return len(bytes.TrimSpace(out)) == 0, nil
+}
+
+// ResolveRef returns the commit a ref points at
+func (m *Manager) ResolveRef(ref string) (string, error) {
+	out, err := m.output(nil, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("ref %s not found", ref)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// RootCommit returns the first commit of the current branch
+func (m *Manager) RootCommit() (string, error) {
+	out, err := m.output(nil, "rev-list", "--max-parents=0", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	roots := strings.Fields(string(out))
+	if len(roots) == 0 {
+		return "", fmt.Errorf("repository has no commits")
+	}
+	return roots[len(roots)-1], nil
+}
+
+// Files returns the files of a commit keyed by their slash-separated path
+func (m *Manager) Files(commit string) (map[string]File, error) {
+	out, err := m.output(nil, "archive", "--format=tar", commit)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]File)
+	reader := tar.NewReader(bytes.NewReader(out))
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", commit, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", header.Name, err)
+		}
+		files[header.Name] = File{Data: data, Mode: FileMode(os.FileMode(header.Mode))}
+	}
+	return files, nil
+}
+
+// CreateBranch creates a branch at HEAD and switches to it
+func (m *Manager) CreateBranch(name string) error {
+	if _, err := m.output(nil, "checkout", "-b", name); err != nil {
+		return fmt.Errorf("failed to create branch %s: %w", name, err)
+	}
+	return nil
+}
+
+// CommitAll commits every change in the working tree
+func (m *Manager) CommitAll(message string) error {
+	if _, err := m.output(nil, "add", "-A"); err != nil {
+		return fmt.Errorf("failed to add files to git: %w", err)
+	}
+	if _, err := m.output(nil, "commit", "-m", message); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+// SnapshotDir commits the files of dir, which is outside the working tree,
+// without touching the index or any branch, and returns the commit
+func (m *Manager) SnapshotDir(dir, parent, message string) (string, error) {
+	index, err := os.CreateTemp("", "go-gen-index-*")
+	if err != nil {
+		return "", err
+	}
+	index.Close()
+	os.Remove(index.Name())
+	defer os.Remove(index.Name())
+
+	env := []string{"GIT_INDEX_FILE=" + index.Name()}
+	if _, err := m.output(env, "--work-tree="+dir, "add", "-A", ":/"); err != nil {
+		return "", fmt.Errorf("failed to add %s: %w", dir, err)
+	}
+	tree, err := m.output(env, "write-tree")
+	if err != nil {
+		return "", err
+	}
+	args := []string{"commit-tree", strings.TrimSpace(string(tree)), "-m", message}
+	if parent != "" {
+		args = append(args, "-p", parent)
+	}
+	commit, err := m.output(nil, args...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(commit)), nil
+}
+
+// UpdateRef points ref at commit
+func (m *Manager) UpdateRef(ref, commit string) error {
+	if _, err := m.output(nil, "update-ref", ref, commit); err != nil {
+		return fmt.Errorf("failed to update %s: %w", ref, err)
+	}
+	return nil
+}
+
+// output runs git in the project and returns its standard output
+func (m *Manager) output(env []string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = m.projectPath
+	cmd.Env = append(os.Environ(), env...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// MergeFile merges the changes from base to theirs into ours, the way git
+// merges a file, and reports whether conflict markers were left. Labels name
+// ours, base and theirs in the markers.
+func MergeFile(ours, base, theirs []byte, labels [3]string) ([]byte, bool, error) {
+	dir, err := os.MkdirTemp("", "go-gen-merge-*")
+	if err != nil {
+		return nil, false, err
+	}
+	defer os.RemoveAll(dir)
+
+	args := []string{"merge-file", "-p"}
+	for _, label := range labels {
+		args = append(args, "-L", label)
+	}
+	for i, data := range [][]byte{ours, base, theirs} {
+		path := filepath.Join(dir, strconv.Itoa(i))
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return nil, false, err
+		}
+		args = append(args, path)
+	}
+
+	// merge-file exits with the number of conflicts, negative on errors
+	out, err := exec.Command("git", args...).Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 && exitErr.ExitCode() < 128 {
+		return out, true, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("git merge-file: %w", err)
+	}
+	return out, false, nil
+}
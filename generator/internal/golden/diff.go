@@ -0,0 +1,174 @@
+package golden
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 3
+
+// maxDiffCells bounds the table the line diff fills; larger changes are
+// shown as one replaced block
+const maxDiffCells = 4_000_000
+
+type edit struct {
+	op   byte // ' ', '-' or '+'
+	line string
+	from int // lines of each side before this one
+	to   int
+}
+
+// unifiedDiff returns the difference between two files as a unified diff
+// of their lines, empty when they are equal
+func unifiedDiff(fromName, toName string, from, to []byte) string {
+	if bytes.Equal(from, to) {
+		return ""
+	}
+	if isBinary(from) || isBinary(to) {
+		return fmt.Sprintf("Binary files %s and %s differ\n", fromName, toName)
+	}
+
+	edits := diffLines(splitLines(from), splitLines(to))
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", fromName, toName)
+	hunks := 0
+	for start := 0; start < len(edits); {
+		// A hunk runs from the context before a change to the context
+		// after the last change that follows it closely enough
+		first := indexChange(edits, start)
+		if first < 0 {
+			break
+		}
+		last := first
+		for next := indexChange(edits, last+1); next >= 0 && next-last <= 2*diffContext; next = indexChange(edits, last+1) {
+			last = next
+		}
+		lo, hi := max(first-diffContext, 0), min(last+diffContext+1, len(edits))
+		writeHunk(&b, edits[lo:hi])
+		hunks++
+		start = hi
+	}
+	if hunks == 0 || bytes.HasSuffix(from, []byte("\n")) != bytes.HasSuffix(to, []byte("\n")) {
+		fmt.Fprintf(&b, "(the newline at the end of the file differs)\n")
+	}
+	return b.String()
+}
+
+func writeHunk(b *strings.Builder, hunk []edit) {
+	fromCount, toCount := 0, 0
+	for _, e := range hunk {
+		if e.op != '+' {
+			fromCount++
+		}
+		if e.op != '-' {
+			toCount++
+		}
+	}
+	fromStart, toStart := hunk[0].from, hunk[0].to
+	if fromCount > 0 {
+		fromStart++
+	}
+	if toCount > 0 {
+		toStart++
+	}
+	fmt.Fprintf(b, "@@ -%d,%d +%d,%d @@\n", fromStart, fromCount, toStart, toCount)
+	for _, e := range hunk {
+		fmt.Fprintf(b, "%c%s\n", e.op, e.line)
+	}
+}
+
+func indexChange(edits []edit, start int) int {
+	for i := start; i < len(edits); i++ {
+		if edits[i].op != ' ' {
+			return i
+		}
+	}
+	return -1
+}
+
+// diffLines returns the edits turning from into to, keeping the longest
+// common subsequence of lines
+func diffLines(from, to []string) []edit {
+	// Lines shared at both ends need no table
+	prefix := 0
+	for prefix < len(from) && prefix < len(to) && from[prefix] == to[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(from)-prefix && suffix < len(to)-prefix && from[len(from)-1-suffix] == to[len(to)-1-suffix] {
+		suffix++
+	}
+	a, b := from[prefix:len(from)-suffix], to[prefix:len(to)-suffix]
+
+	edits := make([]edit, 0, len(from)+len(to))
+	i, j := 0, 0
+	add := func(op byte, line string) {
+		edits = append(edits, edit{op: op, line: line, from: i, to: j})
+		if op != '+' {
+			i++
+		}
+		if op != '-' {
+			j++
+		}
+	}
+	for _, line := range from[:prefix] {
+		add(' ', line)
+	}
+
+	if (len(a)+1)*(len(b)+1) > maxDiffCells {
+		for _, line := range a {
+			add('-', line)
+		}
+		for _, line := range b {
+			add('+', line)
+		}
+	} else {
+		// lcs[x][y] is the longest common subsequence of a[x:] and b[y:]
+		lcs := make([][]int, len(a)+1)
+		for x := range lcs {
+			lcs[x] = make([]int, len(b)+1)
+		}
+		for x := len(a) - 1; x >= 0; x-- {
+			for y := len(b) - 1; y >= 0; y-- {
+				if a[x] == b[y] {
+					lcs[x][y] = lcs[x+1][y+1] + 1
+				} else {
+					lcs[x][y] = max(lcs[x+1][y], lcs[x][y+1])
+				}
+			}
+		}
+		x, y := 0, 0
+		for x < len(a) || y < len(b) {
+			switch {
+			case x < len(a) && y < len(b) && a[x] == b[y]:
+				add(' ', a[x])
+				x, y = x+1, y+1
+			case y == len(b) || (x < len(a) && lcs[x+1][y] >= lcs[x][y+1]):
+				add('-', a[x])
+				x++
+			default:
+				add('+', b[y])
+				y++
+			}
+		}
+	}
+
+	for _, line := range from[len(from)-suffix:] {
+		add(' ', line)
+	}
+	return edits
+}
+
+func splitLines(data []byte) []string {
+	text := strings.TrimSuffix(string(data), "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+func isBinary(data []byte) bool {
+	return bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0
+}
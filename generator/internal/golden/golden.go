@@ -0,0 +1,253 @@
+// Package golden renders the templates with fixture answers and compares the
+// output with checked-in snapshots, so that a change to the generator or a
+// template shows up as a diff to review rather than in the next project.
+package golden
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	generator "github.com/darkphotonKN/go-template-generator"
+	"github.com/darkphotonKN/go-template-generator/internal/config"
+	"github.com/darkphotonKN/go-template-generator/internal/ddd"
+	"github.com/darkphotonKN/go-template-generator/internal/schema"
+	"github.com/darkphotonKN/go-template-generator/internal/wizard"
+)
+
+// AnswersSuffix ends the name of a fixture: an answers file as written by
+// go-gen create --save-answers
+const AnswersSuffix = ".answers.yaml"
+
+// Suffix is appended to every file of a snapshot, so that files such as
+// .gitignore and go.mod don't act on the repository keeping it
+const Suffix = ".golden"
+
+// Fixture is an answers file and the snapshot of what it renders: the
+// directory next to it named after it
+type Fixture struct {
+	Name    string
+	Answers string
+	Dir     string
+}
+
+// Difference is a file the render and the snapshot disagree on
+type Difference struct {
+	File string // slash-separated, relative to the project root
+	Kind string // Missing, Added or Changed
+	Diff string // unified diff of a changed file, snapshot to render
+}
+
+// Kinds of difference
+const (
+	Missing = "missing" // in the snapshot only
+	Added   = "added"   // rendered only
+	Changed = "changed"
+)
+
+func (d Difference) String() string {
+	return fmt.Sprintf("%s %s", d.File, d.Kind)
+}
+
+// Fixtures returns the fixtures in dir by name
+func Fixtures(dir string) ([]Fixture, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*"+AnswersSuffix))
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no fixtures (*%s) in %s", AnswersSuffix, dir)
+	}
+
+	fixtures := make([]Fixture, 0, len(matches))
+	for _, answers := range matches {
+		name := strings.TrimSuffix(filepath.Base(answers), AnswersSuffix)
+		fixtures = append(fixtures, Fixture{Name: name, Answers: answers, Dir: filepath.Join(dir, name)})
+	}
+	return fixtures, nil
+}
+
+// Render renders a fixture's answers into dir, returning the project root
+// in it. Nil templates are the embedded ones. The configuration is the
+// built-in one, so the user's config.yaml doesn't leak into snapshots.
+func Render(templatesFS fs.FS, f Fixture, dir string) (string, error) {
+	answers, err := wizard.LoadAnswers(f.Answers)
+	if err != nil {
+		return "", err
+	}
+	ports := answers.Ports
+	if ports.API == 0 || ports.DB == 0 || ports.Redis == 0 || (answers.Features.Frontend && ports.Frontend == 0) {
+		return "", fmt.Errorf("fixture %s must set every port, since allocated ones differ between runs", f.Name)
+	}
+
+	cfg, err := config.Parse(generator.DefaultConfig)
+	if err != nil {
+		return "", err
+	}
+	cfg.Hooks = nil
+	cfg.Defaults.ModulePrefix = answers.ModulePrefix
+
+	opts := &ddd.GeneratorOptions{
+		ProjectName:        answers.ProjectName,
+		IncludeAuth:        answers.Features.Auth,
+		IncludeS3:          answers.Features.S3,
+		IncludeRedis:       answers.Features.Redis,
+		IncludeFrontend:    answers.Features.Frontend,
+		ProjectDescription: answers.Description,
+		Config:             cfg,
+		APIPort:            ports.API,
+		DBPort:             ports.DB,
+		RedisPort:          ports.Redis,
+		FrontendPort:       ports.Frontend,
+		Templates:          templatesFS,
+	}
+	domain := &schema.Schema{Entities: answers.Entities}
+	if source := answers.Source(); source != "" {
+		loaded, err := ddd.LoadSource(answers.Schema, answers.FromSQL, answers.FromOpenAPI)
+		if err != nil {
+			return "", err
+		}
+		domain = loaded.Schema
+		opts.SchemaFile, opts.SchemaName = source, loaded.SchemaName
+		opts.Migrations, opts.API = loaded.Migrations, loaded.API
+	}
+	if err := domain.Validate(); err != nil {
+		return "", err
+	}
+	opts.Entities, opts.Fields, opts.Relations = domain.EntityNames(), domain.Fields(), domain.Relations()
+
+	if err := ddd.Render(opts, dir); err != nil {
+		return "", fmt.Errorf("failed to render fixture %s: %w", f.Name, err)
+	}
+	return filepath.Join(dir, answers.ProjectName), nil
+}
+
+// Check renders a fixture and compares it with its snapshot
+func Check(templatesFS fs.FS, f Fixture) ([]Difference, error) {
+	dir, err := os.MkdirTemp("", "go-gen-golden-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	root, err := Render(templatesFS, f, dir)
+	if err != nil {
+		return nil, err
+	}
+	rendered, err := readTree(root, "")
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := readTree(f.Dir, Suffix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the snapshot of %s (create it with --update): %w", f.Name, err)
+	}
+	return compare(f.Name, snapshot, rendered), nil
+}
+
+// Update renders a fixture and replaces its snapshot with the result
+func Update(templatesFS fs.FS, f Fixture) error {
+	dir, err := os.MkdirTemp("", "go-gen-golden-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	root, err := Render(templatesFS, f, dir)
+	if err != nil {
+		return err
+	}
+	rendered, err := readTree(root, "")
+	if err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(f.Dir); err != nil {
+		return fmt.Errorf("failed to remove the snapshot of %s: %w", f.Name, err)
+	}
+	for name, data := range rendered {
+		path := filepath.Join(f.Dir, filepath.FromSlash(name)+Suffix)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return fmt.Errorf("failed to write snapshot: %w", err)
+		}
+	}
+	return nil
+}
+
+// Report describes the differences of a fixture for people, diffs included
+func Report(f Fixture, differences []Difference) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "fixture %s: %d file(s) differ from %s\n", f.Name, len(differences), f.Dir)
+	for _, d := range differences {
+		switch d.Kind {
+		case Changed:
+			b.WriteString(d.Diff)
+		default:
+			fmt.Fprintf(&b, "%s: %s\n", d.Kind, d.File)
+		}
+	}
+	return b.String()
+}
+
+// compare lists the files in which a render differs from a snapshot
+func compare(name string, snapshot, rendered map[string][]byte) []Difference {
+	var files []string
+	for file := range snapshot {
+		files = append(files, file)
+	}
+	for file := range rendered {
+		if _, ok := snapshot[file]; !ok {
+			files = append(files, file)
+		}
+	}
+	slices.Sort(files)
+
+	var differences []Difference
+	for _, file := range files {
+		want, inSnapshot := snapshot[file]
+		got, inRender := rendered[file]
+		switch {
+		case !inRender:
+			differences = append(differences, Difference{File: file, Kind: Missing})
+		case !inSnapshot:
+			differences = append(differences, Difference{File: file, Kind: Added})
+		case !bytes.Equal(want, got):
+			label := name + "/" + file
+			differences = append(differences, Difference{
+				File: file,
+				Kind: Changed,
+				Diff: unifiedDiff(label+" (snapshot)", label+" (rendered)", want, got),
+			})
+		}
+	}
+	return differences
+}
+
+// readTree reads every file under root by slash-separated relative path,
+// without suffix
+func readTree(root, suffix string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[strings.TrimSuffix(filepath.ToSlash(rel), suffix)] = data
+		return nil
+	})
+	return files, err
+}
@@ -0,0 +1,126 @@
+package golden
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the template snapshots in templates/testdata")
+
+// fixtures is where the repository keeps the fixtures of its templates
+const fixtures = "../../../templates/testdata"
+
+func TestTemplates(t *testing.T) {
+	Test(t, nil, fixtures, *update)
+}
+
+func TestRenderIsRepeatable(t *testing.T) {
+	found, err := Fixtures(fixtures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := found[0]
+
+	first, err := Render(nil, f, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Render(nil, f, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := readTree(first, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := readTree(second, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if differences := compare(f.Name, a, b); len(differences) > 0 {
+		t.Errorf("two renders of %s differ:\n%s", f.Name, Report(f, differences))
+	}
+}
+
+func TestCheckReportsDifferences(t *testing.T) {
+	found, err := Fixtures(fixtures)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A copy of the first fixture whose snapshot has one file edited, one
+	// removed and one too many
+	dir := t.TempDir()
+	data, err := os.ReadFile(found[0].Answers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := Fixture{Name: found[0].Name, Answers: filepath.Join(dir, found[0].Name+AnswersSuffix), Dir: filepath.Join(dir, found[0].Name)}
+	if err := os.WriteFile(f.Answers, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Update(nil, f); err != nil {
+		t.Fatal(err)
+	}
+	readme := filepath.Join(f.Dir, "README.md"+Suffix)
+	text, err := os.ReadFile(readme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(readme, []byte(strings.Replace(string(text), "\n", "\nAn edited line\n", 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(f.Dir, "Makefile"+Suffix)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(f.Dir, "extra.txt"+Suffix), []byte("extra\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	differences, err := Check(nil, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, d := range differences {
+		got = append(got, d.String())
+	}
+	want := "Makefile added, README.md changed, extra.txt missing"
+	if strings.Join(got, ", ") != want {
+		t.Errorf("differences = %s, want %s", strings.Join(got, ", "), want)
+	}
+	if diff := differences[1].Diff; !strings.Contains(diff, "\n-An edited line\n") || !strings.Contains(diff, "@@ -1,") {
+		t.Errorf("unexpected diff:\n%s", diff)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	from := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	to := "a\nb\nc\nD\ne\nf\ng\nh\ni\nj\nk\n"
+	want := `--- old
++++ new
+@@ -1,7 +1,7 @@
+ a
+ b
+ c
+-d
++D
+ e
+ f
+ g
+@@ -8,3 +8,4 @@
+ h
+ i
+ j
++k
+`
+	if got := unifiedDiff("old", "new", []byte(from), []byte(to)); got != want {
+		t.Errorf("diff:\n%s\nwant:\n%s", got, want)
+	}
+	if got := unifiedDiff("old", "new", []byte("a\n"), []byte("a")); !strings.Contains(got, "newline at the end") {
+		t.Errorf("expected the missing newline reported, got:\n%s", got)
+	}
+}
@@ -0,0 +1,36 @@
+package golden
+
+import (
+	"io/fs"
+	"testing"
+)
+
+// Test checks each fixture in dir against its snapshot in a subtest, or
+// rewrites the snapshots when update is set. Nil templates are the
+// embedded ones.
+func Test(t *testing.T, templatesFS fs.FS, dir string, update bool) {
+	t.Helper()
+	fixtures, err := Fixtures(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range fixtures {
+		t.Run(f.Name, func(t *testing.T) {
+			if update {
+				if err := Update(templatesFS, f); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			differences, err := Check(templatesFS, f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(differences) > 0 {
+				t.Errorf("%srun with -update to accept the changes", Report(f, differences))
+			}
+		})
+	}
+}
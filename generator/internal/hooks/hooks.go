@@ -0,0 +1,191 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Phases of generation a hook can run at, in order
+const (
+	BeforeCopy   = "before_copy"    // variables resolved, nothing written yet
+	AfterRender  = "after_render"   // every template rendered
+	AfterGoMod   = "after_go_mod"   // go.mod tidied
+	AfterGitInit = "after_git_init" // initial commit made
+)
+
+// Phases lists every phase in the order they run
+var Phases = []string{BeforeCopy, AfterRender, AfterGoMod, AfterGitInit}
+
+// What a failing hook does to the generation
+const (
+	OnFailureAbort = "abort" // stop and remove the project
+	OnFailureWarn  = "warn"  // report it and carry on
+)
+
+// DefaultTimeout bounds hooks that don't set their own
+const DefaultTimeout = 5 * time.Minute
+
+// EnvPrefix starts the environment variables holding template variables,
+// e.g. GOGEN_PROJECT_NAME for ProjectName
+const EnvPrefix = "GOGEN_"
+
+// ErrAborted marks the failure of a hook whose policy is to abort
+var ErrAborted = errors.New("generation aborted")
+
+// Hook is a shell command run at a phase of generation, e.g. a script
+// shipped with the template ("sh scripts/setup.sh") or a team's own step
+type Hook struct {
+	Name      string        `yaml:"name"`
+	Phase     string        `yaml:"phase"`
+	Run       string        `yaml:"run"`
+	Timeout   time.Duration `yaml:"timeout"`    // DefaultTimeout when unset
+	OnFailure string        `yaml:"on_failure"` // OnFailureAbort when unset
+}
+
+// Validate checks hook declarations, reporting every problem
+func Validate(hooks []Hook) error {
+	var errs []error
+	for i, h := range hooks {
+		name := h.Name
+		if name == "" {
+			name = fmt.Sprintf("%d", i+1)
+		}
+		if !slices.Contains(Phases, h.Phase) {
+			errs = append(errs, fmt.Errorf("hook %s has unknown phase %q (use %s)", name, h.Phase, strings.Join(Phases, ", ")))
+		}
+		if strings.TrimSpace(h.Run) == "" {
+			errs = append(errs, fmt.Errorf("hook %s has nothing to run", name))
+		}
+		if h.OnFailure != "" && h.OnFailure != OnFailureAbort && h.OnFailure != OnFailureWarn {
+			errs = append(errs, fmt.Errorf("hook %s has unknown on_failure %q (use %s or %s)", name, h.OnFailure, OnFailureAbort, OnFailureWarn))
+		}
+		if h.Timeout < 0 {
+			errs = append(errs, fmt.Errorf("hook %s has a negative timeout", name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type registered struct {
+	Hook
+	dir string
+}
+
+// Runner runs the hooks of a generation with the template variables in
+// their environment
+type Runner struct {
+	hooks []registered
+	env   []string
+	root  string
+
+	Warnings []string // failures of hooks that only warn
+}
+
+// NewRunner prepares hooks for the project created at root; vars are
+// exposed to them as GOGEN_* environment variables
+func NewRunner(root string, vars map[string]any) *Runner {
+	env := os.Environ()
+	for name, value := range vars {
+		env = append(env, EnvName(name)+"="+fmt.Sprint(value))
+	}
+	return &Runner{env: append(env, EnvPrefix+"PROJECT_DIR="+root), root: root}
+}
+
+// Add registers hooks run in dir, which must be inside the project
+func (r *Runner) Add(hooks []Hook, dir string) {
+	for _, h := range hooks {
+		r.hooks = append(r.hooks, registered{Hook: h, dir: dir})
+	}
+}
+
+// Run runs the hooks of a phase in the order they were added. Hooks run
+// before the copy run where the project is being created. A failing hook
+// stops the run with an error wrapping ErrAborted unless it only warns.
+// A nil Runner runs nothing.
+func (r *Runner) Run(phase string) error {
+	if r == nil {
+		return nil
+	}
+
+	for _, h := range r.hooks {
+		if h.Phase != phase {
+			continue
+		}
+
+		dir := h.dir
+		if phase == BeforeCopy {
+			dir = filepath.Dir(r.root)
+		}
+
+		label := h.Name
+		if label == "" {
+			label = h.Run
+		}
+		fmt.Fprintf(os.Stderr, "🪝 Running hook '%s' (%s)...\n", label, phase)
+		if err := h.run(dir, r.env); err != nil {
+			if h.OnFailure == OnFailureWarn {
+				warning := fmt.Sprintf("hook '%s' failed: %v", label, err)
+				fmt.Fprintf(os.Stderr, "⚠️  Warning: %s\n", warning)
+				r.Warnings = append(r.Warnings, warning)
+				continue
+			}
+			return fmt.Errorf("hook '%s' failed: %w: %w", label, err, ErrAborted)
+		}
+	}
+	return nil
+}
+
+func (h Hook) run(dir string, env []string) error {
+	timeout := h.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+	cmd := exec.CommandContext(ctx, shell, flag, h.Run)
+	cmd.Dir = dir
+	cmd.Env = append(slices.Clip(env), EnvPrefix+"PHASE="+h.Phase)
+	cmd.Stdout = os.Stderr // progress, like go-gen's own
+	cmd.Stderr = os.Stderr
+	cmd.WaitDelay = time.Second
+	killProcessGroup(cmd)
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+	return err
+}
+
+// EnvName returns the environment variable a template variable is exposed
+// as: ProjectName becomes GOGEN_PROJECT_NAME, APIPort GOGEN_API_PORT
+func EnvName(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	b.WriteString(EnvPrefix)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
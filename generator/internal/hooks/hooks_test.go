@@ -0,0 +1,94 @@
+package hooks
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnvName(t *testing.T) {
+	for name, want := range map[string]string{
+		"ProjectName":     "GOGEN_PROJECT_NAME",
+		"APIPort":         "GOGEN_API_PORT",
+		"DBPassword":      "GOGEN_DB_PASSWORD",
+		"IncludeS3":       "GOGEN_INCLUDE_S3",
+		"EntityPlural":    "GOGEN_ENTITY_PLURAL",
+		"IncludeFrontend": "GOGEN_INCLUDE_FRONTEND",
+	} {
+		if got := EnvName(name); got != want {
+			t.Errorf("EnvName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	err := Validate([]Hook{
+		{Name: "ok", Phase: AfterGitInit, Run: "true"},
+		{Name: "phase", Phase: "after_lunch", Run: "true"},
+		{Name: "empty", Phase: AfterRender},
+		{Name: "policy", Phase: AfterRender, Run: "true", OnFailure: "ignore"},
+	})
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	for _, want := range []string{"hook phase has unknown phase", "hook empty has nothing to run", "hook policy has unknown on_failure"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to mention %q, got: %v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "hook ok") {
+		t.Errorf("Expected the valid hook to pass, got: %v", err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not found")
+	}
+
+	root := filepath.Join(t.TempDir(), "shop")
+	if err := os.Mkdir(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	runner := NewRunner(root, map[string]any{"ProjectName": "shop", "APIPort": 8010})
+	runner.Add([]Hook{
+		{Name: "env", Phase: AfterRender, Run: `echo "$GOGEN_PROJECT_NAME $GOGEN_API_PORT $GOGEN_PHASE" > env.txt`},
+		{Name: "flaky", Phase: AfterRender, Run: "exit 3", OnFailure: OnFailureWarn},
+		{Name: "later", Phase: AfterGitInit, Run: "touch later.txt"},
+	}, root)
+
+	if err := runner.Run(AfterRender); err != nil {
+		t.Fatalf("Expected a warning only, got %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(root, "env.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "shop 8010 after_render" {
+		t.Errorf("hook environment = %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(root, "later.txt")); !os.IsNotExist(err) {
+		t.Errorf("Expected hooks of other phases not to run")
+	}
+
+	runner.Add([]Hook{
+		{Name: "slow", Phase: AfterGoMod, Run: "sleep 5", Timeout: 100 * time.Millisecond},
+		{Name: "skipped", Phase: AfterGoMod, Run: "touch skipped.txt"},
+	}, root)
+	err = runner.Run(AfterGoMod)
+	if !errors.Is(err, ErrAborted) || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Expected a timed out abort, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "skipped.txt")); !os.IsNotExist(err) {
+		t.Errorf("Expected hooks after an abort not to run")
+	}
+
+	var nilRunner *Runner
+	if err := nilRunner.Run(AfterRender); err != nil {
+		t.Errorf("Expected a nil runner to run nothing, got %v", err)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package hooks
+
+import "os/exec"
+
+// killProcessGroup leaves the default of killing the shell only
+func killProcessGroup(cmd *exec.Cmd) {}
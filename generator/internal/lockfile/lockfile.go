@@ -0,0 +1,187 @@
+package lockfile
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/darkphotonKN/go-template-generator/internal/schema"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the lockfile kept at the root of every generated project
+const FileName = ".go-gen.yaml"
+
+const header = "# Written by go-gen: how this project was generated. Commands run inside\n" +
+	"# the project read it, so keep it committed and don't edit it by hand.\n"
+
+// Lockfile records everything a project was generated with, so later
+// commands can work from the project directory alone
+type Lockfile struct {
+	Project   string          `yaml:"project"`
+	Templates []Template      `yaml:"templates"` // the API template first
+	Features  Features        `yaml:"features"`
+	Ports     Ports           `yaml:"ports"`
+	Entities  []schema.Entity `yaml:"entities"` // the primary entity first
+
+	// Files holds the SHA-256 of each file as go-gen last wrote it, keyed
+	// by its slash-separated path from the project root
+	Files map[string]string `yaml:"files"`
+}
+
+// Template is one template rendered into the project
+type Template struct {
+	Name      string         `yaml:"name"`
+	Version   string         `yaml:"version,omitempty"`
+	Hash      string         `yaml:"hash"` // of the template's files, see HashFS
+	Dir       string         `yaml:"dir"`  // output directory from the project root
+	Variables map[string]any `yaml:"variables"`
+}
+
+type Features struct {
+	Auth     bool `yaml:"auth"`
+	S3       bool `yaml:"s3"`
+	Redis    bool `yaml:"redis"`
+	Frontend bool `yaml:"frontend"`
+}
+
+type Ports struct {
+	API      int `yaml:"api"`
+	DB       int `yaml:"db"`
+	Redis    int `yaml:"redis"`
+	Frontend int `yaml:"frontend,omitempty"`
+}
+
+// Load reads the lockfile of the project at root. A missing lockfile is
+// reported with an error matching fs.ErrNotExist.
+func Load(root string) (*Lockfile, error) {
+	data, err := os.ReadFile(filepath.Join(root, FileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", FileName, err)
+	}
+
+	var l Lockfile
+	if err := yaml.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", FileName, err)
+	}
+	return &l, nil
+}
+
+// Save writes the lockfile to the project at root
+func (l *Lockfile) Save(root string) error {
+	var buf bytes.Buffer
+	buf.WriteString(header)
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(l); err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", FileName, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, FileName), buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", FileName, err)
+	}
+	return nil
+}
+
+// Template returns the rendered template by name, or nil
+func (l *Lockfile) Template(name string) *Template {
+	for i := range l.Templates {
+		if l.Templates[i].Name == name {
+			return &l.Templates[i]
+		}
+	}
+	return nil
+}
+
+// Schema returns the recorded entities and their relations as a schema
+func (l *Lockfile) Schema() *schema.Schema {
+	return &schema.Schema{Entities: l.Entities}
+}
+
+// Track records the checksum of every file under root that was created or
+// changed since before was taken with Checksums
+func (l *Lockfile) Track(root string, before map[string]string) error {
+	after, err := Checksums(root)
+	if err != nil {
+		return err
+	}
+
+	if l.Files == nil {
+		l.Files = make(map[string]string)
+	}
+	for name, sum := range after {
+		if before[name] != sum {
+			l.Files[name] = sum
+		}
+	}
+	return nil
+}
+
+// Checksums returns the SHA-256 of every file under root, leaving out the
+// lockfile itself, git's metadata and installed node modules
+func Checksums(root string) (map[string]string, error) {
+	sums := make(map[string]string)
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" || d.Name() == "node_modules" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == FileName {
+			return nil
+		}
+
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		sums[rel] = Sum(data)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to checksum project files: %w", err)
+	}
+	return sums, nil
+}
+
+// Sum returns the hex-encoded SHA-256 of data
+func Sum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// HashFS returns one SHA-256 over every file of a template directory, so a
+// project records exactly which template contents it was rendered from
+func HashFS(fsys fs.FS, dir string) (string, error) {
+	h := sha256.New()
+	err := fs.WalkDir(fsys, dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s %s\n", strings.TrimPrefix(p, dir+"/"), Sum(data))
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to hash template %s: %w", dir, err)
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,109 @@
+package lockfile
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/darkphotonKN/go-template-generator/internal/schema"
+)
+
+func TestSaveLoad(t *testing.T) {
+	root := t.TempDir()
+	if _, err := Load(root); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Expected a missing lockfile to match fs.ErrNotExist, got %v", err)
+	}
+
+	want := &Lockfile{
+		Project: "shop",
+		Templates: []Template{{
+			Name:      "ddd-api",
+			Version:   "1.0.0",
+			Hash:      "sha256:abc",
+			Dir:       ".",
+			Variables: map[string]any{"APIPort": 8010, "IncludeAuth": true, "ProjectName": "shop"},
+		}},
+		Features: Features{Auth: true, Redis: true},
+		Ports:    Ports{API: 8010, DB: 5442, Redis: 6389},
+		Entities: []schema.Entity{{Name: "order", Fields: []schema.Field{{Name: "total", Type: "decimal"}}}},
+		Files:    map[string]string{"go.mod": Sum([]byte("module shop\n"))},
+	}
+	if err := want.Save(root); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	got, err := Load(root)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load = %+v, want %+v", got, want)
+	}
+}
+
+func TestTrack(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("kept.go", "a")
+	write("edited.go", "a")
+	write(".git/HEAD", "ref")
+	before, err := Checksums(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := before[".git/HEAD"]; ok || len(before) != 2 {
+		t.Fatalf("Checksums = %v, want kept.go and edited.go", before)
+	}
+
+	write("edited.go", "b")
+	write("internal/order/order.go", "c")
+	write(FileName, "project: shop\n")
+
+	l := &Lockfile{Files: map[string]string{"kept.go": "old"}}
+	if err := l.Track(root, before); err != nil {
+		t.Fatalf("Track failed: %v", err)
+	}
+	want := map[string]string{
+		"kept.go":                 "old",
+		"edited.go":               Sum([]byte("b")),
+		"internal/order/order.go": Sum([]byte("c")),
+	}
+	if !reflect.DeepEqual(l.Files, want) {
+		t.Errorf("Files = %v, want %v", l.Files, want)
+	}
+}
+
+func TestHashFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"api/go.mod":      {Data: []byte("module api\n")},
+		"api/main.go":     {Data: []byte("package main\n")},
+		"other/README.md": {Data: []byte("other\n")},
+	}
+	hash, err := HashFS(fsys, "api")
+	if err != nil {
+		t.Fatalf("HashFS failed: %v", err)
+	}
+
+	fsys["other/README.md"] = &fstest.MapFile{Data: []byte("changed\n")}
+	if again, _ := HashFS(fsys, "api"); again != hash {
+		t.Errorf("Expected files outside the template not to change its hash")
+	}
+
+	fsys["api/main.go"] = &fstest.MapFile{Data: []byte("package main\n\nfunc main() {}\n")}
+	if changed, _ := HashFS(fsys, "api"); changed == hash {
+		t.Errorf("Expected a changed template file to change the hash")
+	}
+}
@@ -0,0 +1,318 @@
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/darkphotonKN/go-template-generator/internal/hooks"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the manifest file every template directory carries
+const FileName = "template.yaml"
+
+// Variable types supported in a manifest
+const (
+	TypeString = "string"
+	TypeInt    = "int"
+	TypeBool   = "bool"
+)
+
+// Manifest describes a template and the variables its files may reference
+type Manifest struct {
+	Name        string       `yaml:"name"`
+	Version     string       `yaml:"version"` // bumped on changes projects can upgrade to
+	Description string       `yaml:"description"`
+	Variables   []Variable   `yaml:"variables"`
+	Files       []FileRule   `yaml:"files"`
+	Hooks       []hooks.Hook `yaml:"hooks"` // run in the template's output directory
+}
+
+// Variable declares one template variable. A variable is either supplied by
+// the generator or user (optionally falling back to Default) or computed from
+// earlier variables through Derive, a text/template expression.
+type Variable struct {
+	Name     string `yaml:"name"`
+	Type     string `yaml:"type"`
+	Default  any    `yaml:"default"`
+	Required bool   `yaml:"required"`
+	Pattern  string `yaml:"pattern"`
+	Prompt   string `yaml:"prompt"`
+	Derive   string `yaml:"derive"`
+	Secret   bool   `yaml:"secret"`
+}
+
+// FileRule includes a file or directory of the template only when When holds.
+// When names a bool variable, optionally negated with "!" (e.g. "!IncludeAuth").
+type FileRule struct {
+	Path string `yaml:"path"`
+	When string `yaml:"when"`
+}
+
+// Vars holds resolved template variables keyed by name
+type Vars map[string]any
+
+// String returns a variable rendered as text, or "" when it is not set
+func (v Vars) String(name string) string {
+	value, ok := v[name]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// Bool returns a boolean variable, or false when it is not set
+func (v Vars) Bool(name string) bool {
+	b, _ := v[name].(bool)
+	return b
+}
+
+// Load reads and checks the manifest of the template in dir
+func Load(fsys fs.FS, dir string) (*Manifest, error) {
+	data, err := fs.ReadFile(fsys, path.Join(dir, FileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template manifest: %w", err)
+	}
+
+	return Parse(data)
+}
+
+// Parse decodes a manifest and checks its declarations are usable
+func Parse(data []byte) (*Manifest, error) {
+	var m Manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse template manifest: %w", err)
+	}
+
+	var errs []error
+	seen := make(map[string]bool)
+	for i := range m.Variables {
+		v := &m.Variables[i]
+		if v.Type == "" {
+			v.Type = TypeString
+		}
+
+		switch {
+		case v.Name == "":
+			errs = append(errs, fmt.Errorf("variable %d has no name", i+1))
+		case seen[v.Name]:
+			errs = append(errs, fmt.Errorf("variable %s is declared twice", v.Name))
+		case v.Type != TypeString && v.Type != TypeInt && v.Type != TypeBool:
+			errs = append(errs, fmt.Errorf("variable %s has unknown type %q", v.Name, v.Type))
+		}
+		seen[v.Name] = true
+
+		if v.Pattern != "" {
+			if _, err := regexp.Compile(v.Pattern); err != nil {
+				errs = append(errs, fmt.Errorf("variable %s has invalid pattern: %w", v.Name, err))
+			}
+		}
+	}
+
+	for _, rule := range m.Files {
+		name := strings.TrimPrefix(rule.When, "!")
+		v, ok := m.Variable(name)
+		switch {
+		case rule.Path == "" || path.IsAbs(rule.Path) || strings.HasPrefix(path.Clean(rule.Path), ".."):
+			errs = append(errs, fmt.Errorf("file rule has invalid path %q", rule.Path))
+		case !ok:
+			errs = append(errs, fmt.Errorf("file rule for %s depends on undeclared variable %q", rule.Path, name))
+		case v.Type != TypeBool:
+			errs = append(errs, fmt.Errorf("file rule for %s depends on %s, which is not a bool", rule.Path, name))
+		}
+	}
+
+	if err := hooks.Validate(m.Hooks); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid template manifest: %w", err)
+	}
+
+	return &m, nil
+}
+
+// Excluded returns the template paths whose file rule doesn't hold for vars
+func (m *Manifest) Excluded(vars Vars) []string {
+	var excluded []string
+	for _, rule := range m.Files {
+		name, negated := strings.CutPrefix(rule.When, "!")
+		if vars.Bool(name) == negated {
+			excluded = append(excluded, path.Clean(rule.Path))
+		}
+	}
+	return excluded
+}
+
+// IsExcluded reports whether relPath is, or is inside, one of the excluded paths
+func IsExcluded(relPath string, excluded []string) bool {
+	for _, p := range excluded {
+		if relPath == p || strings.HasPrefix(relPath, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Variable returns the declaration of a variable by name
+func (m *Manifest) Variable(name string) (Variable, bool) {
+	for _, v := range m.Variables {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return Variable{}, false
+}
+
+// Public returns vars without the variables declared secret, for recording
+// in the generated project
+func (m *Manifest) Public(vars Vars) Vars {
+	public := make(Vars, len(vars))
+	for name, value := range vars {
+		if v, ok := m.Variable(name); !ok || !v.Secret {
+			public[name] = value
+		}
+	}
+	return public
+}
+
+// Check validates a value for a single variable against its type and pattern
+func (m *Manifest) Check(name string, value any) error {
+	v, ok := m.Variable(name)
+	if !ok {
+		return fmt.Errorf("%s is not declared", name)
+	}
+
+	converted, err := convert(v, value)
+	if err != nil {
+		return err
+	}
+	if v.Pattern != "" && v.Type == TypeString && !regexp.MustCompile(v.Pattern).MatchString(converted.(string)) {
+		return fmt.Errorf("%s %q does not match %s", v.Name, converted, v.Pattern)
+	}
+
+	return nil
+}
+
+// Resolve builds the variables for rendering from the supplied input.
+// Variables are processed in declaration order so derived expressions can use
+// anything declared before them; funcs are made available to those
+// expressions. Input keys the manifest does not declare are ignored. Every
+// problem found is reported, not just the first.
+func (m *Manifest) Resolve(input map[string]any, funcs template.FuncMap) (Vars, error) {
+	vars := make(Vars, len(m.Variables))
+	var errs []error
+
+	for _, v := range m.Variables {
+		value, ok := input[v.Name]
+
+		if !ok && v.Derive != "" {
+			derived, err := derive(v, vars, funcs)
+			if err != nil {
+				errs = append(errs, err)
+				vars[v.Name] = zero(v.Type)
+				continue
+			}
+			value, ok = derived, true
+		}
+
+		if !ok && v.Default != nil {
+			value, ok = v.Default, true
+		}
+
+		if !ok {
+			if v.Required {
+				errs = append(errs, fmt.Errorf("%s is required", v.Name))
+			}
+			value = zero(v.Type)
+		}
+
+		// Invalid values are still recorded so later derive expressions
+		// don't pile follow-up errors on top of the real one
+		converted, err := convert(v, value)
+		if err != nil {
+			errs = append(errs, err)
+			converted = zero(v.Type)
+		} else if err := m.Check(v.Name, converted); err != nil {
+			errs = append(errs, err)
+		}
+
+		vars[v.Name] = converted
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return vars, nil
+}
+
+// derive evaluates a variable's derive expression against the variables resolved so far
+func derive(v Variable, vars Vars, funcs template.FuncMap) (string, error) {
+	tmpl, err := template.New(v.Name).Funcs(funcs).Option("missingkey=error").Parse(v.Derive)
+	if err != nil {
+		return "", fmt.Errorf("%s: invalid derive expression: %w", v.Name, err)
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, vars); err != nil {
+		return "", fmt.Errorf("%s: failed to derive value: %w", v.Name, err)
+	}
+
+	return out.String(), nil
+}
+
+// convert coerces a supplied, default or derived value to the declared type
+func convert(v Variable, value any) (any, error) {
+	switch v.Type {
+	case TypeInt:
+		switch n := value.(type) {
+		case int:
+			return n, nil
+		case string:
+			i, err := strconv.Atoi(strings.TrimSpace(n))
+			if err != nil {
+				return nil, fmt.Errorf("%s must be an integer, got %q", v.Name, n)
+			}
+			return i, nil
+		}
+	case TypeBool:
+		switch b := value.(type) {
+		case bool:
+			return b, nil
+		case string:
+			parsed, err := strconv.ParseBool(strings.TrimSpace(b))
+			if err != nil {
+				return nil, fmt.Errorf("%s must be true or false, got %q", v.Name, b)
+			}
+			return parsed, nil
+		}
+	default:
+		switch s := value.(type) {
+		case string:
+			return s, nil
+		case int, bool:
+			return fmt.Sprint(s), nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s must be a %s, got %T", v.Name, v.Type, value)
+}
+
+func zero(varType string) any {
+	switch varType {
+	case TypeInt:
+		return 0
+	case TypeBool:
+		return false
+	default:
+		return ""
+	}
+}
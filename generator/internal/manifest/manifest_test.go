@@ -0,0 +1,158 @@
+package manifest
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/darkphotonKN/go-template-generator/templates"
+)
+
+const testManifest = `
+name: test
+variables:
+  - name: ProjectName
+    required: true
+    pattern: "^[a-z][a-z0-9-]*$"
+  - name: ModuleName
+    derive: "example.com/{{.ProjectName}}"
+  - name: Title
+    derive: "{{upper .ProjectName}}"
+  - name: APIPort
+    type: int
+    required: true
+  - name: IncludeAuth
+    type: bool
+    default: true
+`
+
+func TestResolve(t *testing.T) {
+	m, err := Parse([]byte(testManifest))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	funcs := template.FuncMap{"upper": strings.ToUpper}
+	vars, err := m.Resolve(map[string]any{"ProjectName": "shop", "APIPort": "8010", "Unknown": 1}, funcs)
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+
+	expected := Vars{
+		"ProjectName": "shop",
+		"ModuleName":  "example.com/shop",
+		"Title":       "SHOP",
+		"APIPort":     8010,
+		"IncludeAuth": true,
+	}
+	for name, want := range expected {
+		if vars[name] != want {
+			t.Errorf("Expected %s = %v, got %v", name, want, vars[name])
+		}
+	}
+	if _, ok := vars["Unknown"]; ok {
+		t.Errorf("Expected undeclared input to be dropped")
+	}
+}
+
+func TestResolveReportsAllErrors(t *testing.T) {
+	m, err := Parse([]byte(testManifest))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	_, err = m.Resolve(map[string]any{"ProjectName": "My App", "IncludeAuth": "maybe"},
+		template.FuncMap{"upper": strings.ToUpper})
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	lines := strings.Split(err.Error(), "\n")
+	if len(lines) != 3 {
+		t.Errorf("Expected 3 errors (pattern, missing port, bad bool), got %d:\n%v", len(lines), err)
+	}
+}
+
+func TestParseRejectsInvalidDeclarations(t *testing.T) {
+	_, err := Parse([]byte(`
+variables:
+  - name: A
+    type: float
+  - name: A
+  - name: B
+    pattern: "("
+hooks:
+  - name: setup
+    phase: after_everything
+    run: make setup
+`))
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	for _, want := range []string{"unknown type", "declared twice", "invalid pattern", "unknown phase"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to mention %q, got: %v", want, err)
+		}
+	}
+}
+
+func TestExcluded(t *testing.T) {
+	m, err := Parse([]byte(`
+variables:
+  - name: IncludeAuth
+    type: bool
+  - name: IncludeS3
+    type: bool
+files:
+  - path: internal/auth
+    when: IncludeAuth
+  - path: internal/s3/
+    when: IncludeS3
+  - path: docs/no-auth.md
+    when: "!IncludeAuth"
+`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	excluded := m.Excluded(Vars{"IncludeAuth": true, "IncludeS3": false})
+	if len(excluded) != 2 || excluded[0] != "internal/s3" || excluded[1] != "docs/no-auth.md" {
+		t.Fatalf("Expected internal/s3 and docs/no-auth.md excluded, got %v", excluded)
+	}
+
+	for path, want := range map[string]bool{
+		"internal/s3":            true,
+		"internal/s3/service.go": true,
+		"internal/s3x/file.go":   false,
+		"internal/auth/auth.go":  false,
+	} {
+		if got := IsExcluded(path, excluded); got != want {
+			t.Errorf("IsExcluded(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestPublic(t *testing.T) {
+	m, err := Parse([]byte(`
+variables:
+  - name: DBUser
+  - name: DBPassword
+    secret: true
+`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	public := m.Public(Vars{"DBUser": "user", "DBPassword": "hunter2"})
+	if len(public) != 1 || public["DBUser"] != "user" {
+		t.Errorf("Expected only DBUser kept, got %v", public)
+	}
+}
+
+func TestBundledManifestsParse(t *testing.T) {
+	for _, dir := range []string{"ddd-api", "nextjs-frontend"} {
+		if _, err := Load(templates.FS, dir); err != nil {
+			t.Errorf("%s: %v", dir, err)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"runtime/debug"
+	"slices"
+
+	"github.com/darkphotonKN/go-template-generator/internal/output"
+)
+
+// ProtocolVersion is the newest Model Context Protocol revision the server
+// speaks; clients asking for an older one in supportedVersions get it
+const ProtocolVersion = "2025-06-18"
+
+var supportedVersions = []string{ProtocolVersion, "2025-03-26", "2024-11-05"}
+
+// JSON-RPC 2.0 error codes
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+const instructions = "go-gen generates DDD Go API projects. Check names with validate_names " +
+	"and the ports a project would get with preview_ports, then create it with create_project; " +
+	"list_projects shows what was generated before. Results are JSON; failed calls carry an " +
+	"error code as in go-gen --output json."
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"` // absent for notifications
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string { return e.Message }
+
+// Tool is an operation clients can call. Its arguments are decoded like a
+// schema file: JSON read as YAML, unknown keys rejected.
+type Tool struct {
+	Name        string         `json:"name"`
+	Title       string         `json:"title,omitempty"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+
+	call func(args []byte) (any, error)
+}
+
+// Server answers MCP requests over newline-delimited JSON-RPC, one at a
+// time, so tool calls never run concurrently
+type Server struct {
+	tools []Tool
+}
+
+func NewServer(tools []Tool) *Server {
+	return &Server{tools: tools}
+}
+
+// Serve reads requests from r and writes responses to w until r ends.
+// Generation progress goes to stderr, as with --output json, so w carries
+// nothing but protocol messages.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	reader := bufio.NewReader(r)
+	encoder := json.NewEncoder(w)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			if resp := s.handle(line); resp != nil {
+				if err := encoder.Encode(resp); err != nil {
+					return fmt.Errorf("failed to write response: %w", err)
+				}
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read request: %w", err)
+		}
+	}
+}
+
+// handle answers one message; notifications get no response
+func (s *Server) handle(line []byte) *response {
+	var req request
+	if err := json.Unmarshal(line, &req); err != nil {
+		return &response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{codeParseError, "parse error: " + err.Error()}}
+	}
+	if req.ID == nil {
+		return nil
+	}
+
+	resp := &response{JSONRPC: "2.0", ID: req.ID}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		resp.Error = &rpcError{codeInvalidRequest, "invalid request"}
+		return resp
+	}
+
+	result, err := s.dispatch(req.Method, req.Params)
+	var rpcErr *rpcError
+	switch {
+	case errors.As(err, &rpcErr):
+		resp.Error = rpcErr
+	case err != nil:
+		resp.Error = &rpcError{codeInvalidParams, err.Error()}
+	default:
+		resp.Result = result
+	}
+	return resp
+}
+
+func (s *Server) dispatch(method string, params json.RawMessage) (any, error) {
+	switch method {
+	case "initialize":
+		var p struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		if err := json.Unmarshal(orEmpty(params), &p); err != nil {
+			return nil, fmt.Errorf("invalid initialize params: %w", err)
+		}
+		version := ProtocolVersion
+		if slices.Contains(supportedVersions, p.ProtocolVersion) {
+			version = p.ProtocolVersion
+		}
+		return map[string]any{
+			"protocolVersion": version,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]any{"name": "go-gen", "version": serverVersion()},
+			"instructions":    instructions,
+		}, nil
+	case "ping":
+		return map[string]any{}, nil
+	case "tools/list":
+		return map[string]any{"tools": s.tools}, nil
+	case "tools/call":
+		var p struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(orEmpty(params), &p); err != nil {
+			return nil, fmt.Errorf("invalid tools/call params: %w", err)
+		}
+		return s.call(p.Name, p.Arguments)
+	}
+	return nil, &rpcError{codeMethodNotFound, fmt.Sprintf("method %q not found", method)}
+}
+
+// call runs a tool. Its failures are results flagged isError, carrying the
+// coded error, so the model can see and act on them.
+func (s *Server) call(name string, args json.RawMessage) (any, error) {
+	i := slices.IndexFunc(s.tools, func(t Tool) bool { return t.Name == name })
+	if i < 0 {
+		return nil, fmt.Errorf("unknown tool %q", name)
+	}
+
+	result, err := s.tools[i].call(orEmpty(args))
+	isError := err != nil
+	if isError {
+		result = output.NewErrorInfo(err)
+	}
+	text, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the result of %s: %w", name, err)
+	}
+	return map[string]any{
+		"content":           []map[string]any{{"type": "text", "text": string(text)}},
+		"structuredContent": result,
+		"isError":           isError,
+	}, nil
+}
+
+func orEmpty(raw json.RawMessage) []byte {
+	if len(raw) == 0 || string(raw) == "null" {
+		return []byte("{}")
+	}
+	return raw
+}
+
+func serverVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "devel"
+}
@@ -0,0 +1,109 @@
+package mcp
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/darkphotonKN/go-template-generator/internal/config"
+)
+
+func TestServe(t *testing.T) {
+	cfg, err := config.LoadConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.ProjectsRegistry = filepath.Join(t.TempDir(), "projects.json")
+
+	input := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"validate_names","arguments":{"project_name":"My App","entities":["order","type"]}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"preview_ports","arguments":{"frontend":true}}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"list_projects"}}`,
+		`{"jsonrpc":"2.0","id":6,"method":"tools/call","params":{"name":"create_project","arguments":{"project_name":"shop","colour":"red"}}}`,
+		`{"jsonrpc":"2.0","id":7,"method":"resources/list"}`,
+		`{not json`,
+	}, "\n")
+
+	var out strings.Builder
+	if err := NewServer(Tools(cfg, nil)).Serve(strings.NewReader(input), &out); err != nil {
+		t.Fatalf("Serve failed: %v", err)
+	}
+
+	type toolResult struct {
+		StructuredContent json.RawMessage `json:"structuredContent"`
+		IsError           bool            `json:"isError"`
+	}
+	type message struct {
+		ID     json.RawMessage `json:"id"`
+		Result json.RawMessage `json:"result"`
+		Error  *rpcError       `json:"error"`
+	}
+	responses := make(map[string]message)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 8 {
+		t.Fatalf("Expected 8 responses (none for the notification), got %d:\n%s", len(lines), out.String())
+	}
+	for _, line := range lines {
+		var resp message
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			t.Fatalf("Invalid response %s: %v", line, err)
+		}
+		responses[string(resp.ID)] = resp
+	}
+
+	var initialized struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	json.Unmarshal(responses["1"].Result, &initialized)
+	if initialized.ProtocolVersion != "2025-03-26" {
+		t.Errorf("Expected the client's supported version to be kept, got %q", initialized.ProtocolVersion)
+	}
+
+	var listed struct {
+		Tools []Tool `json:"tools"`
+	}
+	json.Unmarshal(responses["2"].Result, &listed)
+	if len(listed.Tools) != 4 {
+		t.Errorf("Expected 4 tools, got %d", len(listed.Tools))
+	}
+
+	var validated toolResult
+	json.Unmarshal(responses["3"].Result, &validated)
+	var validation Validation
+	json.Unmarshal(validated.StructuredContent, &validation)
+	if validation.Valid || len(validation.Problems) != 2 || validation.Problems[0].Suggestion != "my-app" ||
+		validation.Problems[1].Name != "type" || validation.Problems[1].Code != "invalid_name" {
+		t.Errorf("Expected the project name and entity 'type' rejected, got %s", validated.StructuredContent)
+	}
+
+	var previewed toolResult
+	json.Unmarshal(responses["4"].Result, &previewed)
+	var preview PortPreview
+	json.Unmarshal(previewed.StructuredContent, &preview)
+	if preview.Ports.API == 0 || preview.Ports.Frontend == 0 {
+		t.Errorf("Expected API and frontend ports, got %s", previewed.StructuredContent)
+	}
+
+	var list toolResult
+	json.Unmarshal(responses["5"].Result, &list)
+	if list.IsError || !strings.Contains(string(list.StructuredContent), `"projects":[]`) {
+		t.Errorf("Expected an empty project list, got %s", list.StructuredContent)
+	}
+
+	var created toolResult
+	json.Unmarshal(responses["6"].Result, &created)
+	if !created.IsError || !strings.Contains(string(created.StructuredContent), `"code":"invalid_arguments"`) {
+		t.Errorf("Expected unknown arguments to be a tool error, got %s", created.StructuredContent)
+	}
+
+	if e := responses["7"].Error; e == nil || e.Code != codeMethodNotFound {
+		t.Errorf("Expected method not found, got %+v", e)
+	}
+	if e := responses["null"].Error; e == nil || e.Code != codeParseError {
+		t.Errorf("Expected a parse error, got %+v", e)
+	}
+}
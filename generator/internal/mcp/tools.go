@@ -0,0 +1,319 @@
+package mcp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/darkphotonKN/go-template-generator/internal/config"
+	"github.com/darkphotonKN/go-template-generator/internal/ddd"
+	"github.com/darkphotonKN/go-template-generator/internal/output"
+	"github.com/darkphotonKN/go-template-generator/internal/ports"
+	"github.com/darkphotonKN/go-template-generator/internal/registry"
+	"github.com/darkphotonKN/go-template-generator/internal/schema"
+	"gopkg.in/yaml.v3"
+)
+
+// Validation is the result of validate_names
+type Validation struct {
+	Valid    bool             `json:"valid"`
+	Problems []output.Problem `json:"problems"`
+}
+
+// PortPreview is the result of preview_ports
+type PortPreview struct {
+	Ports      output.Ports `json:"ports"`
+	Randomized bool         `json:"randomized"` // each preview differs; pass the ports to create_project to keep them
+}
+
+type createArgs struct {
+	ProjectName  string          `yaml:"project_name"`
+	Entities     []schema.Entity `yaml:"entities"`
+	Description  string          `yaml:"description"`
+	ModulePrefix string          `yaml:"module_prefix"`
+	Features     struct {
+		Auth     *bool `yaml:"auth"`
+		S3       *bool `yaml:"s3"`
+		Redis    *bool `yaml:"redis"`
+		Frontend *bool `yaml:"frontend"`
+	} `yaml:"features"`
+	Ports     output.Ports `yaml:"ports"`
+	Verify    bool         `yaml:"verify"`
+	Directory string       `yaml:"directory"`
+}
+
+type validateArgs struct {
+	ProjectName string   `yaml:"project_name"`
+	Entities    []string `yaml:"entities"`
+	Directory   string   `yaml:"directory"`
+}
+
+type previewArgs struct {
+	Frontend bool `yaml:"frontend"`
+}
+
+// Tools returns the generator operations served over MCP; nil templates
+// are the ones embedded in the binary
+func Tools(cfg *config.Config, templatesFS fs.FS) []Tool {
+	t := &tools{cfg: cfg, templates: templatesFS}
+	entity := map[string]any{
+		"type":        "object",
+		"description": "An entity as in a go-gen schema file: name plus optional fields ([{name, type, required, nullable, ...}]) and belongs_to, has_many and many_to_many naming other entities",
+		"properties": map[string]any{
+			"name":         map[string]any{"type": "string"},
+			"fields":       map[string]any{"type": "array", "items": map[string]any{"type": "object"}},
+			"belongs_to":   map[string]any{"type": "array"},
+			"has_many":     map[string]any{"type": "array"},
+			"many_to_many": map[string]any{"type": "array"},
+		},
+		"required": []string{"name"},
+	}
+	directory := map[string]any{"type": "string", "description": "Directory the project is created in; the server's working directory by default"}
+
+	return []Tool{
+		{
+			Name:        "create_project",
+			Title:       "Create project",
+			Description: "Generate a DDD API project (optionally with a Next.js frontend), initialise git and register it. Returns its path, module, ports and next steps.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"project_name":  map[string]any{"type": "string", "description": "Lowercase letters, digits and hyphens"},
+					"entities":      map[string]any{"type": "array", "items": entity, "description": "The primary entity first; the config's default entity when empty"},
+					"description":   map[string]any{"type": "string"},
+					"module_prefix": map[string]any{"type": "string", "description": "e.g. github.com/acme/; the config's when empty"},
+					"features": map[string]any{
+						"type":        "object",
+						"description": "Unset features follow the config",
+						"properties": map[string]any{
+							"auth":     map[string]any{"type": "boolean"},
+							"s3":       map[string]any{"type": "boolean"},
+							"redis":    map[string]any{"type": "boolean"},
+							"frontend": map[string]any{"type": "boolean"},
+						},
+					},
+					"ports":     portsSchema("Unset ports are allocated"),
+					"verify":    map[string]any{"type": "boolean", "description": "Build, vet and scan the project for template leftovers before registering it; failures carry file and line"},
+					"directory": directory,
+				},
+				"required": []string{"project_name"},
+			},
+			call: t.createProject,
+		},
+		{
+			Name:        "list_projects",
+			Title:       "List projects",
+			Description: "List the generated projects in the registry with their paths, entities and ports.",
+			InputSchema: map[string]any{"type": "object", "properties": map[string]any{}},
+			call:        t.listProjects,
+		},
+		{
+			Name:        "preview_ports",
+			Title:       "Preview ports",
+			Description: "Return the ports the next project would be given, without writing anything. When randomization is on each call differs; pass the ports to create_project to keep them.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"frontend": map[string]any{"type": "boolean", "description": "Include a port for the Next.js frontend"},
+				},
+			},
+			call: t.previewPorts,
+		},
+		{
+			Name:        "validate_names",
+			Title:       "Validate names",
+			Description: "Check a project name and entity names as create_project would, including whether the project already exists. Returns every problem found.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"project_name": map[string]any{"type": "string"},
+					"entities":     map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "The primary entity first"},
+					"directory":    directory,
+				},
+			},
+			call: t.validateNames,
+		},
+	}
+}
+
+func portsSchema(description string) map[string]any {
+	port := map[string]any{"type": "integer", "minimum": 1, "maximum": 65535}
+	return map[string]any{
+		"type":        "object",
+		"description": description,
+		"properties":  map[string]any{"api": port, "db": port, "redis": port, "frontend": port},
+	}
+}
+
+type tools struct {
+	cfg       *config.Config
+	templates fs.FS
+}
+
+func (t *tools) createProject(data []byte) (any, error) {
+	var args createArgs
+	if err := decode(data, &args); err != nil {
+		return nil, err
+	}
+	if args.ProjectName == "" {
+		return nil, output.Errorf(output.CodeInvalidArguments, "project_name is required")
+	}
+
+	domain := &schema.Schema{Entities: args.Entities}
+	if len(domain.Entities) == 0 {
+		domain.Entities = []schema.Entity{{Name: t.cfg.Defaults.PrimaryEntity}}
+	}
+	if err := errors.Join(
+		ddd.CheckProjectName(t.templates, args.ProjectName),
+		ddd.CheckEntities(t.templates, domain.EntityNames()),
+	); err != nil {
+		return nil, output.Wrap(output.CodeInvalidName, err)
+	}
+	if err := domain.Validate(); err != nil {
+		return nil, output.Wrap(output.CodeSchema, err)
+	}
+
+	// Options are per call; the config is shared
+	cfg := *t.cfg
+	if args.ModulePrefix != "" {
+		cfg.Defaults.ModulePrefix = args.ModulePrefix
+	}
+	feature := func(set *bool, def bool) bool {
+		if set != nil {
+			return *set
+		}
+		return def
+	}
+	opts := &ddd.GeneratorOptions{
+		ProjectName:        args.ProjectName,
+		Entities:           domain.EntityNames(),
+		Fields:             domain.Fields(),
+		Relations:          domain.Relations(),
+		IncludeAuth:        feature(args.Features.Auth, cfg.Features.Auth.Enabled),
+		IncludeS3:          feature(args.Features.S3, cfg.Features.S3.Enabled),
+		IncludeRedis:       feature(args.Features.Redis, cfg.Features.Redis.Enabled),
+		IncludeFrontend:    feature(args.Features.Frontend, cfg.Features.Frontend.Enabled),
+		ProjectDescription: args.Description,
+		Config:             &cfg,
+		APIPort:            args.Ports.API,
+		DBPort:             args.Ports.DB,
+		RedisPort:          args.Ports.Redis,
+		FrontendPort:       args.Ports.Frontend,
+		Verify:             args.Verify,
+		Templates:          t.templates,
+	}
+
+	// The generator writes relative to the working directory
+	if args.Directory != "" {
+		restore, err := chdir(args.Directory)
+		if err != nil {
+			return nil, err
+		}
+		defer restore()
+	}
+
+	generator := ddd.NewGenerator(opts)
+	vars, err := generator.Preview()
+	if err != nil {
+		return nil, err
+	}
+	if err := generator.Generate(); err != nil {
+		return nil, output.GenerationError(err)
+	}
+	return output.NewCreate(opts, vars, generator.Warnings())
+}
+
+func (t *tools) listProjects(data []byte) (any, error) {
+	if err := decode(data, &struct{}{}); err != nil {
+		return nil, err
+	}
+	projects, err := registry.NewManager(t.cfg.ProjectsRegistry).List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+	return output.NewList(projects), nil
+}
+
+func (t *tools) previewPorts(data []byte) (any, error) {
+	var args previewArgs
+	if err := decode(data, &args); err != nil {
+		return nil, err
+	}
+
+	index, err := registry.NewManager(t.cfg.ProjectsRegistry).GetNextIndex()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get next project index: %w", err)
+	}
+	allocated := ports.NewManager(t.cfg).AllocatePorts(index)
+	preview := &PortPreview{
+		Ports:      output.Ports{API: allocated.API, DB: allocated.DB, Redis: allocated.Redis},
+		Randomized: t.cfg.Ports.Randomization.Enabled && t.cfg.Ports.Randomization.Range > 0,
+	}
+	if args.Frontend {
+		preview.Ports.Frontend = allocated.Frontend
+	}
+	return preview, nil
+}
+
+func (t *tools) validateNames(data []byte) (any, error) {
+	var args validateArgs
+	if err := decode(data, &args); err != nil {
+		return nil, err
+	}
+	if args.ProjectName == "" && len(args.Entities) == 0 {
+		return nil, output.Errorf(output.CodeInvalidArguments, "give project_name, entities or both")
+	}
+
+	result := &Validation{Problems: []output.Problem{}}
+	exists := func(name, message string) {
+		result.Problems = append(result.Problems, output.Problem{Kind: "project", Name: name, Code: output.CodeAlreadyExists, Message: message})
+	}
+
+	if name := args.ProjectName; name != "" {
+		result.Problems = append(result.Problems, output.NameProblems(ddd.CheckProjectName(t.templates, name), "project", name)...)
+		if _, err := os.Stat(filepath.Join(args.Directory, name)); err == nil {
+			exists(name, fmt.Sprintf("directory '%s' %v", name, ddd.ErrExists))
+		}
+		registered, err := registry.NewManager(t.cfg.ProjectsRegistry).ProjectExists(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check projects: %w", err)
+		}
+		if registered {
+			exists(name, fmt.Sprintf("project '%s' %v", name, ddd.ErrExists))
+		}
+	}
+
+	if len(args.Entities) > 0 {
+		result.Problems = append(result.Problems, output.NameProblems(ddd.CheckEntities(t.templates, args.Entities), "entity", args.Entities...)...)
+	}
+
+	result.Valid = len(result.Problems) == 0
+	return result, nil
+}
+
+// decode reads tool arguments like a schema file: JSON as YAML, so
+// relations can name their entity directly, with unknown keys rejected
+func decode(data []byte, args any) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(args); err != nil && !errors.Is(err, io.EOF) {
+		return output.Wrap(output.CodeInvalidArguments, fmt.Errorf("invalid arguments: %w", err))
+	}
+	return nil
+}
+
+// chdir changes the working directory, returning how to change it back
+func chdir(dir string) (func(), error) {
+	previous, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		return nil, output.Wrap(output.CodeInvalidArguments, fmt.Errorf("failed to enter directory: %w", err))
+	}
+	return func() { os.Chdir(previous) }, nil
+}
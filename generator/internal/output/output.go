@@ -0,0 +1,192 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Formats of --output. Text is for people; JSON and YAML print one document
+// per run on stdout while progress goes to stderr.
+const (
+	Text = "text"
+	JSON = "json"
+	YAML = "yaml"
+)
+
+// Formats lists every format --output accepts
+var Formats = []string{Text, JSON, YAML}
+
+// Error codes. They are stable: scripts branch on them, so existing codes
+// are never renamed or reused.
+const (
+	CodeInvalidArguments   = "invalid_arguments"   // flags, arguments or an answers file
+	CodeConfig             = "config_error"        // config.yaml can't be loaded
+	CodeInvalidName        = "invalid_name"        // a project or entity name is rejected
+	CodeAlreadyExists      = "already_exists"      // the project or its directory exists
+	CodeProjectNotFound    = "project_not_found"   // no generated project at --dir
+	CodeSchema             = "schema_error"        // a schema, SQL or OpenAPI file is invalid
+	CodeHookFailed         = "hook_failed"         // a hook aborted the generation
+	CodeUncommittedChanges = "uncommitted_changes" // the project must be committed first
+	CodeCancelled          = "cancelled"           // the confirmation was declined
+	CodeVerifyFailed       = "verification_failed" // the project doesn't build, vet or render cleanly
+	CodeSnapshotMismatch   = "snapshot_mismatch"   // a template renders differently from its snapshot
+	CodeLintFailed         = "lint_failed"         // the template linter found problems
+	CodeFailed             = "failed"              // anything else
+)
+
+// Error is a failure with a stable code
+type Error struct {
+	Code string
+	Err  error
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Wrap gives err a code; nil stays nil and errors that already have a code
+// keep it
+func Wrap(code string, err error) error {
+	var coded *Error
+	if err == nil || errors.As(err, &coded) {
+		return err
+	}
+	return &Error{Code: code, Err: err}
+}
+
+// Errorf formats an error with a code
+func Errorf(code, format string, args ...any) error {
+	return &Error{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+// Code returns the code of err, CodeFailed when it has none
+func Code(err error) string {
+	var coded *Error
+	if errors.As(err, &coded) {
+		return coded.Code
+	}
+	return CodeFailed
+}
+
+// Result is what a command reports when it succeeds
+type Result interface {
+	// Text writes the result for people
+	Text(w io.Writer)
+}
+
+// document is what JSON and YAML output print: the result or the error
+type document struct {
+	OK     bool       `json:"ok" yaml:"ok"`
+	Result Result     `json:"result,omitempty" yaml:"result,omitempty"`
+	Error  *ErrorInfo `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// Problem is one rejected name, as the MCP validate_names tool reports it
+type Problem struct {
+	Kind       string `json:"kind" yaml:"kind"` // project or entity
+	Name       string `json:"name" yaml:"name"`
+	Code       string `json:"code" yaml:"code"`
+	Message    string `json:"message" yaml:"message"`
+	Suggestion string `json:"suggestion,omitempty" yaml:"suggestion,omitempty"` // a name that would pass
+}
+
+// NamesError is rejected names with one problem per name, which programs
+// get instead of the lines of Err
+type NamesError struct {
+	Err      error
+	Problems []Problem
+}
+
+func (e *NamesError) Error() string { return e.Err.Error() }
+func (e *NamesError) Unwrap() error { return e.Err }
+
+// ErrorInfo is how an error is reported to programs
+type ErrorInfo struct {
+	Code     string    `json:"code" yaml:"code"`
+	Message  string    `json:"message" yaml:"message"`
+	Details  []string  `json:"details,omitempty" yaml:"details,omitempty"`   // one per problem when several were found
+	Problems []Problem `json:"problems,omitempty" yaml:"problems,omitempty"` // one per rejected name
+}
+
+// NewErrorInfo describes err with its code. Errors of several lines, as
+// when every problem found is reported, list the problems as details;
+// rejected names are listed as problems instead.
+func NewErrorInfo(err error) *ErrorInfo {
+	var namesErr *NamesError
+	if errors.As(err, &namesErr) {
+		return &ErrorInfo{Code: Code(err), Message: fmt.Sprintf("%d invalid name(s)", len(namesErr.Problems)), Problems: namesErr.Problems}
+	}
+
+	info := &ErrorInfo{Code: Code(err), Message: err.Error()}
+	if lines := strings.Split(err.Error(), "\n"); len(lines) > 1 {
+		info.Message = strings.TrimSuffix(lines[0], ":")
+		for _, line := range lines[1:] {
+			if line = strings.TrimSpace(line); line != "" {
+				info.Details = append(info.Details, line)
+			}
+		}
+	}
+	return info
+}
+
+// Printer writes results and errors in one format
+type Printer struct {
+	format string
+	out    io.Writer // results, and errors in JSON and YAML
+	errOut io.Writer // errors in text
+}
+
+// NewPrinter returns a printer for format, one of Formats
+func NewPrinter(format string, out, errOut io.Writer) (*Printer, error) {
+	if !slices.Contains(Formats, format) {
+		return nil, Errorf(CodeInvalidArguments, "unknown output format %q (use %s)", format, strings.Join(Formats, ", "))
+	}
+	return &Printer{format: format, out: out, errOut: errOut}, nil
+}
+
+// Structured reports whether output is for programs rather than people
+func (p *Printer) Structured() bool {
+	return p.format != Text
+}
+
+// Result prints the result of a successful command
+func (p *Printer) Result(result Result) error {
+	if !p.Structured() {
+		result.Text(p.out)
+		return nil
+	}
+	return p.print(document{OK: true, Result: result})
+}
+
+// Error prints a failure with its code
+func (p *Printer) Error(err error) error {
+	if !p.Structured() {
+		_, werr := fmt.Fprintf(p.errOut, "Error: %v\n", err)
+		return werr
+	}
+	return p.print(document{Error: NewErrorInfo(err)})
+}
+
+func (p *Printer) print(doc document) error {
+	if p.format == JSON {
+		encoder := json.NewEncoder(p.out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(doc); err != nil {
+			return fmt.Errorf("failed to write JSON output: %w", err)
+		}
+		return nil
+	}
+
+	encoder := yaml.NewEncoder(p.out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write YAML output: %w", err)
+	}
+	return encoder.Close()
+}
@@ -0,0 +1,122 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/darkphotonKN/go-template-generator/internal/ddd"
+	"gopkg.in/yaml.v3"
+)
+
+func TestPrinterResult(t *testing.T) {
+	result := &AddEntity{Entity: "order", Path: "/src/shop", NextSteps: []string{"make migrate-up"}}
+
+	var out strings.Builder
+	p, err := NewPrinter(JSON, &out, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Result(result); err != nil {
+		t.Fatalf("Result failed: %v", err)
+	}
+	var doc struct {
+		OK     bool      `json:"ok"`
+		Result AddEntity `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(out.String()), &doc); err != nil {
+		t.Fatalf("Expected JSON, got %q: %v", out.String(), err)
+	}
+	if !doc.OK || doc.Result.Entity != "order" || doc.Result.NextSteps[0] != "make migrate-up" {
+		t.Errorf("Unexpected document: %s", out.String())
+	}
+
+	out.Reset()
+	p, _ = NewPrinter(Text, &out, nil)
+	if err := p.Result(result); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Entity 'order' added") {
+		t.Errorf("Expected text output, got %q", out.String())
+	}
+}
+
+func TestPrinterError(t *testing.T) {
+	err := fmt.Errorf("invalid answers a.yaml:\n%w", errors.Join(errors.New("project_name is required"), errors.New("ports.api 70000 is out of range")))
+	err = Wrap(CodeInvalidArguments, err)
+
+	var out strings.Builder
+	p, _ := NewPrinter(YAML, &out, nil)
+	if err := p.Error(Wrap(CodeFailed, err)); err != nil {
+		t.Fatalf("Error failed: %v", err)
+	}
+
+	var doc struct {
+		OK    bool      `yaml:"ok"`
+		Error ErrorInfo `yaml:"error"`
+	}
+	if err := yaml.Unmarshal([]byte(out.String()), &doc); err != nil {
+		t.Fatalf("Expected YAML, got %q: %v", out.String(), err)
+	}
+	want := ErrorInfo{
+		Code:    CodeInvalidArguments,
+		Message: "invalid answers a.yaml",
+		Details: []string{"project_name is required", "ports.api 70000 is out of range"},
+	}
+	if doc.OK || doc.Error.Code != want.Code || doc.Error.Message != want.Message || strings.Join(doc.Error.Details, "|") != strings.Join(want.Details, "|") {
+		t.Errorf("error = %+v, want %+v", doc.Error, want)
+	}
+
+	var errOut strings.Builder
+	p, _ = NewPrinter(Text, &out, &errOut)
+	p.Error(errors.New("boom"))
+	if errOut.String() != "Error: boom\n" {
+		t.Errorf("Expected the text error on stderr, got %q", errOut.String())
+	}
+	if Code(errors.New("boom")) != CodeFailed {
+		t.Errorf("Expected uncoded errors to be %s", CodeFailed)
+	}
+}
+
+func TestNewPrinterRejectsUnknownFormat(t *testing.T) {
+	_, err := NewPrinter("xml", nil, nil)
+	if Code(err) != CodeInvalidArguments {
+		t.Errorf("Expected %s, got %v", CodeInvalidArguments, err)
+	}
+}
+
+func TestNameProblems(t *testing.T) {
+	entities := []string{"type", "order_item", "orderitem"}
+	projectErr := ddd.CheckProjectName(nil, "My App")
+	entitiesErr := ddd.CheckEntities(nil, entities)
+	problems := append(NameProblems(projectErr, "project", "My App"), NameProblems(entitiesErr, "entity", entities...)...)
+	err := Wrap(CodeInvalidName, &NamesError{Err: errors.Join(projectErr, entitiesErr), Problems: problems})
+
+	var out strings.Builder
+	p, _ := NewPrinter(JSON, &out, nil)
+	if err := p.Error(err); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Error ErrorInfo `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(out.String()), &doc); err != nil {
+		t.Fatalf("Expected JSON, got %q: %v", out.String(), err)
+	}
+	want := []Problem{
+		{Kind: "project", Name: "My App", Code: CodeInvalidName, Suggestion: "my-app",
+			Message: "contains spaces, which Go module paths and Docker container names don't allow; has upper-case letters, which Docker Compose project names don't allow"},
+		{Kind: "entity", Name: "type", Code: CodeInvalidName, Suggestion: "category", Message: "the Go package 'type' would be a keyword"},
+		{Kind: "entity", Name: "type, order_item, orderitem", Code: CodeInvalidName, Message: "entities 'order_item' and 'orderitem' would both use package 'orderitem'"},
+	}
+	if doc.Error.Code != CodeInvalidName || len(doc.Error.Details) > 0 || !reflect.DeepEqual(doc.Error.Problems, want) {
+		t.Errorf("error = %+v,\nwant problems %+v", doc.Error, want)
+	}
+
+	if got := NameProblems(nil, "project", "shop"); len(got) != 0 {
+		t.Errorf("NameProblems(nil) = %+v", got)
+	}
+}
@@ -0,0 +1,318 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/darkphotonKN/go-template-generator/internal/ddd"
+	"github.com/darkphotonKN/go-template-generator/internal/hooks"
+	"github.com/darkphotonKN/go-template-generator/internal/manifest"
+	"github.com/darkphotonKN/go-template-generator/internal/registry"
+)
+
+type Features struct {
+	Auth     bool `json:"auth" yaml:"auth"`
+	S3       bool `json:"s3" yaml:"s3"`
+	Redis    bool `json:"redis" yaml:"redis"`
+	Frontend bool `json:"frontend" yaml:"frontend"`
+}
+
+type Ports struct {
+	API      int `json:"api" yaml:"api"`
+	DB       int `json:"db" yaml:"db"`
+	Redis    int `json:"redis" yaml:"redis"`
+	Frontend int `json:"frontend,omitempty" yaml:"frontend,omitempty"`
+}
+
+// Create is a project create generated
+type Create struct {
+	Project     string   `json:"project" yaml:"project"`
+	Path        string   `json:"path" yaml:"path"`                                   // absolute path of the API project
+	ClientPath  string   `json:"client_path,omitempty" yaml:"client_path,omitempty"` // of the Next.js app
+	Module      string   `json:"module" yaml:"module"`
+	Entities    []string `json:"entities" yaml:"entities"` // the primary entity first
+	Features    Features `json:"features" yaml:"features"`
+	Ports       Ports    `json:"ports" yaml:"ports"`
+	APIURL      string   `json:"api_url" yaml:"api_url"`
+	FrontendURL string   `json:"frontend_url,omitempty" yaml:"frontend_url,omitempty"`
+	AnswersFile string   `json:"answers_file,omitempty" yaml:"answers_file,omitempty"` // --save-answers
+
+	// Commands to run from where create ran, for the API and the client
+	NextSteps       []string `json:"next_steps" yaml:"next_steps"`
+	ClientNextSteps []string `json:"client_next_steps,omitempty" yaml:"client_next_steps,omitempty"`
+
+	Warnings []string `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+}
+
+// NewCreate describes the project a generation with opts wrote
+func NewCreate(opts *ddd.GeneratorOptions, vars manifest.Vars, warnings []string) (*Create, error) {
+	name := opts.ProjectName
+	dir := name
+	if opts.IncludeFrontend {
+		dir = filepath.Join(name, name+"-server")
+	}
+	path, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Create{
+		Project:  name,
+		Path:     path,
+		Module:   vars.String("ModuleName"),
+		Entities: opts.Entities,
+		Features: Features{Auth: opts.IncludeAuth, S3: opts.IncludeS3, Redis: opts.IncludeRedis, Frontend: opts.IncludeFrontend},
+		Ports:    Ports{API: opts.APIPort, DB: opts.DBPort, Redis: opts.RedisPort, Frontend: opts.FrontendPort},
+		APIURL:   fmt.Sprintf("http://localhost:%d", opts.APIPort),
+		NextSteps: []string{
+			"cd " + filepath.ToSlash(dir),
+			"cp .env.example .env",
+			"make docker-up",
+			"make migrate-up",
+			"make dev",
+		},
+		Warnings: warnings,
+	}
+	if opts.IncludeFrontend {
+		clientDir := filepath.Join(name, name+"-client")
+		if result.ClientPath, err = filepath.Abs(clientDir); err != nil {
+			return nil, err
+		}
+		result.FrontendURL = fmt.Sprintf("http://localhost:%d", opts.FrontendPort)
+		result.ClientNextSteps = []string{
+			"cd " + filepath.ToSlash(clientDir),
+			"cp .env.example .env.local",
+			"npm install",
+			"npm run dev",
+		}
+	}
+	return result, nil
+}
+
+// GenerationError codes a failed generation
+func GenerationError(err error) error {
+	var verifyErr *ddd.VerifyError
+	switch {
+	case errors.As(err, &verifyErr):
+		return Wrap(CodeVerifyFailed, err)
+	case errors.Is(err, ddd.ErrExists):
+		return Wrap(CodeAlreadyExists, err)
+	case errors.Is(err, hooks.ErrAborted):
+		return Wrap(CodeHookFailed, err)
+	}
+	return fmt.Errorf("failed to generate project: %w", err)
+}
+
+// NameProblems lists what a check of names of kind (project or entity)
+// found, one problem per name with everything wrong with it. Problems that
+// concern no single name, as two entities sharing a package, are reported
+// under the names joined.
+func NameProblems(err error, kind string, names ...string) []Problem {
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+
+	var problems []Problem
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		problem := Problem{Kind: kind, Name: strings.Join(names, ", "), Code: CodeInvalidName, Message: err.Error()}
+		var nameErr *ddd.NameError
+		if errors.As(err, &nameErr) {
+			problem.Name = nameErr.Name
+			problem.Message = strings.Join(nameErr.Problems, "; ")
+			problem.Suggestion = nameErr.Suggestion
+		}
+		problems = append(problems, problem)
+	}
+	return problems
+}
+
+func (r *Create) Text(w io.Writer) {
+	fmt.Fprintf(w, "\n✅ Project '%s' created successfully!\n\n", r.Project)
+	fmt.Fprintf(w, "Next steps:\n")
+	for _, step := range r.NextSteps {
+		fmt.Fprintf(w, "  %s\n", step)
+	}
+	fmt.Fprintln(w)
+	if len(r.ClientNextSteps) > 0 {
+		fmt.Fprintf(w, "In another terminal:\n")
+		for _, step := range r.ClientNextSteps {
+			fmt.Fprintf(w, "  %s\n", step)
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "Your API will be running at %s\n", r.APIURL)
+	if r.FrontendURL != "" {
+		fmt.Fprintf(w, "Your frontend will be running at %s\n", r.FrontendURL)
+	}
+}
+
+// SchemaChanges is what a schema would change in a project that exists;
+// create reports it instead of writing anything
+type SchemaChanges struct {
+	Project string   `json:"project" yaml:"project"`
+	Source  string   `json:"source" yaml:"source"` // the schema given
+	Stored  string   `json:"stored" yaml:"stored"` // the schema the project was generated from
+	Changes []string `json:"changes" yaml:"changes"`
+}
+
+func (r *SchemaChanges) Text(w io.Writer) {
+	if len(r.Changes) == 0 {
+		fmt.Fprintf(w, "✅ Project '%s' already matches %s\n", r.Project, r.Source)
+		return
+	}
+
+	fmt.Fprintf(w, "📋 Project '%s' already exists. Changes from %s to %s:\n\n", r.Project, r.Stored, r.Source)
+	for _, change := range r.Changes {
+		fmt.Fprintf(w, "  %s\n", change)
+	}
+	fmt.Fprintf(w, "\nNothing was written. Add new entities with 'go-gen add entity NAME --fields ...';\n")
+	fmt.Fprintf(w, "changes to existing tables need a new migration.\n")
+}
+
+// List is every registered project
+type List struct {
+	Projects []Project `json:"projects" yaml:"projects"`
+}
+
+type Project struct {
+	Name            string    `json:"name" yaml:"name"`
+	Path            string    `json:"path,omitempty" yaml:"path,omitempty"`
+	Entities        []string  `json:"entities" yaml:"entities"`
+	Ports           Ports     `json:"ports" yaml:"ports"`
+	TemplateVersion string    `json:"template_version,omitempty" yaml:"template_version,omitempty"`
+	CreatedAt       time.Time `json:"created_at" yaml:"created_at"`
+}
+
+// NewList lists the registered projects
+func NewList(projects []registry.Project) *List {
+	list := &List{Projects: []Project{}}
+	for _, p := range projects {
+		list.Projects = append(list.Projects, Project{
+			Name:            p.Name,
+			Path:            p.Path,
+			Entities:        p.EntityList(),
+			Ports:           Ports{API: p.APIPort, DB: p.DBPort, Redis: p.RedisPort, Frontend: p.FrontendPort},
+			TemplateVersion: p.TemplateVersion,
+			CreatedAt:       p.CreatedAt,
+		})
+	}
+	return list
+}
+
+func (r *List) Text(w io.Writer) {
+	if len(r.Projects) == 0 {
+		fmt.Fprintln(w, "No projects generated yet.")
+		return
+	}
+
+	fmt.Fprintln(w, "Generated projects:")
+	fmt.Fprintln(w, "==================")
+	for _, p := range r.Projects {
+		frontend := ""
+		if p.Ports.Frontend != 0 {
+			frontend = fmt.Sprintf(", Frontend: %d", p.Ports.Frontend)
+		}
+		fmt.Fprintf(w, "  %s - API: %d, DB: %d, Redis: %d%s (created: %s)\n",
+			p.Name, p.Ports.API, p.Ports.DB, p.Ports.Redis, frontend, p.CreatedAt.Format("2006-01-02"))
+	}
+}
+
+// AddEntity is an entity added to a project
+type AddEntity struct {
+	Entity    string   `json:"entity" yaml:"entity"`
+	Path      string   `json:"path" yaml:"path"` // absolute path of the project given with --dir
+	NextSteps []string `json:"next_steps" yaml:"next_steps"`
+}
+
+func (r *AddEntity) Text(w io.Writer) {
+	fmt.Fprintf(w, "\n✅ Entity '%s' added!\n\n", r.Entity)
+	fmt.Fprintf(w, "Next steps:\n")
+	for _, step := range r.NextSteps {
+		fmt.Fprintf(w, "  %s\n", step)
+	}
+}
+
+// Upgrade is what an upgrade merged into a project
+type Upgrade struct {
+	UpToDate  bool     `json:"up_to_date" yaml:"up_to_date"`
+	Branch    string   `json:"branch,omitempty" yaml:"branch,omitempty"`
+	From      string   `json:"from,omitempty" yaml:"from,omitempty"` // template versions
+	To        string   `json:"to,omitempty" yaml:"to,omitempty"`
+	Updated   []string `json:"updated,omitempty" yaml:"updated,omitempty"`
+	Added     []string `json:"added,omitempty" yaml:"added,omitempty"`
+	Removed   []string `json:"removed,omitempty" yaml:"removed,omitempty"`
+	Conflicts []string `json:"conflicts,omitempty" yaml:"conflicts,omitempty"` // left with conflict markers
+	Skipped   []string `json:"skipped,omitempty" yaml:"skipped,omitempty"`     // not applied, with the reason
+	Warnings  []string `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+}
+
+func (r *Upgrade) Text(w io.Writer) {
+	if r.UpToDate {
+		fmt.Fprintf(w, "\n✅ Project is up to date with the templates\n")
+		return
+	}
+
+	fmt.Fprintf(w, "\n✅ Upgrade committed on branch '%s'\n\n", r.Branch)
+	for _, group := range []struct {
+		title string
+		files []string
+	}{
+		{"Updated", r.Updated},
+		{"Added", r.Added},
+		{"Removed", r.Removed},
+		{"Conflicts (resolve the markers, then commit)", r.Conflicts},
+		{"Not applied", r.Skipped},
+	} {
+		if len(group.files) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s:\n", group.title)
+		for _, file := range group.files {
+			fmt.Fprintf(w, "  %s\n", file)
+		}
+	}
+	fmt.Fprintf(w, "\nReview with 'git diff HEAD~1', then merge the branch.\n")
+}
+
+// Verify is a project that passed every check
+type Verify struct {
+	Path   string   `json:"path" yaml:"path"`
+	Checks []string `json:"checks" yaml:"checks"`
+}
+
+func (r *Verify) Text(w io.Writer) {
+	fmt.Fprintf(w, "\n✅ Project verified: %s\n", strings.Join(r.Checks, ", "))
+}
+
+// TemplateTest is the fixtures whose renders matched their snapshots, or
+// whose snapshots were rewritten
+type TemplateTest struct {
+	Fixtures []string `json:"fixtures" yaml:"fixtures"`
+	Updated  bool     `json:"updated" yaml:"updated"`
+}
+
+func (r *TemplateTest) Text(w io.Writer) {
+	if r.Updated {
+		fmt.Fprintf(w, "\n✅ Snapshots updated: %s\n", strings.Join(r.Fixtures, ", "))
+		return
+	}
+	fmt.Fprintf(w, "\n✅ Templates match their snapshots: %s\n", strings.Join(r.Fixtures, ", "))
+}
+
+// TemplateLint is the templates the linter found no problems in
+type TemplateLint struct {
+	Templates []string `json:"templates" yaml:"templates"`
+	Checks    []string `json:"checks" yaml:"checks"`
+}
+
+func (r *TemplateLint) Text(w io.Writer) {
+	fmt.Fprintf(w, "\n✅ Templates lint clean: %s (%s)\n", strings.Join(r.Templates, ", "), strings.Join(r.Checks, ", "))
+}
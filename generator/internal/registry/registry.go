@@ -5,17 +5,31 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
+
+	"github.com/darkphotonKN/go-template-generator/internal/schema"
 )
 
 type Project struct {
-	Name      string    `json:"name"`
-	Index     int       `json:"index"`
-	APIPort   int       `json:"api_port"`
-	DBPort    int       `json:"db_port"`
-	RedisPort int       `json:"redis_port"`
-	Entity    string    `json:"entity"`
-	CreatedAt time.Time `json:"created_at"`
+	Name         string    `json:"name"`
+	Index        int       `json:"index"`
+	APIPort      int       `json:"api_port"`
+	DBPort       int       `json:"db_port"`
+	RedisPort    int       `json:"redis_port"`
+	FrontendPort int       `json:"frontend_port,omitempty"` // 0 for backend-only projects
+	Entity       string    `json:"entity"`                  // primary entity
+	Entities     []string  `json:"entities,omitempty"`      // every entity, primary first
+	Path         string    `json:"path,omitempty"`          // absolute path of the API project
+	CreatedAt    time.Time `json:"created_at"`
+
+	// What the project was rendered from, for upgrades; empty for projects
+	// registered before it was recorded
+	Template        string                    `json:"template,omitempty"`
+	TemplateVersion string                    `json:"template_version,omitempty"`
+	Description     string                    `json:"description,omitempty"`
+	Fields          map[string][]schema.Field `json:"fields,omitempty"`
+	Relations       []schema.Relation         `json:"relations,omitempty"`
 }
 
 type Registry struct {
@@ -72,28 +86,25 @@ func (m *Manager) Save(registry *Registry) error {
 	return nil
 }
 
-func (m *Manager) AddProject(name, entity string, apiPort, dbPort, redisPort int) error {
+// AddProject registers a new project, assigning its index and creation time
+func (m *Manager) AddProject(project Project) error {
 	registry, err := m.Load()
 	if err != nil {
 		return err
 	}
 
 	// Check if project already exists
-	for _, project := range registry.Projects {
-		if project.Name == name {
-			return fmt.Errorf("project '%s' already exists", name)
+	for _, existing := range registry.Projects {
+		if existing.Name == project.Name {
+			return fmt.Errorf("project '%s' already exists", project.Name)
 		}
 	}
 
 	// Add new project
-	project := Project{
-		Name:      name,
-		Index:     registry.NextIndex,
-		APIPort:   apiPort,
-		DBPort:    dbPort,
-		RedisPort: redisPort,
-		Entity:    entity,
-		CreatedAt: time.Now(),
+	project.Index = registry.NextIndex
+	project.CreatedAt = time.Now()
+	if len(project.Entities) > 0 {
+		project.Entity = project.Entities[0]
 	}
 
 	registry.Projects = append(registry.Projects, project)
@@ -130,4 +141,88 @@ func (m *Manager) ProjectExists(name string) (bool, error) {
 		}
 	}
 	return false, nil
-}
\ No newline at end of file
+}
+
+// Get returns the project registered under name
+func (m *Manager) Get(name string) (*Project, error) {
+	registry, err := m.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range registry.Projects {
+		if registry.Projects[i].Name == name {
+			return &registry.Projects[i], nil
+		}
+	}
+	return nil, fmt.Errorf("project '%s' is not registered", name)
+}
+
+// Find returns the project registered at path, falling back to the project
+// named like the directory (projects registered before paths were recorded)
+func (m *Manager) Find(path string) (*Project, error) {
+	registry, err := m.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range registry.Projects {
+		if registry.Projects[i].Path == path {
+			return &registry.Projects[i], nil
+		}
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), "-server")
+	for i := range registry.Projects {
+		if registry.Projects[i].Path == "" && registry.Projects[i].Name == name {
+			return &registry.Projects[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("no registered project at %s", path)
+}
+
+// AddEntity records an entity added to an existing project
+func (m *Manager) AddEntity(name, entity string) error {
+	registry, err := m.Load()
+	if err != nil {
+		return err
+	}
+
+	for i := range registry.Projects {
+		project := &registry.Projects[i]
+		if project.Name != name {
+			continue
+		}
+		project.Entities = append(project.EntityList(), entity)
+		return m.Save(registry)
+	}
+
+	return fmt.Errorf("project '%s' is not registered", name)
+}
+
+// Update applies fn to the project registered under name and saves it
+func (m *Manager) Update(name string, fn func(*Project)) error {
+	registry, err := m.Load()
+	if err != nil {
+		return err
+	}
+
+	for i := range registry.Projects {
+		if registry.Projects[i].Name == name {
+			fn(&registry.Projects[i])
+			return m.Save(registry)
+		}
+	}
+
+	return fmt.Errorf("project '%s' is not registered", name)
+}
+
+// EntityList returns every entity of a project, including projects
+// registered before multiple entities were tracked
+func (p *Project) EntityList() []string {
+	if len(p.Entities) > 0 {
+		return p.Entities
+	}
+	return []string{p.Entity}
+}
@@ -0,0 +1,67 @@
+package registry
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	m := NewManager(filepath.Join(t.TempDir(), "projects.json"))
+	for _, project := range []Project{
+		{Name: "shop", Path: "/work/shop"},
+		{Name: "blog"}, // registered before paths were recorded
+		{Name: "notes", Path: "/work/notes/notes-server"},
+	} {
+		if err := m.AddProject(project); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := map[string]struct {
+		path string
+		want string // "" when no project should be found
+	}{
+		"by path":                 {"/work/shop", "shop"},
+		"by path of a server":     {"/work/notes/notes-server", "notes"},
+		"by name without a path":  {"/elsewhere/blog", "blog"},
+		"by name of a server":     {"/elsewhere/blog-server", "blog"},
+		"not by name with a path": {"/elsewhere/shop", ""},
+		"not registered":          {"/work/other", ""},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			project, err := m.Find(tt.path)
+			switch {
+			case tt.want == "" && err == nil:
+				t.Errorf("Find(%s) = %s, want an error", tt.path, project.Name)
+			case tt.want != "" && err != nil:
+				t.Errorf("Find(%s) failed: %v", tt.path, err)
+			case tt.want != "" && project.Name != tt.want:
+				t.Errorf("Find(%s) = %s, want %s", tt.path, project.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddEntity(t *testing.T) {
+	m := NewManager(filepath.Join(t.TempDir(), "projects.json"))
+	// Projects registered before multiple entities were tracked only have Entity
+	if err := m.AddProject(Project{Name: "shop", Entity: "product"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AddEntity("shop", "order"); err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := m.Get("shop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := project.EntityList(); len(got) != 2 || got[0] != "product" || got[1] != "order" {
+		t.Errorf("EntityList() = %v, want [product order]", got)
+	}
+
+	if err := m.AddEntity("blog", "post"); err == nil {
+		t.Errorf("expected adding to an unregistered project to fail")
+	}
+}
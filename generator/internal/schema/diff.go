@@ -0,0 +1,138 @@
+package schema
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Kinds of schema changes
+const (
+	Added   = "+"
+	Removed = "-"
+	Changed = "~"
+)
+
+// Change is one difference between two schemas
+type Change struct {
+	Kind   string
+	Path   string // entity or entity.field
+	Detail string
+}
+
+func (c Change) String() string {
+	if c.Detail == "" {
+		return fmt.Sprintf("%s %s", c.Kind, c.Path)
+	}
+	return fmt.Sprintf("%s %s: %s", c.Kind, c.Path, c.Detail)
+}
+
+// Diff lists what changes from one validated schema to the next: entity by
+// entity in the new schema's order, then removed entities, then relations
+func Diff(from, to *Schema) []Change {
+	var changes []Change
+
+	for _, entity := range to.Entities {
+		old := findEntity(from, entity.Name)
+		if old == nil {
+			changes = append(changes, Change{Kind: Added, Path: entity.Name, Detail: describeEntity(entity)})
+			continue
+		}
+
+		for _, field := range entity.Fields {
+			path := entity.Name + "." + field.Name
+			oldField := findField(old.Fields, field.Name)
+			switch {
+			case oldField == nil:
+				changes = append(changes, Change{Kind: Added, Path: path, Detail: field.Describe()})
+			case oldField.Describe() != field.Describe():
+				changes = append(changes, Change{Kind: Changed, Path: path, Detail: oldField.Describe() + " → " + field.Describe()})
+			}
+		}
+		for _, field := range old.Fields {
+			if findField(entity.Fields, field.Name) == nil {
+				changes = append(changes, Change{Kind: Removed, Path: entity.Name + "." + field.Name})
+			}
+		}
+	}
+
+	for _, entity := range from.Entities {
+		if findEntity(to, entity.Name) == nil {
+			changes = append(changes, Change{Kind: Removed, Path: entity.Name})
+		}
+	}
+
+	fromRelations, toRelations := relationSet(from), relationSet(to)
+	for _, r := range to.Relations() {
+		if !fromRelations[r.String()] {
+			changes = append(changes, Change{Kind: Added, Path: r.String()})
+		}
+	}
+	for _, r := range from.Relations() {
+		if !toRelations[r.String()] {
+			changes = append(changes, Change{Kind: Removed, Path: r.String()})
+		}
+	}
+
+	return changes
+}
+
+// Describe summarises a field, e.g. "enum(open, closed), default open"
+func (f Field) Describe() string {
+	typ := f.Type
+	if f.Type == TypeEnum {
+		typ = fmt.Sprintf("enum(%s)", strings.Join(f.Values, ", "))
+	}
+	if f.Length > 0 {
+		typ = fmt.Sprintf("%s(%d)", typ, f.Length)
+	}
+
+	parts := []string{typ}
+	for _, attr := range []struct {
+		set  bool
+		name string
+	}{{f.Required, "required"}, {f.Nullable, "nullable"}, {f.Unique, "unique"}, {f.Index, "index"}} {
+		if attr.set {
+			parts = append(parts, attr.name)
+		}
+	}
+	if f.Default != "" {
+		parts = append(parts, "default "+f.Default)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func describeEntity(entity Entity) string {
+	if len(entity.Fields) == 0 {
+		return "sample fields"
+	}
+	names := make([]string, len(entity.Fields))
+	for i, field := range entity.Fields {
+		names[i] = field.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+func relationSet(s *Schema) map[string]bool {
+	set := make(map[string]bool)
+	for _, r := range s.Relations() {
+		set[r.String()] = true
+	}
+	return set
+}
+
+func findEntity(s *Schema, name string) *Entity {
+	i := slices.IndexFunc(s.Entities, func(e Entity) bool { return e.Name == name })
+	if i < 0 {
+		return nil
+	}
+	return &s.Entities[i]
+}
+
+func findField(fields []Field, name string) *Field {
+	i := slices.IndexFunc(fields, func(f Field) bool { return f.Name == name })
+	if i < 0 {
+		return nil
+	}
+	return &fields[i]
+}
@@ -0,0 +1,391 @@
+// Package schema describes the fields of generated entities and how each
+// field type maps to Go, SQL and request validation.
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Field types
+const (
+	TypeString  = "string"
+	TypeText    = "text"
+	TypeInt     = "int"
+	TypeBigInt  = "bigint"
+	TypeFloat   = "float"
+	TypeDecimal = "decimal"
+	TypeBool    = "bool"
+	TypeTime    = "time"
+	TypeDate    = "date"
+	TypeUUID    = "uuid"
+	TypeEnum    = "enum"
+)
+
+type typeInfo struct {
+	goType  string
+	sqlType string
+	zero    string // SQL literal matching the Go zero value, "" when the zero value isn't a usable default
+	check   string // extra validation in request binding tags
+}
+
+var types = map[string]typeInfo{
+	TypeString:  {goType: "string", sqlType: "VARCHAR(255)", zero: "''", check: "max=255"},
+	TypeText:    {goType: "string", sqlType: "TEXT", zero: "''"},
+	TypeInt:     {goType: "int", sqlType: "INTEGER", zero: "0"},
+	TypeBigInt:  {goType: "int64", sqlType: "BIGINT", zero: "0"},
+	TypeFloat:   {goType: "float64", sqlType: "DOUBLE PRECISION", zero: "0"},
+	TypeDecimal: {goType: "float64", sqlType: "NUMERIC(12,2)", zero: "0"},
+	TypeBool:    {goType: "bool", sqlType: "BOOLEAN", zero: "false"},
+	TypeTime:    {goType: "time.Time", sqlType: "TIMESTAMP"},
+	TypeDate:    {goType: "time.Time", sqlType: "DATE"},
+	TypeUUID:    {goType: "uuid.UUID", sqlType: "UUID"},
+	TypeEnum:    {goType: "string", sqlType: "VARCHAR(50)"},
+}
+
+// TypeNames lists the supported field types
+func TypeNames() []string {
+	return []string{TypeString, TypeText, TypeInt, TypeBigInt, TypeFloat, TypeDecimal,
+		TypeBool, TypeTime, TypeDate, TypeUUID, TypeEnum + "(a,b,...)"}
+}
+
+// Columns every generated table already has
+var builtinColumns = map[string]bool{"id": true, "created_at": true, "updated_at": true}
+
+var (
+	fieldName    = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+	enumValue    = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	jsonName     = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	bindingRules = regexp.MustCompile(`^[a-z0-9_]+(=[^,"` + "`" + `\s]+)?(,[a-z0-9_]+(=[^,"` + "`" + `\s]+)?)*$`)
+)
+
+// Field is one column of an entity
+type Field struct {
+	Name     string   `yaml:"name" json:"name"` // snake_case column name
+	Type     string   `yaml:"type" json:"type"`
+	Values   []string `yaml:"values,omitempty" json:"values,omitempty"` // allowed values of an enum
+	Length   int      `yaml:"length,omitempty" json:"length,omitempty"` // maximum length of a string, 255 by default
+	Required bool     `yaml:"required,omitempty" json:"required,omitempty"`
+	Nullable bool     `yaml:"nullable,omitempty" json:"nullable,omitempty"`
+	Unique   bool     `yaml:"unique,omitempty" json:"unique,omitempty"`
+	Index    bool     `yaml:"index,omitempty" json:"index,omitempty"`
+	Default  string   `yaml:"default,omitempty" json:"default,omitempty"` // SQL default, e.g. 0, open or NOW()
+	JSON     string   `yaml:"json,omitempty" json:"json,omitempty"`       // name in requests and responses, the column name by default
+	Rules    string   `yaml:"rules,omitempty" json:"rules,omitempty"`     // extra binding rules, e.g. email or gte=0
+
+	// Set on foreign keys added by relations
+	References string `yaml:"-" json:"-"` // referenced table
+	OnDelete   string `yaml:"-" json:"-"`
+}
+
+// SampleFields mirrors the template sample entity's fields, for entities
+// that declare none but still get columns added by relations
+var SampleFields = []Field{
+	{Name: "name", Type: TypeString, Required: true, Index: true},
+	{Name: "description", Type: TypeText},
+}
+
+// ParseFields parses a command-line field list such as
+// "title:string!,price:decimal,due_at:time?,status:enum(open,closed)".
+// A trailing ! makes a field required, ? makes it nullable.
+func ParseFields(spec string) ([]Field, error) {
+	var fields []Field
+	var errs []error
+
+	for _, part := range splitTopLevel(spec) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, typ, ok := strings.Cut(part, ":")
+		if !ok {
+			errs = append(errs, fmt.Errorf("field %q: expected name:type", part))
+			continue
+		}
+
+		field := Field{Name: strings.TrimSpace(name)}
+		typ = strings.TrimSpace(typ)
+		switch {
+		case strings.HasSuffix(typ, "!"):
+			field.Required = true
+			typ = strings.TrimSuffix(typ, "!")
+		case strings.HasSuffix(typ, "?"):
+			field.Nullable = true
+			typ = strings.TrimSuffix(typ, "?")
+		}
+
+		field.Type = typ
+
+		fields = append(fields, field)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return fields, Validate(fields)
+}
+
+// Validate checks a field list and fills in what the rules imply: enum(a,b)
+// types are split into their values, enums default to their first value, and time, date and uuid fields that are
+// neither nullable nor defaulted become required. Every problem is reported.
+func Validate(fields []Field) error {
+	var errs []error
+	seen := make(map[string]bool)
+
+	for i := range fields {
+		f := &fields[i]
+
+		// enum(a,b) is shorthand for type enum with values a and b
+		if values, ok := strings.CutPrefix(f.Type, TypeEnum+"("); ok && strings.HasSuffix(values, ")") && len(f.Values) == 0 {
+			f.Type = TypeEnum
+			for _, value := range strings.Split(strings.TrimSuffix(values, ")"), ",") {
+				f.Values = append(f.Values, strings.TrimSpace(value))
+			}
+		}
+		info, known := types[f.Type]
+
+		switch {
+		case !fieldName.MatchString(f.Name):
+			errs = append(errs, fmt.Errorf("field %q: names must be snake_case", f.Name))
+		case builtinColumns[f.Name]:
+			errs = append(errs, fmt.Errorf("field %q: every entity already has id, created_at and updated_at", f.Name))
+		case seen[f.Name]:
+			errs = append(errs, fmt.Errorf("field %q is defined twice", f.Name))
+		}
+		seen[f.Name] = true
+
+		if !known {
+			errs = append(errs, fmt.Errorf("field %q: unknown type %q (supported: %s)", f.Name, f.Type, strings.Join(TypeNames(), ", ")))
+			continue
+		}
+		if f.Required && f.Nullable {
+			errs = append(errs, fmt.Errorf("field %q can't be both required and nullable", f.Name))
+		}
+
+		if f.Type == TypeEnum {
+			if len(f.Values) == 0 {
+				errs = append(errs, fmt.Errorf("field %q: enum needs at least one value", f.Name))
+			}
+			for _, value := range f.Values {
+				if !enumValue.MatchString(value) {
+					errs = append(errs, fmt.Errorf("field %q: invalid enum value %q", f.Name, value))
+				}
+			}
+			if f.Default != "" && !slices.Contains(f.Values, f.Default) {
+				errs = append(errs, fmt.Errorf("field %q: default %q is not one of its values", f.Name, f.Default))
+			}
+			if !f.Required && !f.Nullable && f.Default == "" && len(f.Values) > 0 {
+				f.Default = f.Values[0]
+			}
+		} else if len(f.Values) > 0 {
+			errs = append(errs, fmt.Errorf("field %q: only enums take values", f.Name))
+		}
+		if f.JSON != "" && !jsonName.MatchString(f.JSON) {
+			errs = append(errs, fmt.Errorf("field %q: invalid json name %q", f.Name, f.JSON))
+		}
+		if f.Rules != "" && !bindingRules.MatchString(f.Rules) {
+			errs = append(errs, fmt.Errorf("field %q: invalid rules %q (comma separated, e.g. email,gte=0)", f.Name, f.Rules))
+		}
+		if f.Length != 0 && (f.Type != TypeString || f.Length < 0) {
+			errs = append(errs, fmt.Errorf("field %q: length only applies to strings and must be positive", f.Name))
+		}
+
+		if f.Default != "" && f.DefaultGo() == "" {
+			errs = append(errs, fmt.Errorf("field %q: invalid default %q for a %s (functions are only supported for times and uuids)", f.Name, f.Default, f.Type))
+		}
+
+		if !f.Required && !f.Nullable && f.Default == "" && info.zero == "" && f.Type != TypeEnum {
+			f.Required = true
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// GoName returns the exported Go field name, e.g. due_at -> DueAt, user_id -> UserID
+func (f Field) GoName() string {
+	var b strings.Builder
+	for _, word := range strings.Split(f.Name, "_") {
+		if word == "" {
+			continue
+		}
+		if initialisms[word] {
+			b.WriteString(strings.ToUpper(word))
+			continue
+		}
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+var initialisms = map[string]bool{"id": true, "url": true, "uri": true, "api": true, "ip": true, "uuid": true, "sku": true, "json": true, "html": true, "http": true}
+
+// JSONName returns the field's name in requests and responses
+func (f Field) JSONName() string {
+	if f.JSON != "" {
+		return f.JSON
+	}
+	return f.Name
+}
+
+// GoType returns the model field type; nullable fields are pointers
+func (f Field) GoType() string {
+	if f.Nullable {
+		return "*" + types[f.Type].goType
+	}
+	return types[f.Type].goType
+}
+
+// BaseGoType returns the Go type without the pointer of nullable fields
+func (f Field) BaseGoType() string {
+	return types[f.Type].goType
+}
+
+// SQLType returns the column type
+func (f Field) SQLType() string {
+	if f.Type == TypeString && f.Length > 0 {
+		return fmt.Sprintf("VARCHAR(%d)", f.Length)
+	}
+	return types[f.Type].sqlType
+}
+
+// ZeroIsValid reports whether the Go zero value is a meaningful input (0, false),
+// so required fields of the type must be pointers in requests to tell it apart
+// from a missing value
+func (f Field) ZeroIsValid() bool {
+	switch f.Type {
+	case TypeInt, TypeBigInt, TypeFloat, TypeDecimal, TypeBool:
+		return true
+	}
+	return false
+}
+
+// Column returns the column definition for CREATE TABLE
+func (f Field) Column() string {
+	parts := []string{f.Name, f.SQLType()}
+	if !f.Nullable {
+		parts = append(parts, "NOT NULL")
+	}
+	switch {
+	case f.Default != "":
+		parts = append(parts, "DEFAULT "+f.DefaultSQL())
+	case !f.Required && !f.Nullable:
+		parts = append(parts, "DEFAULT "+types[f.Type].zero)
+	}
+	if f.Unique {
+		parts = append(parts, "UNIQUE")
+	}
+	if f.References != "" {
+		parts = append(parts, fmt.Sprintf("REFERENCES %s(id) ON DELETE %s", f.References, strings.ToUpper(strings.ReplaceAll(f.OnDelete, "_", " "))))
+	}
+	if f.Type == TypeEnum {
+		quoted := make([]string, len(f.Values))
+		for i, value := range f.Values {
+			quoted[i] = "'" + value + "'"
+		}
+		parts = append(parts, fmt.Sprintf("CHECK (%s IN (%s))", f.Name, strings.Join(quoted, ", ")))
+	}
+	return strings.Join(parts, " ")
+}
+
+// DefaultSQL returns the default as a SQL expression. Function calls such as
+// NOW() are kept as they are; other values of text-like types are quoted.
+func (f Field) DefaultSQL() string {
+	if f.Default == "" || isCall(f.Default) {
+		return f.Default
+	}
+	switch f.Type {
+	case TypeInt, TypeBigInt, TypeFloat, TypeDecimal, TypeBool:
+		return f.DefaultGo()
+	}
+	return "'" + strings.ReplaceAll(f.Default, "'", "''") + "'"
+}
+
+// DefaultGo returns the default as a Go expression, or "" when it has none
+// or it can only be computed by the database
+func (f Field) DefaultGo() string {
+	if f.Default == "" {
+		return ""
+	}
+	if isCall(f.Default) {
+		switch f.Type {
+		case TypeTime, TypeDate:
+			return "time.Now()"
+		case TypeUUID:
+			return "uuid.New()"
+		}
+		return ""
+	}
+	switch f.Type {
+	case TypeInt, TypeBigInt:
+		if _, err := strconv.ParseInt(f.Default, 10, 64); err == nil {
+			return f.Default
+		}
+	case TypeFloat, TypeDecimal:
+		if _, err := strconv.ParseFloat(f.Default, 64); err == nil {
+			return f.Default
+		}
+	case TypeBool:
+		if value, err := strconv.ParseBool(f.Default); err == nil {
+			return strconv.FormatBool(value)
+		}
+	case TypeString, TypeText, TypeEnum:
+		return strconv.Quote(f.Default)
+	}
+	return ""
+}
+
+// Binding returns the validation rules of the field in a create request
+// (or an update request when update is true) for gin's binding tag
+func (f Field) Binding(update bool) string {
+	var rules []string
+	if f.Required && !update {
+		rules = append(rules, "required")
+	} else {
+		rules = append(rules, "omitempty")
+	}
+	switch check := types[f.Type].check; {
+	case f.Type == TypeString && f.Length > 0:
+		rules = append(rules, fmt.Sprintf("max=%d", f.Length))
+	case check != "":
+		rules = append(rules, check)
+	}
+	if f.Type == TypeEnum {
+		rules = append(rules, "oneof="+strings.Join(f.Values, " "))
+	}
+	if f.Rules != "" {
+		rules = append(rules, f.Rules)
+	}
+	return strings.Join(rules, ",")
+}
+
+// splitTopLevel splits on commas outside parentheses
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func isCall(s string) bool {
+	upper := strings.ToUpper(s)
+	return strings.HasSuffix(s, ")") || upper == "CURRENT_TIMESTAMP" || upper == "CURRENT_DATE"
+}
@@ -0,0 +1,56 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseFields(t *testing.T) {
+	fields, err := ParseFields("title:string!,price:decimal,due_at:time?,status:enum(open,closed)")
+	if err != nil {
+		t.Fatalf("ParseFields failed: %v", err)
+	}
+	if len(fields) != 4 {
+		t.Fatalf("Expected 4 fields, got %d", len(fields))
+	}
+
+	tests := []struct {
+		field   Field
+		goName  string
+		goType  string
+		column  string
+		binding string
+	}{
+		{fields[0], "Title", "string", "title VARCHAR(255) NOT NULL", "required,max=255"},
+		{fields[1], "Price", "float64", "price NUMERIC(12,2) NOT NULL DEFAULT 0", "omitempty"},
+		{fields[2], "DueAt", "*time.Time", "due_at TIMESTAMP", "omitempty"},
+		{fields[3], "Status", "string", "status VARCHAR(50) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'closed'))", "omitempty,oneof=open closed"},
+	}
+	for _, tt := range tests {
+		if got := tt.field.GoName(); got != tt.goName {
+			t.Errorf("GoName() = %q, want %q", got, tt.goName)
+		}
+		if got := tt.field.GoType(); got != tt.goType {
+			t.Errorf("%s: GoType() = %q, want %q", tt.field.Name, got, tt.goType)
+		}
+		if got := tt.field.Column(); got != tt.column {
+			t.Errorf("%s: Column() = %q, want %q", tt.field.Name, got, tt.column)
+		}
+		if got := tt.field.Binding(false); got != tt.binding {
+			t.Errorf("%s: Binding() = %q, want %q", tt.field.Name, got, tt.binding)
+		}
+	}
+}
+
+func TestParseFieldsReportsAllProblems(t *testing.T) {
+	_, err := ParseFields("Title:string,id:uuid,price:money,kind:enum(),note:text!?")
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	for _, want := range []string{`"Title"`, `"id"`, `unknown type "money"`, `"kind"`, `"note"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to mention %s, got:\n%v", want, err)
+		}
+	}
+}
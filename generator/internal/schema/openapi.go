@@ -0,0 +1,703 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Actions an API operation maps to
+const (
+	ActionCreate      = "create"       // POST /orders
+	ActionList        = "list"         // GET /orders
+	ActionGet         = "get"          // GET /orders/{id}
+	ActionUpdate      = "update"       // PUT or PATCH /orders/{id}
+	ActionDelete      = "delete"       // DELETE /orders/{id}
+	ActionListRelated = "list_related" // GET /customers/{id}/orders
+	ActionLink        = "link"         // POST /orders/{id}/tags/{tag_id}
+	ActionUnlink      = "unlink"       // DELETE /orders/{id}/tags/{tag_id}
+)
+
+// API is the route table of an imported OpenAPI document
+type API struct {
+	BasePath   string            // prefix of every path, e.g. /api/v1
+	Segments   map[string]string // each entity's collection path segment, e.g. order-items
+	Operations []Operation
+}
+
+// Operation is one operation of the document and what it maps to
+type Operation struct {
+	Method  string // upper case
+	Path    string // below the base path, as written: /orders/{id}
+	ID      string // operationId
+	Entity  string // entity whose collection the path starts with, "" when none
+	Related string // the other entity of relation routes
+	Action  string // "" when the operation doesn't map to CRUD or a relation
+}
+
+func (o Operation) String() string {
+	s := o.Method + " " + o.Path
+	if o.ID != "" {
+		s += " (" + o.ID + ")"
+	}
+	return s
+}
+
+// Unmapped returns the operations no generated handler serves
+func (a *API) Unmapped() []Operation {
+	var unmapped []Operation
+	for _, op := range a.Operations {
+		if op.Action == "" {
+			unmapped = append(unmapped, op)
+		}
+	}
+	return unmapped
+}
+
+// OpenAPIImport is a schema read from an OpenAPI document, along with the
+// document's routes
+type OpenAPIImport struct {
+	Schema   *Schema
+	API      *API
+	Warnings []string
+}
+
+// LoadOpenAPI reads an OpenAPI document; see FromOpenAPI
+func LoadOpenAPI(path string, plural func(entity string) string) (*OpenAPIImport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OpenAPI document: %w", err)
+	}
+
+	imp, err := FromOpenAPI(data, plural)
+	if err != nil {
+		return nil, fmt.Errorf("can't import %s:\n%w", path, err)
+	}
+	return imp, nil
+}
+
+// FromOpenAPI turns an OpenAPI 3 document in YAML or JSON into a schema.
+// Every collection with CRUD operations (/orders, /orders/{id}) becomes an
+// entity named after the singular of its path segment, with the fields of
+// the component schema its operations answer with. A <target>_id property
+// becomes a belongs_to relation, and POST or DELETE
+// /orders/{id}/tags/{tag_id} a many_to_many one. Operations that map to none
+// of these are returned with no action.
+func FromOpenAPI(data []byte, plural func(entity string) string) (*OpenAPIImport, error) {
+	var doc openAPIDoc
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI document: %w", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("expected an OpenAPI 3 document, found openapi: %q", doc.OpenAPI)
+	}
+
+	i := &openAPIImporter{doc: &doc, plural: plural, schemas: make(map[string]*openAPISchema)}
+	return i.run()
+}
+
+// OpenAPI documents, as far as the import reads them
+
+type openAPIDoc struct {
+	OpenAPI string `yaml:"openapi"`
+	Servers []struct {
+		URL string `yaml:"url"`
+	} `yaml:"servers"`
+	Paths      ordered[pathItem] `yaml:"paths"`
+	Components struct {
+		Schemas ordered[*openAPISchema] `yaml:"schemas"`
+	} `yaml:"components"`
+}
+
+// pathItem holds a path's operations by method
+type pathItem ordered[*openAPIOperation]
+
+var httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+func (p *pathItem) UnmarshalYAML(node *yaml.Node) error {
+	var all ordered[yaml.Node]
+	if err := node.Decode(&all); err != nil {
+		return err
+	}
+	for _, entry := range all {
+		if !slices.Contains(httpMethods, entry.Name) {
+			continue // parameters, summary, ...
+		}
+		var op openAPIOperation
+		if err := entry.Value.Decode(&op); err != nil {
+			return err
+		}
+		*p = append(*p, named[*openAPIOperation]{Name: entry.Name, Value: &op})
+	}
+	return nil
+}
+
+type openAPIOperation struct {
+	OperationID string `yaml:"operationId"`
+	RequestBody struct {
+		Content map[string]mediaType `yaml:"content"`
+	} `yaml:"requestBody"`
+	Responses map[string]struct {
+		Content map[string]mediaType `yaml:"content"`
+	} `yaml:"responses"`
+}
+
+type mediaType struct {
+	Schema *openAPISchema `yaml:"schema"`
+}
+
+// jsonSchema returns the schema of a JSON body
+func jsonSchema(content map[string]mediaType) *openAPISchema {
+	for contentType, media := range content {
+		if strings.Contains(contentType, "json") {
+			return media.Schema
+		}
+	}
+	return nil
+}
+
+// response returns the JSON schema of the first of the given responses
+func (op *openAPIOperation) response(codes ...string) *openAPISchema {
+	for _, code := range codes {
+		if s := jsonSchema(op.Responses[code].Content); s != nil {
+			return s
+		}
+	}
+	return nil
+}
+
+type openAPISchema struct {
+	Ref        string                  `yaml:"$ref"`
+	Type       schemaType              `yaml:"type"`
+	Format     string                  `yaml:"format"`
+	Enum       []any                   `yaml:"enum"`
+	Default    any                     `yaml:"default"`
+	Nullable   bool                    `yaml:"nullable"`
+	MaxLength  *int                    `yaml:"maxLength"`
+	MinLength  *int                    `yaml:"minLength"`
+	Minimum    *float64                `yaml:"minimum"`
+	Maximum    *float64                `yaml:"maximum"`
+	Required   []string                `yaml:"required"`
+	Properties ordered[*openAPISchema] `yaml:"properties"`
+	Items      *openAPISchema          `yaml:"items"`
+	AllOf      []*openAPISchema        `yaml:"allOf"`
+}
+
+// schemaType is a type name, or in OpenAPI 3.1 a list such as [string, "null"]
+type schemaType []string
+
+func (t *schemaType) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*t = schemaType{node.Value}
+		return nil
+	}
+	return node.Decode((*[]string)(t))
+}
+
+// name returns the type besides null
+func (t schemaType) name() string {
+	for _, name := range t {
+		if name != "null" {
+			return name
+		}
+	}
+	return ""
+}
+
+// ordered is a YAML mapping that keeps its order
+type ordered[T any] []named[T]
+
+type named[T any] struct {
+	Name  string
+	Value T
+}
+
+func (o *ordered[T]) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: expected a mapping", node.Line)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		var value T
+		if err := node.Content[i+1].Decode(&value); err != nil {
+			return err
+		}
+		*o = append(*o, named[T]{Name: node.Content[i].Value, Value: value})
+	}
+	return nil
+}
+
+func (o ordered[T]) get(name string) (T, bool) {
+	for _, entry := range o {
+		if entry.Name == name {
+			return entry.Value, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// The import itself
+
+type openAPIImporter struct {
+	doc      *openAPIDoc
+	plural   func(string) string
+	api      API
+	entities []string
+	schemas  map[string]*openAPISchema // each entity's component schema
+	requests map[string]*openAPISchema // each entity's create request body
+	warnings []string
+}
+
+// route is an operation with its path split into segments
+type route struct {
+	op       Operation
+	spec     *openAPIOperation
+	segments []string
+}
+
+func (i *openAPIImporter) run() (*OpenAPIImport, error) {
+	routes := i.routes()
+	i.api.Segments = make(map[string]string)
+	i.requests = make(map[string]*openAPISchema)
+
+	// Collections with CRUD operations are the entities
+	for _, r := range routes {
+		if crudAction(r) == "" || slices.Contains(i.entities, r.op.Entity) {
+			continue
+		}
+		i.entities = append(i.entities, r.op.Entity)
+		i.api.Segments[r.op.Entity] = r.segments[0]
+	}
+	if len(i.entities) == 0 {
+		return nil, errors.New("no paths map to CRUD operations on a collection, e.g. GET /orders and GET /orders/{id}")
+	}
+
+	// Collections without a schema aren't entities after all
+	i.entities = slices.DeleteFunc(i.entities, func(entity string) bool {
+		if err := i.findSchemas(entity, routes); err != nil {
+			i.warnings = append(i.warnings, err.Error())
+			return true
+		}
+		return false
+	})
+	if len(i.entities) == 0 {
+		return nil, fmt.Errorf("no collection has a schema with properties:\n%s", strings.Join(i.warnings, "\n"))
+	}
+	for _, r := range routes {
+		if !slices.Contains(i.entities, r.op.Entity) {
+			r.op.Entity = ""
+		}
+		if !slices.Contains(i.entities, r.op.Related) {
+			r.op.Related = ""
+		}
+	}
+
+	s := &Schema{}
+	for _, entity := range i.entities {
+		s.Entities = append(s.Entities, i.entity(entity))
+	}
+	i.linkRelations(s, routes)
+	s.Entities = parentsFirst(s.Entities)
+
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	for _, r := range routes {
+		r.op.Action = i.action(s, r)
+		if r.op.Action == ActionList {
+			if resp := i.resolve(r.spec.response("200")); resp != nil && resp.Type.name() == "array" {
+				i.warnings = append(i.warnings, fmt.Sprintf("%s answers {%s, total, limit, offset} rather than the documented array", r.op, i.plural(r.op.Entity)))
+			}
+		}
+		i.api.Operations = append(i.api.Operations, r.op)
+	}
+
+	return &OpenAPIImport{Schema: s, API: &i.api, Warnings: i.warnings}, nil
+}
+
+// routes lists the operations below the base path: the server URL's path
+// plus whatever static prefix all paths share
+func (i *openAPIImporter) routes() []*route {
+	var routes []*route
+	for _, path := range i.doc.Paths {
+		for _, op := range path.Value {
+			routes = append(routes, &route{
+				op:       Operation{Method: strings.ToUpper(op.Name), Path: path.Name, ID: op.Value.OperationID},
+				spec:     op.Value,
+				segments: strings.FieldsFunc(path.Name, func(r rune) bool { return r == '/' }),
+			})
+		}
+	}
+
+	// Segments every path shares, short of the last one
+	shared := 0
+	if len(routes) > 0 {
+		shared = len(routes[0].segments)
+	}
+	for _, r := range routes {
+		n := 0
+		for n < shared && n < len(r.segments)-1 && !isParam(r.segments[n]) && r.segments[n] == routes[0].segments[n] {
+			n++
+		}
+		shared = n
+	}
+
+	base := ""
+	if len(i.doc.Servers) > 0 {
+		if u, err := url.Parse(i.doc.Servers[0].URL); err == nil {
+			base = strings.TrimSuffix(u.Path, "/")
+		}
+	}
+	if shared > 0 {
+		base += "/" + strings.Join(routes[0].segments[:shared], "/")
+	}
+	i.api.BasePath = base
+
+	for _, r := range routes {
+		r.segments = r.segments[shared:]
+		r.op.Path = "/" + strings.Join(r.segments, "/")
+		if len(r.segments) > 0 && !isParam(r.segments[0]) {
+			r.op.Entity = i.entityOf(r.segments[0])
+		}
+		if len(r.segments) > 2 && !isParam(r.segments[2]) {
+			r.op.Related = i.entityOf(r.segments[2])
+		}
+	}
+	return routes
+}
+
+// entityOf returns the entity a collection segment names, "" when the
+// segment isn't the plural the generated code would use
+func (i *openAPIImporter) entityOf(segment string) string {
+	table := strings.ReplaceAll(segment, "-", "_")
+	entity := singular(table)
+	if entity == table || i.plural(entity) != table {
+		return ""
+	}
+	return entity
+}
+
+// crudAction returns the CRUD action a route's shape maps to, if any
+func crudAction(r *route) string {
+	if r.op.Entity == "" {
+		return ""
+	}
+	switch {
+	case len(r.segments) == 1 && r.op.Method == "POST":
+		return ActionCreate
+	case len(r.segments) == 1 && r.op.Method == "GET":
+		return ActionList
+	case len(r.segments) == 2 && isParam(r.segments[1]):
+		switch r.op.Method {
+		case "GET":
+			return ActionGet
+		case "PUT", "PATCH":
+			return ActionUpdate
+		case "DELETE":
+			return ActionDelete
+		}
+	}
+	return ""
+}
+
+// action maps a route to CRUD or a relation route of the final schema
+func (i *openAPIImporter) action(s *Schema, r *route) string {
+	if action := crudAction(r); action != "" || r.op.Related == "" || !slices.Contains(i.entities, r.op.Related) {
+		return action
+	}
+
+	for _, rel := range s.Relations() {
+		switch {
+		case len(r.segments) == 3 && r.op.Method == "GET" && isParam(r.segments[1]):
+			if rel.Kind == BelongsTo && rel.Entity == r.op.Related && rel.Target == r.op.Entity ||
+				rel.Kind == ManyToMany && (rel.Entity == r.op.Entity && rel.Target == r.op.Related || rel.Entity == r.op.Related && rel.Target == r.op.Entity) {
+				return ActionListRelated
+			}
+		case len(r.segments) == 4 && isParam(r.segments[1]) && isParam(r.segments[3]):
+			if rel.Kind == ManyToMany && rel.Entity == r.op.Entity && rel.Target == r.op.Related {
+				switch r.op.Method {
+				case "POST":
+					return ActionLink
+				case "DELETE":
+					return ActionUnlink
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// findSchemas finds the component schema an entity's operations answer
+// with, or the one named after it, and its create request body
+func (i *openAPIImporter) findSchemas(entity string, routes []*route) error {
+	var candidates []*openAPISchema
+	for _, r := range routes {
+		if r.op.Entity != entity {
+			continue
+		}
+		switch crudAction(r) {
+		case ActionGet, ActionUpdate:
+			candidates = append(candidates, r.spec.response("200"))
+		case ActionCreate:
+			candidates = append(candidates, r.spec.response("201", "200"))
+			i.requests[entity] = i.resolve(jsonSchema(r.spec.RequestBody.Content))
+		}
+	}
+	for _, component := range i.doc.Components.Schemas {
+		if strings.EqualFold(component.Name, strings.ReplaceAll(entity, "_", "")) {
+			candidates = append(candidates, component.Value)
+		}
+	}
+
+	for _, candidate := range candidates {
+		if resolved := i.resolve(candidate); resolved != nil && len(resolved.Properties) > 0 {
+			i.schemas[entity] = resolved
+			return nil
+		}
+	}
+	return fmt.Errorf("/%s: no schema with properties describes a %s, so its operations aren't mapped", i.api.Segments[entity], entity)
+}
+
+// resolve follows $refs and merges allOf
+func (i *openAPIImporter) resolve(s *openAPISchema) *openAPISchema {
+	for depth := 0; s != nil && s.Ref != ""; depth++ {
+		name, ok := strings.CutPrefix(s.Ref, "#/components/schemas/")
+		if !ok || depth > 20 {
+			return nil
+		}
+		s, _ = i.doc.Components.Schemas.get(name)
+	}
+	if s == nil || len(s.AllOf) == 0 {
+		return s
+	}
+
+	merged := *s
+	merged.AllOf = nil
+	for _, part := range s.AllOf {
+		if part = i.resolve(part); part != nil {
+			merged.Properties = append(slices.Clone(merged.Properties), part.Properties...)
+			merged.Required = append(slices.Clone(merged.Required), part.Required...)
+		}
+	}
+	return &merged
+}
+
+// entity converts an entity's schema properties into fields and belongs_to
+// relations
+func (i *openAPIImporter) entity(name string) Entity {
+	entity := Entity{Name: name}
+	s, request := i.schemas[name], i.requests[name]
+
+	required := s.Required
+	if request != nil {
+		required = request.Required
+		for _, prop := range request.Properties {
+			if _, ok := s.Properties.get(prop.Name); !ok {
+				i.warnings = append(i.warnings, fmt.Sprintf("%s: request property %s isn't in the %s schema and is ignored", name, prop.Name, name))
+			}
+		}
+	}
+
+	for _, prop := range s.Properties {
+		column := snakeCase(prop.Name)
+		p := i.resolve(prop.Value)
+		if p == nil {
+			i.warnings = append(i.warnings, fmt.Sprintf("%s.%s: unresolvable schema, left out", name, prop.Name))
+			continue
+		}
+		if builtinColumns[column] {
+			if column == "id" && p.Format != "uuid" {
+				i.warnings = append(i.warnings, fmt.Sprintf("%s.id is a UUID in the generated code", name))
+			}
+			if column != prop.Name {
+				i.warnings = append(i.warnings, fmt.Sprintf("%s.%s is named %s in the generated code", name, prop.Name, column))
+			}
+			continue
+		}
+
+		// Requests carry the validation rules when they declare the property
+		rules := p
+		if request != nil {
+			if requestProp, ok := request.Properties.get(prop.Name); ok && i.resolve(requestProp) != nil {
+				rules = i.resolve(requestProp)
+			}
+		}
+		isRequired := slices.Contains(required, prop.Name)
+
+		if target := strings.TrimSuffix(column, "_id"); target != column && target != name && slices.Contains(i.entities, target) {
+			nullable := p.Nullable || slices.Contains(p.Type, "null")
+			entity.BelongsTo = append(entity.BelongsTo, Reference{Entity: target, Optional: nullable || !isRequired})
+			if column != prop.Name {
+				i.warnings = append(i.warnings, fmt.Sprintf("%s.%s is named %s in the generated code", name, prop.Name, column))
+			}
+			continue
+		}
+
+		field, err := openAPIField(column, prop.Name, p, rules, isRequired)
+		if err != nil {
+			i.warnings = append(i.warnings, fmt.Sprintf("%s.%s: %v; left out", name, prop.Name, err))
+			continue
+		}
+		entity.Fields = append(entity.Fields, field)
+	}
+	return entity
+}
+
+// openAPIField maps a property to a field; rules is the property as the
+// create request declares it
+func openAPIField(column, property string, p, rules *openAPISchema, required bool) (Field, error) {
+	field := Field{Name: column}
+	if column != property {
+		field.JSON = property
+	}
+
+	var checks []string
+	switch p.Type.name() {
+	case "string":
+		switch {
+		case p.Format == "date-time":
+			field.Type = TypeTime
+		case p.Format == "date":
+			field.Type = TypeDate
+		case p.Format == "uuid":
+			field.Type = TypeUUID
+		case len(p.Enum) > 0:
+			field.Type = TypeEnum
+			for _, value := range p.Enum {
+				if value != nil {
+					field.Values = append(field.Values, fmt.Sprint(value))
+				}
+			}
+		case rules.MaxLength != nil:
+			field.Type, field.Length = TypeString, *rules.MaxLength
+		default:
+			field.Type = TypeText
+		}
+		switch rules.Format {
+		case "email":
+			checks = append(checks, "email")
+		case "uri", "url":
+			checks = append(checks, "url")
+		}
+		if rules.MinLength != nil && *rules.MinLength > 0 {
+			checks = append(checks, fmt.Sprintf("min=%d", *rules.MinLength))
+		}
+	case "integer":
+		field.Type = TypeInt
+		if p.Format == "int64" {
+			field.Type = TypeBigInt
+		}
+	case "number":
+		field.Type = TypeFloat
+		if p.Format == "decimal" {
+			field.Type = TypeDecimal
+		}
+	case "boolean":
+		field.Type = TypeBool
+	case "":
+		return field, errors.New("no type")
+	default:
+		return field, fmt.Errorf("%s properties aren't supported", p.Type.name())
+	}
+
+	if rules.Minimum != nil {
+		checks = append(checks, "gte="+strconv.FormatFloat(*rules.Minimum, 'f', -1, 64))
+	}
+	if rules.Maximum != nil {
+		checks = append(checks, "lte="+strconv.FormatFloat(*rules.Maximum, 'f', -1, 64))
+	}
+	field.Rules = strings.Join(checks, ",")
+
+	if p.Default != nil {
+		field.Default = fmt.Sprint(p.Default)
+	}
+	switch {
+	case p.Nullable || slices.Contains(p.Type, "null"):
+		field.Nullable = true
+	case required:
+		field.Required = true
+	case field.Default == "" && types[field.Type].zero == "" && field.Type != TypeEnum:
+		// Optional in the contract, and without a usable zero value
+		field.Nullable = true
+	}
+	return field, nil
+}
+
+// linkRelations adds many_to_many relations for link routes such as
+// POST /orders/{id}/tags/{tag_id} between entities not related otherwise
+func (i *openAPIImporter) linkRelations(s *Schema, routes []*route) {
+	for _, r := range routes {
+		if len(r.segments) != 4 || !isParam(r.segments[1]) || !isParam(r.segments[3]) || r.op.Method != "POST" && r.op.Method != "DELETE" {
+			continue
+		}
+		owner, target := r.op.Entity, r.op.Related
+		if !slices.Contains(i.entities, owner) || !slices.Contains(i.entities, target) || owner == target {
+			continue
+		}
+		related := slices.ContainsFunc(s.Relations(), func(rel Relation) bool {
+			return rel.Entity == owner && rel.Target == target || rel.Entity == target && rel.Target == owner
+		})
+		if !related {
+			_ = s.AddRelation(owner, ManyToMany, Reference{Entity: target})
+		}
+	}
+}
+
+// parentsFirst orders entities so every belongs_to parent comes before its
+// children, keeping the order otherwise
+func parentsFirst(entities []Entity) []Entity {
+	var sorted []Entity
+	placed := make(map[string]bool)
+	var place func(e Entity, visiting map[string]bool)
+	place = func(e Entity, visiting map[string]bool) {
+		if placed[e.Name] || visiting[e.Name] {
+			return
+		}
+		visiting[e.Name] = true
+		for _, ref := range e.BelongsTo {
+			if i := slices.IndexFunc(entities, func(p Entity) bool { return p.Name == ref.Entity }); i >= 0 {
+				place(entities[i], visiting)
+			}
+		}
+		placed[e.Name] = true
+		sorted = append(sorted, e)
+	}
+	for _, e := range entities {
+		place(e, make(map[string]bool))
+	}
+	return sorted
+}
+
+func isParam(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
+// snakeCase converts a property name such as dueAt or due-at to due_at
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		switch {
+		case r == '-' || r == ' ':
+			b.WriteByte('_')
+		case unicode.IsUpper(r):
+			lowerBefore := i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]))
+			acronymEnd := i > 0 && unicode.IsUpper(runes[i-1]) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if lowerBefore || acronymEnd {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,188 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const shopAPI = `
+openapi: 3.1.0
+info: {title: Shop, version: "1.0"}
+servers:
+  - url: https://shop.example.com/api
+paths:
+  /v1/customers:
+    post:
+      operationId: createCustomer
+      requestBody:
+        content:
+          application/json:
+            schema: {$ref: '#/components/schemas/NewCustomer'}
+      responses:
+        "201":
+          content:
+            application/json:
+              schema: {$ref: '#/components/schemas/Customer'}
+    get:
+      operationId: listCustomers
+      responses:
+        200:
+          content:
+            application/json:
+              schema:
+                type: array
+                items: {$ref: '#/components/schemas/Customer'}
+  /v1/customers/{customerId}:
+    parameters:
+      - {name: customerId, in: path, required: true, schema: {type: string}}
+    get:
+      operationId: getCustomer
+      responses:
+        "200":
+          content:
+            application/json:
+              schema: {$ref: '#/components/schemas/Customer'}
+  /v1/customers/{customerId}/orders:
+    get:
+      operationId: listCustomerOrders
+  /v1/order-items/{id}:
+    patch:
+      operationId: updateOrderItem
+      responses:
+        "200":
+          content:
+            application/json:
+              schema: {$ref: '#/components/schemas/OrderItem'}
+    delete:
+      operationId: deleteOrderItem
+  /v1/order-items/{id}/refund:
+    post:
+      operationId: refundOrderItem
+  /v1/order-items/{id}/tags/{tagId}:
+    post:
+      operationId: tagOrderItem
+  /v1/tags:
+    get:
+      operationId: listTags
+  /v1/stats:
+    get:
+      operationId: getStats
+components:
+  schemas:
+    Customer:
+      allOf:
+        - {$ref: '#/components/schemas/NewCustomer'}
+        - type: object
+          properties:
+            id: {type: string, format: uuid}
+            createdAt: {type: string, format: date-time}
+    NewCustomer:
+      type: object
+      required: [email]
+      properties:
+        email: {type: string, format: email, maxLength: 320}
+        nickName: {type: [string, "null"]}
+        tier: {type: string, enum: [basic, gold], default: basic}
+    OrderItem:
+      type: object
+      required: [customer_id, quantity]
+      properties:
+        id: {type: string, format: uuid}
+        customer_id: {type: string, format: uuid}
+        quantity: {type: integer, minimum: 1, maximum: 99}
+        price: {type: number, format: decimal}
+        shipped_on: {type: string, format: date}
+        options: {type: object}
+    Tag:
+      type: object
+      properties:
+        label: {type: string, maxLength: 30}
+`
+
+func TestFromOpenAPI(t *testing.T) {
+	imp, err := FromOpenAPI([]byte(shopAPI), plural)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := imp.Schema.EntityNames(), []string{"customer", "order_item", "tag"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("entities = %v, want %v", got, want)
+	}
+	if imp.API.BasePath != "/api/v1" {
+		t.Errorf("base path = %q, want /api/v1", imp.API.BasePath)
+	}
+	if got := imp.API.Segments["order_item"]; got != "order-items" {
+		t.Errorf("order_item segment = %q, want order-items", got)
+	}
+
+	wantCustomer := []Field{
+		{Name: "email", Type: TypeString, Length: 320, Required: true, Rules: "email"},
+		{Name: "nick_name", Type: TypeText, Nullable: true, JSON: "nickName"},
+		{Name: "tier", Type: TypeEnum, Values: []string{"basic", "gold"}, Default: "basic"},
+	}
+	if got := imp.Schema.Entities[0].Fields; !reflect.DeepEqual(got, wantCustomer) {
+		t.Errorf("customer fields = %+v, want %+v", got, wantCustomer)
+	}
+	wantOrderItem := []Field{
+		{Name: "quantity", Type: TypeInt, Required: true, Rules: "gte=1,lte=99"},
+		{Name: "price", Type: TypeDecimal},
+		{Name: "shipped_on", Type: TypeDate, Nullable: true},
+	}
+	if got := imp.Schema.Entities[1].Fields; !reflect.DeepEqual(got, wantOrderItem) {
+		t.Errorf("order_item fields = %+v, want %+v", got, wantOrderItem)
+	}
+
+	wantRelations := []Relation{
+		{Kind: BelongsTo, Entity: "order_item", Target: "customer", OnDelete: OnDeleteRestrict},
+		{Kind: ManyToMany, Entity: "order_item", Target: "tag"},
+	}
+	if got := imp.Schema.Relations(); !reflect.DeepEqual(got, wantRelations) {
+		t.Errorf("relations = %+v, want %+v", got, wantRelations)
+	}
+
+	actions := make(map[string]string)
+	for _, op := range imp.API.Operations {
+		actions[op.Method+" "+op.Path] = op.Action
+	}
+	wantActions := map[string]string{
+		"POST /customers":                     ActionCreate,
+		"GET /customers":                      ActionList,
+		"GET /customers/{customerId}":         ActionGet,
+		"GET /customers/{customerId}/orders":  "",
+		"PATCH /order-items/{id}":             ActionUpdate,
+		"DELETE /order-items/{id}":            ActionDelete,
+		"POST /order-items/{id}/refund":       "",
+		"POST /order-items/{id}/tags/{tagId}": ActionLink,
+		"GET /tags":                           ActionList,
+		"GET /stats":                          "",
+	}
+	if !reflect.DeepEqual(actions, wantActions) {
+		t.Errorf("actions = %v, want %v", actions, wantActions)
+	}
+
+	for _, want := range []string{
+		"customer.createdAt is named created_at",
+		"order_item.options: object properties aren't supported",
+		"GET /customers (listCustomers) answers {customers, total, limit, offset}",
+	} {
+		if !strings.Contains(strings.Join(imp.Warnings, "\n"), want) {
+			t.Errorf("warnings lack %q:\n%s", want, strings.Join(imp.Warnings, "\n"))
+		}
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	for name, want := range map[string]string{
+		"dueAt":     "due_at",
+		"due_at":    "due_at",
+		"imageURL":  "image_url",
+		"URLPath":   "url_path",
+		"line-item": "line_item",
+		"address2":  "address2",
+	} {
+		if got := snakeCase(name); got != want {
+			t.Errorf("snakeCase(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Relation kinds
+const (
+	BelongsTo  = "belongs_to"
+	HasMany    = "has_many"
+	ManyToMany = "many_to_many"
+)
+
+// ON DELETE behaviours of belongs_to foreign keys
+const (
+	OnDeleteCascade  = "cascade"
+	OnDeleteRestrict = "restrict"
+	OnDeleteSetNull  = "set_null"
+)
+
+// Reference points at a related entity. In YAML it is either the entity's
+// name or a mapping with options.
+type Reference struct {
+	Entity   string `yaml:"entity" json:"entity"`
+	OnDelete string `yaml:"on_delete,omitempty" json:"on_delete,omitempty"` // belongs_to/has_many: cascade, restrict or set_null
+	Optional bool   `yaml:"optional,omitempty" json:"optional,omitempty"`   // belongs_to/has_many: nullable foreign key
+}
+
+func (r *Reference) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		r.Entity = node.Value
+		return nil
+	}
+
+	type plain Reference
+	return node.Decode((*plain)(r))
+}
+
+// Relation is a relationship normalised to the side that owns it: the
+// entity holding the foreign key for belongs_to (has_many is turned
+// around), the declaring entity for many_to_many
+type Relation struct {
+	Kind     string `json:"kind"` // BelongsTo or ManyToMany
+	Entity   string `json:"entity"`
+	Target   string `json:"target"`
+	OnDelete string `json:"on_delete,omitempty"`
+	Optional bool   `json:"optional,omitempty"`
+}
+
+func (r Relation) String() string {
+	s := fmt.Sprintf("%s %s %s", r.Entity, r.Kind, r.Target)
+	if r.Kind == BelongsTo {
+		s += fmt.Sprintf(" (on delete %s", r.OnDelete)
+		if r.Optional {
+			s += ", optional"
+		}
+		s += ")"
+	}
+	return s
+}
+
+// ForeignKey returns the column a belongs_to relation adds to its entity
+func (r Relation) ForeignKey() string {
+	return r.Target + "_id"
+}
+
+// ParseRelation parses a command-line relation such as
+// "order belongs_to customer", "customer has_many order:cascade" or
+// "post many_to_many tag". A belongs_to or has_many target may end in
+// :cascade, :restrict or :set_null; set_null makes the key optional.
+func ParseRelation(spec string) (entity, kind string, ref Reference, err error) {
+	parts := strings.Fields(spec)
+	if len(parts) != 3 {
+		return "", "", ref, fmt.Errorf("relation %q: expected \"entity kind target\"", spec)
+	}
+
+	entity, kind = parts[0], parts[1]
+	ref.Entity, ref.OnDelete, _ = strings.Cut(parts[2], ":")
+	ref.Optional = ref.OnDelete == OnDeleteSetNull
+	return entity, kind, ref, nil
+}
+
+// AddRelation declares a relation on one of the schema's entities
+func (s *Schema) AddRelation(entity, kind string, ref Reference) error {
+	i := slices.IndexFunc(s.Entities, func(e Entity) bool { return e.Name == entity })
+	if i < 0 {
+		return fmt.Errorf("relation on unknown entity %q", entity)
+	}
+
+	e := &s.Entities[i]
+	switch kind {
+	case BelongsTo:
+		e.BelongsTo = append(e.BelongsTo, ref)
+	case HasMany:
+		e.HasMany = append(e.HasMany, ref)
+	case ManyToMany:
+		e.ManyToMany = append(e.ManyToMany, ref)
+	default:
+		return fmt.Errorf("unknown relation %q (use %s, %s or %s)", kind, BelongsTo, HasMany, ManyToMany)
+	}
+	return nil
+}
+
+// Relations returns every relation normalised to its owning side, each once
+func (s *Schema) Relations() []Relation {
+	var relations []Relation
+	add := func(r Relation) {
+		for _, existing := range relations {
+			same := existing.Kind == r.Kind && existing.Entity == r.Entity && existing.Target == r.Target
+			mirrored := r.Kind == ManyToMany && existing.Kind == ManyToMany && existing.Entity == r.Target && existing.Target == r.Entity
+			if same || mirrored {
+				return
+			}
+		}
+		relations = append(relations, r)
+	}
+
+	for _, entity := range s.Entities {
+		for _, ref := range entity.BelongsTo {
+			add(belongsTo(entity.Name, ref))
+		}
+		for _, ref := range entity.HasMany {
+			add(belongsTo(ref.Entity, Reference{Entity: entity.Name, OnDelete: ref.OnDelete, Optional: ref.Optional}))
+		}
+		for _, ref := range entity.ManyToMany {
+			add(Relation{Kind: ManyToMany, Entity: entity.Name, Target: ref.Entity})
+		}
+	}
+	return relations
+}
+
+func belongsTo(entity string, ref Reference) Relation {
+	onDelete := ref.OnDelete
+	if onDelete == "" {
+		onDelete = OnDeleteRestrict
+		if ref.Optional {
+			onDelete = OnDeleteSetNull
+		}
+	}
+	return Relation{Kind: BelongsTo, Entity: entity, Target: ref.Entity, OnDelete: onDelete, Optional: ref.Optional}
+}
+
+// validateRelations checks every relation refers to declared entities in a
+// usable order and doesn't clash with declared fields
+func (s *Schema) validateRelations() error {
+	var errs []error
+	position := make(map[string]int)
+	for i, entity := range s.Entities {
+		position[entity.Name] = i
+	}
+
+	for _, entity := range s.Entities {
+		for _, declared := range []struct {
+			kind string
+			refs []Reference
+		}{{BelongsTo, entity.BelongsTo}, {HasMany, entity.HasMany}, {ManyToMany, entity.ManyToMany}} {
+			kind := declared.kind
+			for _, ref := range declared.refs {
+				if _, ok := position[ref.Entity]; !ok {
+					errs = append(errs, fmt.Errorf("entity %s: %s %q is not a declared entity", entity.Name, kind, ref.Entity))
+				}
+				if ref.Entity == entity.Name {
+					errs = append(errs, fmt.Errorf("entity %s: relations to itself aren't supported", entity.Name))
+				}
+				if kind == ManyToMany && (ref.OnDelete != "" || ref.Optional) {
+					errs = append(errs, fmt.Errorf("entity %s: on_delete and optional only apply to belongs_to and has_many", entity.Name))
+				}
+				switch ref.OnDelete {
+				case "", OnDeleteCascade, OnDeleteRestrict:
+				case OnDeleteSetNull:
+					if !ref.Optional {
+						errs = append(errs, fmt.Errorf("entity %s: on_delete set_null needs optional: true", entity.Name))
+					}
+				default:
+					errs = append(errs, fmt.Errorf("entity %s: unknown on_delete %q (use cascade, restrict or set_null)", entity.Name, ref.OnDelete))
+				}
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	related := make(map[[2]string]bool)
+	for _, r := range s.Relations() {
+		pair := [2]string{min(r.Entity, r.Target), max(r.Entity, r.Target)}
+		if related[pair] {
+			errs = append(errs, fmt.Errorf("entities %s and %s are related more than once", pair[0], pair[1]))
+		}
+		related[pair] = true
+
+		if r.Kind != BelongsTo {
+			continue
+		}
+		// The referenced table has to exist when the migration runs
+		if position[r.Target] > position[r.Entity] {
+			errs = append(errs, fmt.Errorf("entity %s belongs to %s, so %s must be declared first", r.Entity, r.Target, r.Target))
+		}
+		entity := s.Entities[position[r.Entity]]
+		if slices.ContainsFunc(entity.Fields, func(f Field) bool { return f.Name == r.ForeignKey() }) {
+			errs = append(errs, fmt.Errorf("entity %s: field %s clashes with the foreign key of its %s relation", r.Entity, r.ForeignKey(), r.Target))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ForeignKeyField returns the field a belongs_to relation adds to its
+// entity, referencing the target's table
+func (r Relation) ForeignKeyField(table string) Field {
+	return Field{
+		Name:       r.ForeignKey(),
+		Type:       TypeUUID,
+		Required:   !r.Optional,
+		Nullable:   r.Optional,
+		Index:      true,
+		References: table,
+		OnDelete:   r.OnDelete,
+	}
+}
@@ -0,0 +1,72 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRelations(t *testing.T) {
+	s, err := Parse([]byte(`
+entities:
+  - name: customer
+    has_many:
+      - {entity: order, on_delete: cascade}
+  - name: order
+    belongs_to: [customer]
+    many_to_many: [tag]
+  - name: tag
+    many_to_many: [order]
+  - name: address
+    belongs_to:
+      - {entity: customer, optional: true}
+`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	var got []string
+	for _, r := range s.Relations() {
+		got = append(got, r.String())
+	}
+	expected := []string{
+		"order belongs_to customer (on delete cascade)",
+		"order many_to_many tag",
+		"address belongs_to customer (on delete set_null, optional)",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+
+	fk := s.Relations()[2].ForeignKeyField("customers")
+	if want := "customer_id UUID REFERENCES customers(id) ON DELETE SET NULL"; fk.Column() != want {
+		t.Errorf("Column() = %q, want %q", fk.Column(), want)
+	}
+}
+
+func TestRelationsValidation(t *testing.T) {
+	_, err := Parse([]byte(`
+entities:
+  - name: order
+    belongs_to: [customer]
+    many_to_many: [product]
+  - name: customer
+    fields:
+      - {name: name, type: string}
+`))
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	if !strings.Contains(err.Error(), `"product" is not a declared entity`) {
+		t.Errorf("Expected unknown entity to be reported, got: %v", err)
+	}
+
+	_, err = Parse([]byte(`
+entities:
+  - name: order
+    belongs_to: [customer]
+  - name: customer
+`))
+	if err == nil || !strings.Contains(err.Error(), "customer must be declared first") {
+		t.Errorf("Expected declaration order to be enforced, got: %v", err)
+	}
+}
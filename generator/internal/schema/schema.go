@@ -0,0 +1,158 @@
+package schema
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StoredNames are the file names a generated project keeps its schema under
+var StoredNames = []string{"schema.yaml", "schema.yml", "schema.json", "schema.sql", "openapi.yaml", "openapi.json"}
+
+// Schema declares a project's entities and their fields
+type Schema struct {
+	Entities []Entity `yaml:"entities" json:"entities"`
+}
+
+// Entity is one generated domain package; without fields it keeps the
+// template's sample fields. Relations name other entities of the schema.
+type Entity struct {
+	Name       string      `yaml:"name" json:"name"`
+	Fields     []Field     `yaml:"fields" json:"fields"`
+	BelongsTo  []Reference `yaml:"belongs_to,omitempty" json:"belongs_to,omitempty"`
+	HasMany    []Reference `yaml:"has_many,omitempty" json:"has_many,omitempty"`
+	ManyToMany []Reference `yaml:"many_to_many,omitempty" json:"many_to_many,omitempty"`
+}
+
+// Load reads and checks a schema file in YAML or JSON
+func Load(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %w", err)
+	}
+
+	s, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema %s:\n%w", path, err)
+	}
+	return s, nil
+}
+
+// Parse decodes and checks a schema. JSON is read as YAML; unknown keys are
+// rejected so a misspelt attribute isn't silently ignored.
+func Parse(data []byte) (*Schema, error) {
+	var s Schema
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&s); err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Validate checks every entity and its fields, reporting all problems
+func (s *Schema) Validate() error {
+	var errs []error
+	if len(s.Entities) == 0 {
+		errs = append(errs, errors.New("no entities declared"))
+	}
+
+	seen := make(map[string]bool)
+	for i := range s.Entities {
+		entity := &s.Entities[i]
+		switch {
+		case entity.Name == "":
+			errs = append(errs, fmt.Errorf("entity %d has no name", i+1))
+		case seen[entity.Name]:
+			errs = append(errs, fmt.Errorf("entity %q is declared twice", entity.Name))
+		}
+		seen[entity.Name] = true
+
+		if err := Validate(entity.Fields); err != nil {
+			errs = append(errs, prefixErrors("entity "+entity.Name+": ", err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return s.validateRelations()
+}
+
+// EntityNames returns the entity names in declaration order
+func (s *Schema) EntityNames() []string {
+	names := make([]string, len(s.Entities))
+	for i, entity := range s.Entities {
+		names[i] = entity.Name
+	}
+	return names
+}
+
+// Fields returns each entity's fields keyed by entity name
+func (s *Schema) Fields() map[string][]Field {
+	fields := make(map[string][]Field, len(s.Entities))
+	for _, entity := range s.Entities {
+		fields[entity.Name] = entity.Fields
+	}
+	return fields
+}
+
+// FindStored returns the path of the schema kept in a generated project
+func FindStored(projectDir string) (string, error) {
+	for _, name := range StoredNames {
+		path := filepath.Join(projectDir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("%s has no %s; it wasn't generated from a schema", projectDir, StoredNames[0])
+}
+
+// LoadStored reads a schema kept in a project, whichever its format; plural
+// names tables and collections as in FromSQL
+func LoadStored(path string, plural func(entity string) string) (*Schema, error) {
+	switch base := filepath.Base(path); {
+	case base == "schema.sql":
+		imp, err := LoadSQL(path, plural)
+		if err != nil {
+			return nil, err
+		}
+		return imp.Schema, nil
+	case strings.HasPrefix(base, "openapi."):
+		imp, err := LoadOpenAPI(path, plural)
+		if err != nil {
+			return nil, err
+		}
+		return imp.Schema, nil
+	}
+	return Load(path)
+}
+
+// StoredName returns the name a schema file is kept under in a project
+func StoredName(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "schema.json"
+	case ".sql":
+		return "schema.sql"
+	}
+	return "schema.yaml"
+}
+
+// prefixErrors prefixes every line of a joined error
+func prefixErrors(prefix string, err error) error {
+	lines := strings.Split(err.Error(), "\n")
+	for i, line := range lines {
+		lines[i] = prefix + line
+	}
+	return errors.New(strings.Join(lines, "\n"))
+}
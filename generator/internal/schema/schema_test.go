@@ -0,0 +1,69 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+)
+
+const domain = `
+entities:
+  - name: order
+    fields:
+      - {name: number, type: string, required: true, unique: true}
+      - {name: status, type: "enum(pending,paid)"}
+      - {name: notes, type: text, nullable: true}
+  - name: tag
+`
+
+func TestParse(t *testing.T) {
+	s, err := Parse([]byte(domain))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if got := strings.Join(s.EntityNames(), ","); got != "order,tag" {
+		t.Errorf("EntityNames() = %s", got)
+	}
+	status := s.Entities[0].Fields[1]
+	if status.Type != TypeEnum || len(status.Values) != 2 || status.Default != "pending" {
+		t.Errorf("Expected enum shorthand to be expanded, got %+v", status)
+	}
+
+	if _, err := Parse([]byte("entities:\n  - name: x\n    fields:\n      - {name: a, type: text, nulable: true}\n")); err == nil {
+		t.Error("Expected unknown attribute to be rejected")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	from, err := Parse([]byte(domain))
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := Parse([]byte(`
+entities:
+  - name: order
+    fields:
+      - {name: number, type: string, required: true, unique: true}
+      - {name: status, type: "enum(pending,paid,shipped)"}
+      - {name: total, type: decimal}
+  - name: invoice
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, change := range Diff(from, to) {
+		got = append(got, change.String())
+	}
+	expected := []string{
+		"~ order.status: enum(pending, paid), default pending → enum(pending, paid, shipped), default pending",
+		"+ order.total: decimal",
+		"- order.notes",
+		"+ invoice: sample fields",
+		"- tag",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+}
@@ -0,0 +1,611 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// SQLImport is a schema read from Postgres CREATE TABLE statements, along
+// with the statements themselves so they can serve as the migrations
+type SQLImport struct {
+	Schema     *Schema
+	Preamble   []string            // statements not tied to a table: extensions, types, functions
+	Drops      []string            // undo the preamble's types and functions, last created first
+	Statements map[string][]string // each entity's statements: its CREATE TABLE, indexes, constraints
+	Warnings   []string
+}
+
+// Migrations returns the up migration of each entity, built from its
+// statements; the preamble goes into the first entity's migration
+func (imp *SQLImport) Migrations() map[string]string {
+	migrations := make(map[string]string, len(imp.Schema.Entities))
+	for i, entity := range imp.Schema.Entities {
+		statements := imp.Statements[entity.Name]
+		if i == 0 {
+			statements = append(slices.Clone(imp.Preamble), statements...)
+		}
+		migrations[entity.Name] = strings.Join(statements, "\n\n") + "\n"
+	}
+	return migrations
+}
+
+// DownMigrations returns what each entity's down migration must drop besides
+// its tables: the types and functions of the preamble, which the first
+// entity's up migration creates. Extensions are kept, as the template's are.
+func (imp *SQLImport) DownMigrations() map[string]string {
+	migrations := make(map[string]string)
+	if len(imp.Drops) > 0 && len(imp.Schema.Entities) > 0 {
+		migrations[imp.Schema.Entities[0].Name] = strings.Join(imp.Drops, "\n") + "\n"
+	}
+	return migrations
+}
+
+// LoadSQL reads a DDL file; see FromSQL
+func LoadSQL(path string, plural func(entity string) string) (*SQLImport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SQL: %w", err)
+	}
+
+	imp, err := FromSQL(string(data), plural)
+	if err != nil {
+		return nil, fmt.Errorf("can't import %s:\n%w", path, err)
+	}
+	return imp, nil
+}
+
+// FromSQL turns CREATE TABLE statements into a schema without a database.
+// Every table becomes an entity named after its singular, so the table must
+// be named the way the generated code names it: plural returns the table
+// name of an entity. Tables need a UUID id primary key plus created_at and
+// updated_at. A <target>_id column referencing another table's id becomes a
+// belongs_to relation, and a table holding only two such keys is the join
+// table of a many_to_many relation.
+func FromSQL(ddl string, plural func(entity string) string) (*SQLImport, error) {
+	statements, err := splitStatements(ddl)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &ddlParser{plural: plural, enums: make(map[string][]string)}
+	for _, stmt := range statements {
+		p.statement(stmt)
+	}
+	if len(p.errs) > 0 {
+		return nil, errors.Join(p.errs...)
+	}
+	if len(p.tables) == 0 {
+		return nil, errors.New("no CREATE TABLE statements found")
+	}
+
+	return p.build()
+}
+
+// Column and table definitions as far as the import needs them
+
+type sqlColumn struct {
+	name       string
+	typ        string // lower case, e.g. "character varying(100)"
+	notNull    bool
+	primaryKey bool
+	unique     bool
+	index      bool
+	def        string
+	values     []string // from CHECK (column IN (...))
+	references string   // referenced table
+	refColumn  string
+	onDelete   string
+	generated  bool
+}
+
+type sqlTable struct {
+	name       string
+	columns    []*sqlColumn
+	statements []string
+}
+
+func (t *sqlTable) column(name string) *sqlColumn {
+	i := slices.IndexFunc(t.columns, func(c *sqlColumn) bool { return c.name == name })
+	if i < 0 {
+		return nil
+	}
+	return t.columns[i]
+}
+
+type ddlParser struct {
+	plural   func(entity string) string
+	tables   []*sqlTable
+	enums    map[string][]string // CREATE TYPE ... AS ENUM
+	preamble []string
+	drops    []string // of the preamble's objects, last created first
+	warnings []string
+	errs     []error
+}
+
+// statement records one statement: tables and enum types are parsed,
+// indexes and constraints refine their table, anything else is kept as is
+func (p *ddlParser) statement(stmt sqlStatement) {
+	t := &tokenReader{tokens: stmt.tokens}
+	switch {
+	case t.accept("create", "table"), t.accept("create", "unlogged", "table"):
+		p.createTable(t, stmt)
+	case t.accept("create", "type"):
+		name := t.qualifiedName()
+		if t.accept("as", "enum") {
+			p.enums[name] = t.stringList()
+		}
+		p.preamble = append(p.preamble, stmt.text)
+		p.drop("TYPE", name)
+	case t.accept("create", "function"), t.accept("create", "or", "replace", "function"):
+		p.preamble = append(p.preamble, stmt.text)
+		p.drop("FUNCTION", t.qualifiedName())
+	case t.accept("create", "domain"):
+		p.preamble = append(p.preamble, stmt.text)
+		p.drop("DOMAIN", t.qualifiedName())
+	case t.accept("create", "index"), t.accept("create", "unique", "index"):
+		p.createIndex(t, stmt.text, stmt.tokens[1].text == "unique")
+	case t.accept("alter", "table"):
+		p.alterTable(t, stmt.text)
+	case t.accept("drop"):
+		p.warnings = append(p.warnings, fmt.Sprintf("skipped %q", stmt.head()))
+	case t.accept("create", "trigger"), t.accept("comment", "on"):
+		if table := p.mentionedTable(stmt.tokens); table != nil {
+			table.statements = append(table.statements, stmt.text)
+			return
+		}
+		p.preamble = append(p.preamble, stmt.text)
+	default:
+		p.preamble = append(p.preamble, stmt.text)
+	}
+}
+
+// drop records how to undo a preamble statement creating an object of kind.
+// Functions are dropped by name, which Postgres accepts unless overloaded.
+func (p *ddlParser) drop(kind, name string) {
+	if name != "" {
+		p.drops = append([]string{fmt.Sprintf("DROP %s IF EXISTS %s;", kind, name)}, p.drops...)
+	}
+}
+
+func (p *ddlParser) createTable(t *tokenReader, stmt sqlStatement) {
+	t.accept("if", "not", "exists")
+	table := &sqlTable{name: t.qualifiedName(), statements: []string{stmt.text}}
+	if table.name == "" || t.done() || t.tokens[t.i].text != "(" {
+		p.errs = append(p.errs, fmt.Errorf("can't parse %q", stmt.head()))
+		return
+	}
+	if p.table(table.name) != nil {
+		p.errs = append(p.errs, fmt.Errorf("table %s is created twice", table.name))
+		return
+	}
+
+	for _, item := range t.list() {
+		item := &tokenReader{tokens: item}
+		if item.peekWord("constraint", "primary", "unique", "foreign", "check", "exclude") {
+			p.tableConstraint(table, item)
+			continue
+		}
+		if err := p.columnDefinition(table, item); err != nil {
+			p.errs = append(p.errs, fmt.Errorf("table %s: %w", table.name, err))
+		}
+	}
+	p.tables = append(p.tables, table)
+}
+
+// columnDefinition parses "name type [constraints...]"
+func (p *ddlParser) columnDefinition(table *sqlTable, t *tokenReader) error {
+	column := &sqlColumn{name: t.next().text}
+	column.typ = t.until(columnConstraints...)
+	if column.typ == "" {
+		return fmt.Errorf("column %s has no type", column.name)
+	}
+
+	for !t.done() {
+		switch {
+		case t.accept("constraint"):
+			t.next()
+		case t.accept("not", "null"):
+			column.notNull = true
+		case t.accept("null"):
+		case t.accept("default"):
+			column.def = t.until(columnConstraints...)
+		case t.accept("primary", "key"):
+			column.primaryKey, column.notNull = true, true
+		case t.accept("unique"):
+			column.unique = true
+		case t.accept("references"):
+			column.references, column.refColumn, column.onDelete = t.reference()
+		case t.accept("check"):
+			column.values = checkValues(column.name, t.group())
+		case t.accept("generated"):
+			column.generated = true
+			t.until(columnConstraints...)
+		default:
+			// COLLATE and anything else without a bearing on the fields
+			t.next()
+		}
+	}
+
+	table.columns = append(table.columns, column)
+	return nil
+}
+
+var columnConstraints = []string{"constraint", "not", "null", "default", "primary", "unique", "references", "check", "collate", "generated"}
+
+// tableConstraint parses PRIMARY KEY, UNIQUE, FOREIGN KEY and CHECK
+// constraints that refer to single columns
+func (p *ddlParser) tableConstraint(table *sqlTable, t *tokenReader) {
+	if t.accept("constraint") {
+		t.next()
+	}
+
+	switch {
+	case t.accept("primary", "key"):
+		columns := t.nameList()
+		for _, name := range columns {
+			if column := table.column(name); column != nil {
+				column.notNull = true
+				column.primaryKey = len(columns) == 1
+			}
+		}
+	case t.accept("unique"):
+		if columns := t.nameList(); len(columns) == 1 {
+			if column := table.column(columns[0]); column != nil {
+				column.unique = true
+			}
+		}
+	case t.accept("foreign", "key"):
+		columns := t.nameList()
+		if !t.accept("references") || len(columns) != 1 {
+			return
+		}
+		if column := table.column(columns[0]); column != nil {
+			column.references, column.refColumn, column.onDelete = t.reference()
+		}
+	case t.accept("check"):
+		group := t.group()
+		for _, column := range table.columns {
+			if values := checkValues(column.name, group); values != nil {
+				column.values = values
+			}
+		}
+	}
+}
+
+// createIndex marks the column of a plain single-column index
+func (p *ddlParser) createIndex(t *tokenReader, text string, unique bool) {
+	for !t.done() && !t.accept("on") {
+		t.next()
+	}
+	t.accept("only")
+	table := p.table(t.qualifiedName())
+	if table == nil {
+		p.preamble = append(p.preamble, text)
+		return
+	}
+	table.statements = append(table.statements, text)
+
+	if t.accept("using") {
+		t.next()
+	}
+	columns := t.nameList()
+	if len(columns) != 1 || !t.done() {
+		return // expressions, several columns and partial indexes stay in the migration only
+	}
+	if column := table.column(columns[0]); column != nil {
+		column.index = true
+		column.unique = column.unique || unique
+	}
+}
+
+// alterTable attaches the statement to its table and applies added constraints
+func (p *ddlParser) alterTable(t *tokenReader, text string) {
+	t.accept("if", "exists")
+	t.accept("only")
+	table := p.table(t.qualifiedName())
+	if table == nil {
+		p.preamble = append(p.preamble, text)
+		return
+	}
+	table.statements = append(table.statements, text)
+
+	switch {
+	case t.accept("add", "column"):
+		t.accept("if", "not", "exists")
+		fallthrough
+	case t.accept("add") && !t.peekWord("constraint", "primary", "unique", "foreign", "check", "exclude"):
+		if err := p.columnDefinition(table, t); err != nil {
+			p.errs = append(p.errs, fmt.Errorf("table %s: %w", table.name, err))
+		}
+	default:
+		p.tableConstraint(table, t)
+	}
+}
+
+func (p *ddlParser) table(name string) *sqlTable {
+	i := slices.IndexFunc(p.tables, func(t *sqlTable) bool { return t.name == name })
+	if i < 0 {
+		return nil
+	}
+	return p.tables[i]
+}
+
+// mentionedTable returns the first known table a statement names
+func (p *ddlParser) mentionedTable(tokens []sqlToken) *sqlTable {
+	for _, tok := range tokens {
+		if tok.kind != tokenWord && tok.kind != tokenQuoted {
+			continue
+		}
+		if table := p.table(tok.text); table != nil {
+			return table
+		}
+	}
+	return nil
+}
+
+// build turns the parsed tables into entities and relations
+func (p *ddlParser) build() (*SQLImport, error) {
+	imp := &SQLImport{Schema: &Schema{}, Preamble: p.preamble, Drops: p.drops, Statements: make(map[string][]string)}
+	var errs []error
+
+	// Name the entities first so foreign keys can be resolved in any order
+	entityOf := make(map[string]string)
+	var joinTables []*sqlTable
+	for _, table := range p.tables {
+		if table.column("id") == nil && isJoinTable(table) {
+			joinTables = append(joinTables, table)
+			continue
+		}
+		entity := singular(table.name)
+		if p.plural(entity) != table.name {
+			errs = append(errs, fmt.Errorf("table %s: the generated code would call entity %s's table %s; rename the table", table.name, entity, p.plural(entity)))
+			continue
+		}
+		entityOf[table.name] = entity
+	}
+
+	for _, table := range p.tables {
+		entity, ok := entityOf[table.name]
+		if !ok {
+			continue
+		}
+		e, err := p.entity(table, entity, entityOf)
+		if err != nil {
+			errs = append(errs, prefixErrors("table "+table.name+": ", err))
+			continue
+		}
+		imp.Schema.Entities = append(imp.Schema.Entities, e)
+		imp.Statements[entity] = table.statements
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	for _, table := range joinTables {
+		p.joinTable(imp, table, entityOf)
+	}
+
+	if err := imp.Schema.Validate(); err != nil {
+		return nil, err
+	}
+	imp.Warnings = p.warnings
+	return imp, nil
+}
+
+// entity converts a table's columns into fields and belongs_to relations
+func (p *ddlParser) entity(table *sqlTable, name string, entityOf map[string]string) (Entity, error) {
+	entity := Entity{Name: name}
+	var errs []error
+
+	if id := table.column("id"); id == nil || !id.primaryKey || id.typ != "uuid" {
+		errs = append(errs, errors.New("needs an id UUID PRIMARY KEY column"))
+	}
+	for _, builtin := range []string{"created_at", "updated_at"} {
+		if column := table.column(builtin); column == nil || fieldType(column.typ) != TypeTime {
+			errs = append(errs, fmt.Errorf("needs a timestamp column %s", builtin))
+		}
+	}
+
+	for _, column := range table.columns {
+		if builtinColumns[column.name] {
+			continue
+		}
+		if column.generated {
+			p.warnings = append(p.warnings, fmt.Sprintf("table %s: generated column %s is left out of the entity", table.name, column.name))
+			continue
+		}
+
+		if target, ok := entityOf[column.references]; ok && target != name && column.name == target+"_id" && column.refColumn == "id" {
+			entity.BelongsTo = append(entity.BelongsTo, Reference{
+				Entity:   target,
+				OnDelete: onDeleteAction(column.onDelete),
+				Optional: !column.notNull,
+			})
+			continue
+		}
+
+		field, err := p.field(table, column)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		entity.Fields = append(entity.Fields, field)
+	}
+
+	// Without declared fields the entity would get the sample ones
+	if len(entity.Fields) == 0 && len(errs) == 0 {
+		errs = append(errs, errors.New("has no columns besides id, timestamps and foreign keys"))
+	}
+	return entity, errors.Join(errs...)
+}
+
+// field maps a column to a field
+func (p *ddlParser) field(table *sqlTable, column *sqlColumn) (Field, error) {
+	field := Field{Name: column.name, Unique: column.unique, Index: column.index}
+
+	base, args := column.typ, ""
+	if open := strings.Index(base, "("); open >= 0 {
+		base, args = base[:open], strings.TrimSuffix(base[open+1:], ")")
+	}
+	switch values, isEnum := p.enums[base[strings.LastIndex(base, ".")+1:]]; {
+	case strings.HasSuffix(column.typ, "[]"):
+		return field, fmt.Errorf("column %s: arrays aren't supported", column.name)
+	case isEnum:
+		field.Type, field.Values = TypeEnum, values
+	case column.values != nil && (fieldType(column.typ) == TypeString || fieldType(column.typ) == TypeText):
+		field.Type, field.Values = TypeEnum, column.values
+	default:
+		field.Type = fieldType(column.typ)
+		if field.Type == "" {
+			return field, fmt.Errorf("column %s: unsupported type %s", column.name, column.typ)
+		}
+		if field.Type == TypeString && args != "" {
+			field.Length, _ = strconv.Atoi(args)
+		}
+	}
+
+	if strings.Contains(base, "serial") {
+		p.warnings = append(p.warnings, fmt.Sprintf("table %s: %s is a serial, but the generated code writes it like any other column", table.name, column.name))
+	}
+
+	if column.def != "" {
+		field.Default = column.def
+		if field.DefaultGo() == "" {
+			p.warnings = append(p.warnings, fmt.Sprintf("table %s: the default of %s (%s) can't be computed in Go, so the field is required in requests", table.name, column.name, column.def))
+			field.Default = ""
+		}
+	}
+	switch {
+	case !column.notNull:
+		field.Nullable = true
+	case field.Default == "":
+		field.Required = true
+	}
+	return field, nil
+}
+
+// joinTable turns a table of two foreign keys into a many_to_many relation
+// owned by the entity the table is named after; its statements go with the
+// entity created last
+func (p *ddlParser) joinTable(imp *SQLImport, table *sqlTable, entityOf map[string]string) {
+	var entities []string
+	for _, column := range table.columns {
+		if target, ok := entityOf[column.references]; ok && column.name == target+"_id" {
+			entities = append(entities, target)
+		}
+	}
+
+	last := ""
+	for _, entity := range imp.Schema.EntityNames() {
+		if slices.Contains(entities, entity) {
+			last = entity
+		}
+	}
+	if len(entities) != 2 || last == "" {
+		p.warnings = append(p.warnings, fmt.Sprintf("table %s has no id and isn't a join table of two entities; it was left out", table.name))
+		return
+	}
+	imp.Statements[last] = append(imp.Statements[last], table.statements...)
+
+	a, b := entities[0], entities[1]
+	owner, target := "", ""
+	for _, pair := range [][2]string{{a, b}, {b, a}} {
+		if table.name == pair[0]+"_"+p.plural(pair[1]) {
+			owner, target = pair[0], pair[1]
+		}
+	}
+	if owner == "" {
+		p.warnings = append(p.warnings, fmt.Sprintf("join table %s isn't named %s_<%s plural>, so no routes were generated for it", table.name, a, b))
+		return
+	}
+
+	for i := range imp.Schema.Entities {
+		if imp.Schema.Entities[i].Name == owner {
+			imp.Schema.Entities[i].ManyToMany = append(imp.Schema.Entities[i].ManyToMany, Reference{Entity: target})
+		}
+	}
+}
+
+// isJoinTable reports whether a table has nothing but foreign keys and
+// created_at
+func isJoinTable(table *sqlTable) bool {
+	keys := 0
+	for _, column := range table.columns {
+		switch {
+		case column.references != "" && column.typ == "uuid":
+			keys++
+		case column.name != "created_at":
+			return false
+		}
+	}
+	return keys == 2
+}
+
+// fieldType maps a Postgres column type to a field type, "" when unsupported
+func fieldType(typ string) string {
+	base, _, _ := strings.Cut(typ, "(")
+	switch strings.TrimSpace(base) {
+	case "varchar", "character varying", "char", "character", "bpchar":
+		if base == typ && (base == "varchar" || base == "character varying") {
+			return TypeText // no length limit
+		}
+		return TypeString
+	case "text", "citext", "json", "jsonb":
+		return TypeText
+	case "smallint", "int2", "integer", "int", "int4", "smallserial", "serial2", "serial", "serial4":
+		return TypeInt
+	case "bigint", "int8", "bigserial", "serial8":
+		return TypeBigInt
+	case "real", "float4", "double precision", "float8", "float":
+		return TypeFloat
+	case "numeric", "decimal":
+		return TypeDecimal
+	case "boolean", "bool":
+		return TypeBool
+	case "timestamp", "timestamptz", "timestamp without time zone", "timestamp with time zone":
+		return TypeTime
+	case "date":
+		return TypeDate
+	case "uuid":
+		return TypeUUID
+	}
+	return ""
+}
+
+func onDeleteAction(action string) string {
+	switch action {
+	case "cascade":
+		return OnDeleteCascade
+	case "set null":
+		return OnDeleteSetNull
+	}
+	return OnDeleteRestrict // RESTRICT, NO ACTION or none
+}
+
+// checkValues returns the values of a "column IN ('a', 'b')" check
+func checkValues(column string, tokens []sqlToken) []string {
+	t := &tokenReader{tokens: tokens}
+	t.acceptPunct("(")
+	if t.accept(column) && t.accept("in") {
+		return t.stringList()
+	}
+	return nil
+}
+
+// singular inverts the plural the generated code uses for table names
+func singular(table string) string {
+	switch {
+	case strings.HasSuffix(table, "ies"):
+		return strings.TrimSuffix(table, "ies") + "y"
+	case strings.HasSuffix(table, "sses"), strings.HasSuffix(table, "shes"),
+		strings.HasSuffix(table, "ches"), strings.HasSuffix(table, "xes"):
+		return strings.TrimSuffix(table, "es")
+	case strings.HasSuffix(table, "s"):
+		return strings.TrimSuffix(table, "s")
+	}
+	return table
+}
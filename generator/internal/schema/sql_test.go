@@ -0,0 +1,154 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// plural mirrors the table names the generator gives entities
+func plural(entity string) string {
+	switch {
+	case strings.HasSuffix(entity, "y"):
+		return strings.TrimSuffix(entity, "y") + "ies"
+	case strings.HasSuffix(entity, "s"), strings.HasSuffix(entity, "sh"), strings.HasSuffix(entity, "ch"), strings.HasSuffix(entity, "x"):
+		return entity + "es"
+	}
+	return entity + "s"
+}
+
+const shopDDL = `
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TYPE order_status AS ENUM ('pending', 'paid', 'shipped');
+
+-- Customers place orders
+CREATE TABLE public.customers (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    email VARCHAR(320) NOT NULL UNIQUE,
+    name text NOT NULL,
+    vip boolean NOT NULL DEFAULT false,
+    rating numeric(3,1) DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE orders (
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    customer_id uuid NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    status order_status NOT NULL DEFAULT 'pending'::order_status,
+    total numeric(12, 2) NOT NULL,
+    note character varying,
+    channel varchar(10) NOT NULL DEFAULT 'web' CHECK (channel IN ('web', 'app')),
+    shipped_at timestamp with time zone,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT orders_pkey PRIMARY KEY (id)
+);
+
+CREATE INDEX idx_orders_status ON orders (status);
+CREATE INDEX idx_orders_total_status ON orders (total, status);
+
+CREATE TABLE tags (
+    id UUID PRIMARY KEY,
+    label VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE order_tags (
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    tag_id UUID NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (order_id, tag_id)
+);
+
+CREATE FUNCTION touch() RETURNS trigger AS $$
+BEGIN
+    NEW.updated_at = NOW(); -- keeps the semicolon inside the body
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+`
+
+func TestFromSQL(t *testing.T) {
+	imp, err := FromSQL(shopDDL, plural)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := imp.Schema.EntityNames(), []string{"customer", "order", "tag"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("entities = %v, want %v", got, want)
+	}
+
+	customer, order := imp.Schema.Entities[0], imp.Schema.Entities[1]
+	wantCustomer := []Field{
+		{Name: "email", Type: TypeString, Length: 320, Required: true, Unique: true},
+		{Name: "name", Type: TypeText, Required: true},
+		{Name: "vip", Type: TypeBool, Default: "false"},
+		{Name: "rating", Type: TypeDecimal, Nullable: true, Default: "0"},
+	}
+	if !reflect.DeepEqual(customer.Fields, wantCustomer) {
+		t.Errorf("customer fields = %+v, want %+v", customer.Fields, wantCustomer)
+	}
+
+	wantOrder := []Field{
+		{Name: "status", Type: TypeEnum, Values: []string{"pending", "paid", "shipped"}, Default: "pending", Index: true},
+		{Name: "total", Type: TypeDecimal, Required: true},
+		{Name: "note", Type: TypeText, Nullable: true},
+		{Name: "channel", Type: TypeEnum, Values: []string{"web", "app"}, Default: "web"},
+		{Name: "shipped_at", Type: TypeTime, Nullable: true},
+	}
+	if !reflect.DeepEqual(order.Fields, wantOrder) {
+		t.Errorf("order fields = %+v, want %+v", order.Fields, wantOrder)
+	}
+
+	wantRelations := []Relation{
+		{Kind: BelongsTo, Entity: "order", Target: "customer", OnDelete: OnDeleteCascade},
+		{Kind: ManyToMany, Entity: "order", Target: "tag"},
+	}
+	if got := imp.Schema.Relations(); !reflect.DeepEqual(got, wantRelations) {
+		t.Errorf("relations = %+v, want %+v", got, wantRelations)
+	}
+
+	migrations := imp.Migrations()
+	for _, want := range []string{`CREATE EXTENSION IF NOT EXISTS "uuid-ossp";`, "CREATE TYPE order_status", "$$ LANGUAGE plpgsql;", "-- Customers place orders\nCREATE TABLE public.customers"} {
+		if !strings.Contains(migrations["customer"], want) {
+			t.Errorf("customer migration lacks %q:\n%s", want, migrations["customer"])
+		}
+	}
+	if !strings.Contains(migrations["order"], "CREATE INDEX idx_orders_total_status ON orders (total, status);") {
+		t.Errorf("order migration lacks its indexes:\n%s", migrations["order"])
+	}
+	if !strings.Contains(migrations["tag"], "CREATE TABLE order_tags") {
+		t.Errorf("join table isn't created after both tables:\n%s", migrations["tag"])
+	}
+
+	// What the preamble creates is dropped with the first entity's table, last first
+	wantDown := map[string]string{"customer": "DROP FUNCTION IF EXISTS touch;\nDROP TYPE IF EXISTS order_status;\n"}
+	if got := imp.DownMigrations(); !reflect.DeepEqual(got, wantDown) {
+		t.Errorf("down migrations = %q, want %q", got, wantDown)
+	}
+}
+
+func TestFromSQLReportsAllProblems(t *testing.T) {
+	_, err := FromSQL(`
+CREATE TABLE people (id UUID PRIMARY KEY, name TEXT, created_at TIMESTAMP, updated_at TIMESTAMP);
+CREATE TABLE items (id SERIAL PRIMARY KEY, tags TEXT[], created_at TIMESTAMP, updated_at TIMESTAMP);
+CREATE TABLE boxes (id UUID PRIMARY KEY, size point);
+`, plural)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	for _, want := range []string{
+		"table people: the generated code would call entity people's table peoples",
+		"table items: needs an id UUID PRIMARY KEY column",
+		"table items: column tags: arrays aren't supported",
+		"table boxes: needs a timestamp column created_at",
+		"table boxes: column size: unsupported type point",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error lacks %q:\n%v", want, err)
+		}
+	}
+}
@@ -0,0 +1,407 @@
+package schema
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Token kinds of the DDL lexer
+const (
+	tokenWord   = iota // keyword or identifier, lower-cased
+	tokenQuoted        // "quoted identifier", case kept
+	tokenString        // 'string' or $$dollar quoted$$, unquoted
+	tokenNumber
+	tokenPunct
+)
+
+type sqlToken struct {
+	kind int
+	text string
+	raw  string // as written
+	pos  int
+}
+
+// sqlStatement is one statement with the comments leading up to it
+type sqlStatement struct {
+	text   string
+	tokens []sqlToken
+	src    string
+}
+
+// head returns the first line of the statement itself, for messages
+func (s sqlStatement) head() string {
+	line, _, _ := strings.Cut(s.src[s.tokens[0].pos:], "\n")
+	return strings.TrimSpace(line)
+}
+
+// splitStatements splits DDL on semicolons outside strings, quoted
+// identifiers, comments and dollar-quoted bodies
+func splitStatements(src string) ([]sqlStatement, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+
+	var statements []sqlStatement
+	start, from := 0, 0
+	for i, tok := range tokens {
+		last := i == len(tokens)-1
+		if tok.raw != ";" && !last {
+			continue
+		}
+		end, stmtTokens := tok.pos+1, tokens[from:i]
+		if tok.raw != ";" {
+			end, stmtTokens = len(src), tokens[from:]
+		}
+		if len(stmtTokens) > 0 {
+			text := strings.TrimSpace(src[start:end])
+			if !strings.HasSuffix(text, ";") {
+				text += ";"
+			}
+			statements = append(statements, sqlStatement{text: text, tokens: stmtTokens, src: src})
+		}
+		start, from = end, i+1
+	}
+	return statements, nil
+}
+
+func tokenize(src string) ([]sqlToken, error) {
+	var tokens []sqlToken
+	line := func(pos int) int { return strings.Count(src[:pos], "\n") + 1 }
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		start := i
+		switch {
+		case unicode.IsSpace(rune(c)):
+			i++
+			continue
+		case strings.HasPrefix(src[i:], "--"):
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				end = len(src) - i
+			}
+			i += end
+			continue
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated comment", line(start))
+			}
+			i += end + 4
+			continue
+		case c == '\'' || c == '"':
+			var text strings.Builder
+			i++
+			for {
+				if i >= len(src) {
+					return nil, fmt.Errorf("line %d: unterminated %c", line(start), c)
+				}
+				if src[i] == c {
+					if i+1 < len(src) && src[i+1] == c {
+						text.WriteByte(c)
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				text.WriteByte(src[i])
+				i++
+			}
+			kind := tokenString
+			if c == '"' {
+				kind = tokenQuoted
+			}
+			tokens = append(tokens, sqlToken{kind: kind, text: text.String(), raw: src[start:i], pos: start})
+		case c == '$' && dollarTag(src[i:]) != "":
+			tag := dollarTag(src[i:])
+			end := strings.Index(src[i+len(tag):], tag)
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated %s body", line(start), tag)
+			}
+			i += len(tag) + end + len(tag)
+			tokens = append(tokens, sqlToken{kind: tokenString, text: src[start+len(tag) : i-len(tag)], raw: src[start:i], pos: start})
+		case isWordStart(c):
+			for i < len(src) && (isWordStart(src[i]) || isDigit(src[i]) || src[i] == '$') {
+				i++
+			}
+			tokens = append(tokens, sqlToken{kind: tokenWord, text: strings.ToLower(src[start:i]), raw: src[start:i], pos: start})
+		case isDigit(c):
+			for i < len(src) && (isDigit(src[i]) || src[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, sqlToken{kind: tokenNumber, text: src[start:i], raw: src[start:i], pos: start})
+		case strings.HasPrefix(src[i:], "::"):
+			i += 2
+			tokens = append(tokens, sqlToken{kind: tokenPunct, text: "::", raw: "::", pos: start})
+		default:
+			i++
+			tokens = append(tokens, sqlToken{kind: tokenPunct, text: src[start:i], raw: src[start:i], pos: start})
+		}
+	}
+	return tokens, nil
+}
+
+// dollarTag returns the $tag$ opening a dollar-quoted string, or ""
+func dollarTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '$':
+			return s[:i+1]
+		case !isWordStart(s[i]) && !(i > 1 && isDigit(s[i])):
+			return ""
+		}
+	}
+	return ""
+}
+
+func isWordStart(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// tokenReader walks the tokens of a statement
+type tokenReader struct {
+	tokens []sqlToken
+	i      int
+}
+
+func (t *tokenReader) done() bool {
+	return t.i >= len(t.tokens)
+}
+
+func (t *tokenReader) next() sqlToken {
+	if t.done() {
+		return sqlToken{}
+	}
+	t.i++
+	return t.tokens[t.i-1]
+}
+
+// accept consumes the given sequence of keywords if it comes next
+func (t *tokenReader) accept(words ...string) bool {
+	if t.i+len(words) > len(t.tokens) {
+		return false
+	}
+	for j, word := range words {
+		tok := t.tokens[t.i+j]
+		if tok.kind != tokenWord || tok.text != word {
+			return false
+		}
+	}
+	t.i += len(words)
+	return true
+}
+
+func (t *tokenReader) acceptPunct(punct string) bool {
+	if t.done() || t.tokens[t.i].kind != tokenPunct || t.tokens[t.i].text != punct {
+		return false
+	}
+	t.i++
+	return true
+}
+
+// peekWord reports whether the next token is one of the keywords
+func (t *tokenReader) peekWord(words ...string) bool {
+	return !t.done() && t.tokens[t.i].kind == tokenWord && slices.Contains(words, t.tokens[t.i].text)
+}
+
+// qualifiedName reads a possibly schema-qualified name and returns its last part
+func (t *tokenReader) qualifiedName() string {
+	name := ""
+	for !t.done() {
+		tok := t.tokens[t.i]
+		if tok.kind != tokenWord && tok.kind != tokenQuoted {
+			break
+		}
+		name = tok.text
+		t.i++
+		if !t.acceptPunct(".") {
+			break
+		}
+	}
+	return name
+}
+
+// group reads a parenthesised group and returns the tokens inside it
+func (t *tokenReader) group() []sqlToken {
+	if !t.acceptPunct("(") {
+		return nil
+	}
+	start, depth := t.i, 1
+	for !t.done() {
+		tok := t.next()
+		if tok.kind != tokenPunct {
+			continue
+		}
+		switch tok.text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return t.tokens[start : t.i-1]
+			}
+		}
+	}
+	return t.tokens[start:]
+}
+
+// list reads a parenthesised, comma separated list
+func (t *tokenReader) list() [][]sqlToken {
+	var items [][]sqlToken
+	group := t.group()
+	depth, start := 0, 0
+	for i, tok := range group {
+		if tok.kind != tokenPunct {
+			continue
+		}
+		switch tok.text {
+		case "(", "[":
+			depth++
+		case ")", "]":
+			depth--
+		case ",":
+			if depth == 0 {
+				items = append(items, group[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if start < len(group) {
+		items = append(items, group[start:])
+	}
+	return items
+}
+
+// nameList reads a list of column names; items that aren't a plain name
+// (expressions) are returned as ""
+func (t *tokenReader) nameList() []string {
+	var names []string
+	for _, item := range t.list() {
+		name := ""
+		if len(item) > 0 && (item[0].kind == tokenWord || item[0].kind == tokenQuoted) {
+			name = item[0].text
+		}
+		for _, tok := range item[1:] {
+			if tok.kind != tokenWord || !slices.Contains([]string{"asc", "desc", "nulls", "first", "last"}, tok.text) {
+				name = ""
+			}
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// stringList reads a list of string literals, ignoring casts
+func (t *tokenReader) stringList() []string {
+	values := []string{}
+	for _, item := range t.list() {
+		if len(item) > 0 && item[0].kind == tokenString {
+			values = append(values, item[0].text)
+		}
+	}
+	return values
+}
+
+// until reads up to one of the keywords outside parentheses and returns
+// what it read: a type as written, lower-cased, or a default with its
+// casts and quotes removed
+func (t *tokenReader) until(stops ...string) string {
+	start, depth := t.i, 0
+	for !t.done() {
+		tok := t.tokens[t.i]
+		if depth == 0 && tok.kind == tokenWord && slices.Contains(stops, tok.text) {
+			break
+		}
+		if tok.kind == tokenPunct && (tok.text == "(" || tok.text == "[") {
+			depth++
+		}
+		if tok.kind == tokenPunct && (tok.text == ")" || tok.text == "]") {
+			depth--
+		}
+		t.i++
+	}
+	return expression(t.tokens[start:t.i])
+}
+
+// reference reads "table [(column)] [ON DELETE action] ..." after REFERENCES
+func (t *tokenReader) reference() (table, column, onDelete string) {
+	table, column = t.qualifiedName(), "id"
+	if !t.done() && t.tokens[t.i].text == "(" {
+		if columns := t.nameList(); len(columns) == 1 {
+			column = columns[0]
+		}
+	}
+
+	for {
+		switch {
+		case t.accept("on", "delete"):
+			switch {
+			case t.accept("set", "null"):
+				onDelete = "set null"
+			case t.accept("set", "default"):
+				onDelete = "set default"
+			case t.accept("no", "action"):
+				onDelete = "no action"
+			default:
+				onDelete = t.next().text
+			}
+		case t.accept("on", "update"):
+			if !t.accept("set", "null") && !t.accept("set", "default") && !t.accept("no", "action") {
+				t.next()
+			}
+		case t.accept("match"), t.accept("initially"):
+			t.next()
+		case t.accept("deferrable"), t.accept("not", "deferrable"):
+		default:
+			return table, column, onDelete
+		}
+	}
+}
+
+// expression renders tokens compactly without a trailing cast; a single
+// literal, as in 'open'::status, is returned as its value
+func expression(tokens []sqlToken) string {
+	depth := 0
+	for i, tok := range tokens {
+		if tok.kind == tokenPunct && (tok.text == "(" || tok.text == "[") {
+			depth++
+		}
+		if tok.kind == tokenPunct && (tok.text == ")" || tok.text == "]") {
+			depth--
+		}
+		if depth == 0 && i > 0 && tok.text == "::" {
+			tokens = tokens[:i]
+			break
+		}
+	}
+	for len(tokens) > 2 && tokens[0].text == "(" && tokens[len(tokens)-1].text == ")" && tokens[0].kind == tokenPunct {
+		tokens = tokens[1 : len(tokens)-1]
+	}
+	if len(tokens) == 1 && tokens[0].kind == tokenString {
+		return tokens[0].text
+	}
+
+	var b strings.Builder
+	for i, tok := range tokens {
+		if i > 0 && tok.kind != tokenPunct && tokens[i-1].kind != tokenPunct {
+			b.WriteByte(' ')
+		}
+		text := tok.raw
+		if tok.kind == tokenWord {
+			text = tok.text
+		}
+		b.WriteString(text)
+		if tok.text == "," {
+			b.WriteByte(' ')
+		}
+	}
+	return b.String()
+}
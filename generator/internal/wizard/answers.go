@@ -0,0 +1,178 @@
+package wizard
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/darkphotonKN/go-template-generator/internal/schema"
+	"gopkg.in/yaml.v3"
+)
+
+const answersHeader = "# go-gen answers: replay with `go-gen create --answers <this file>`\n"
+
+// Answers are the options a project is created with. Written to a file they
+// replay a generation without asking anything.
+type Answers struct {
+	ProjectName string `yaml:"project_name"`
+
+	// Entities, the primary one first, as in a schema file. A schema,
+	// SQL or OpenAPI file can supply them instead.
+	Entities    []schema.Entity `yaml:"entities,omitempty"`
+	Schema      string          `yaml:"schema,omitempty"`
+	FromSQL     string          `yaml:"from_sql,omitempty"`
+	FromOpenAPI string          `yaml:"from_openapi,omitempty"`
+
+	Description  string   `yaml:"description,omitempty"` // empty keeps the template's
+	ModulePrefix string   `yaml:"module_prefix"`
+	Features     Features `yaml:"features"`
+	Ports        Ports    `yaml:"ports,omitempty"` // unset ports are allocated
+}
+
+type Features struct {
+	Auth     bool `yaml:"auth"`
+	S3       bool `yaml:"s3"`
+	Redis    bool `yaml:"redis"`
+	Frontend bool `yaml:"frontend"`
+}
+
+type Ports struct {
+	API      int `yaml:"api,omitempty"`
+	DB       int `yaml:"db,omitempty"`
+	Redis    int `yaml:"redis,omitempty"`
+	Frontend int `yaml:"frontend,omitempty"`
+}
+
+// LoadAnswers reads and checks an answers file. Source file paths in it are
+// relative to the answers file.
+func LoadAnswers(path string) (*Answers, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read answers: %w", err)
+	}
+
+	var a Answers
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&a); err != nil {
+		return nil, fmt.Errorf("failed to parse answers %s: %w", path, err)
+	}
+	if err := a.validate(); err != nil {
+		return nil, fmt.Errorf("invalid answers %s:\n%w", path, err)
+	}
+
+	for _, source := range []*string{&a.Schema, &a.FromSQL, &a.FromOpenAPI} {
+		if *source != "" && !filepath.IsAbs(*source) {
+			*source = filepath.Join(filepath.Dir(path), *source)
+		}
+	}
+	return &a, nil
+}
+
+// Save writes the answers to path. Source file paths are made relative to
+// it where possible, so the file can travel with them.
+func (a *Answers) Save(path string) error {
+	saved := *a
+	if dir, err := filepath.Abs(filepath.Dir(path)); err == nil {
+		for _, source := range []*string{&saved.Schema, &saved.FromSQL, &saved.FromOpenAPI} {
+			if *source == "" {
+				continue
+			}
+			if abs, err := filepath.Abs(*source); err == nil {
+				if rel, err := filepath.Rel(dir, abs); err == nil {
+					*source = filepath.ToSlash(rel)
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(answersHeader)
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&saved); err != nil {
+		return fmt.Errorf("failed to marshal answers: %w", err)
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write answers: %w", err)
+	}
+	return nil
+}
+
+// Source returns the schema, SQL or OpenAPI file the entities come from, or ""
+func (a *Answers) Source() string {
+	return a.Schema + a.FromSQL + a.FromOpenAPI
+}
+
+// EntityNames returns the names of the entities, the primary one first
+func (a *Answers) EntityNames() []string {
+	return (&schema.Schema{Entities: a.Entities}).EntityNames()
+}
+
+// SetEntities replaces the entities by name, keeping the fields and
+// relations of those already there and dropping relations to the others
+func (a *Answers) SetEntities(names []string) {
+	entities := make([]schema.Entity, len(names))
+	for i, name := range names {
+		entities[i] = schema.Entity{Name: name}
+		for _, existing := range a.Entities {
+			if existing.Name == name {
+				entities[i] = existing
+				break
+			}
+		}
+	}
+
+	kept := func(refs []schema.Reference) []schema.Reference {
+		return slices.DeleteFunc(slices.Clone(refs), func(ref schema.Reference) bool {
+			return !slices.Contains(names, ref.Entity)
+		})
+	}
+	for i := range entities {
+		entities[i].BelongsTo = kept(entities[i].BelongsTo)
+		entities[i].HasMany = kept(entities[i].HasMany)
+		entities[i].ManyToMany = kept(entities[i].ManyToMany)
+	}
+	a.Entities = entities
+}
+
+// validate reports every problem with answers read from a file
+func (a *Answers) validate() error {
+	var errs []error
+	if a.ProjectName == "" {
+		errs = append(errs, errors.New("project_name is required"))
+	}
+
+	sources := 0
+	for _, source := range []string{a.Schema, a.FromSQL, a.FromOpenAPI} {
+		if source != "" {
+			sources++
+		}
+	}
+	switch {
+	case sources > 1:
+		errs = append(errs, errors.New("only one of schema, from_sql and from_openapi can be given"))
+	case sources == 1 && len(a.Entities) > 0:
+		errs = append(errs, errors.New("entities can't be combined with schema, from_sql or from_openapi"))
+	case sources == 0 && len(a.Entities) == 0:
+		errs = append(errs, errors.New("entities, schema, from_sql or from_openapi is required"))
+	case sources == 0:
+		if err := (&schema.Schema{Entities: a.Entities}).Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, port := range []struct {
+		name  string
+		value int
+	}{{"api", a.Ports.API}, {"db", a.Ports.DB}, {"redis", a.Ports.Redis}, {"frontend", a.Ports.Frontend}} {
+		if port.value < 0 || port.value > 65535 {
+			errs = append(errs, fmt.Errorf("ports.%s %d is out of range", port.name, port.value))
+		}
+	}
+	return errors.Join(errs...)
+}
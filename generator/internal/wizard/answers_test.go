@@ -0,0 +1,103 @@
+package wizard
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/darkphotonKN/go-template-generator/internal/schema"
+)
+
+func TestAnswersSaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	want := &Answers{
+		ProjectName: "shop",
+		Entities: []schema.Entity{
+			{Name: "customer", Fields: []schema.Field{}},
+			{Name: "order", Fields: []schema.Field{{Name: "total", Type: "decimal"}}, BelongsTo: []schema.Reference{{Entity: "customer"}}},
+		},
+		ModulePrefix: "example.com/acme/",
+		Features:     Features{Auth: true, Redis: true},
+		Ports:        Ports{API: 8010, DB: 5442, Redis: 6389},
+	}
+	path := filepath.Join(dir, "answers.yaml")
+	if err := want.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	got, err := LoadAnswers(path)
+	if err != nil {
+		t.Fatalf("LoadAnswers failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadAnswers = %+v, want %+v", got, want)
+	}
+}
+
+func TestAnswersSourcePaths(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "answers", "shop.yaml")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	a := &Answers{ProjectName: "shop", FromSQL: filepath.Join(dir, "db", "schema.sql")}
+	if err := a.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "from_sql: ../db/schema.sql") {
+		t.Errorf("Expected the SQL path relative to the answers file, got:\n%s", data)
+	}
+
+	got, err := LoadAnswers(path)
+	if err != nil {
+		t.Fatalf("LoadAnswers failed: %v", err)
+	}
+	if got.FromSQL != a.FromSQL {
+		t.Errorf("FromSQL = %s, want %s", got.FromSQL, a.FromSQL)
+	}
+}
+
+func TestLoadAnswersReportsAllErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "answers.yaml")
+	data := "schema: schema.yaml\nfrom_sql: schema.sql\nports:\n  api: 70000\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadAnswers(path)
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	for _, want := range []string{"project_name is required", "only one of", "ports.api"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to mention %q, got: %v", want, err)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("project_name: shop\nentitys: [order]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadAnswers(path); err == nil {
+		t.Errorf("Expected a misspelt key to be rejected")
+	}
+}
+
+func TestSetEntities(t *testing.T) {
+	a := &Answers{Entities: []schema.Entity{
+		{Name: "order", Fields: []schema.Field{{Name: "total", Type: "decimal"}}, BelongsTo: []schema.Reference{{Entity: "customer"}, {Entity: "store"}}},
+		{Name: "store"},
+	}}
+	a.SetEntities([]string{"order", "customer"})
+
+	want := []schema.Entity{
+		{Name: "order", Fields: []schema.Field{{Name: "total", Type: "decimal"}}, BelongsTo: []schema.Reference{{Entity: "customer"}}},
+		{Name: "customer"},
+	}
+	if !reflect.DeepEqual(a.Entities, want) {
+		t.Errorf("Entities = %+v, want %+v", a.Entities, want)
+	}
+}
@@ -0,0 +1,163 @@
+package wizard
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Wizard asks for the options of a new project on a terminal
+type Wizard struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	// Optional checks; an answer they reject is asked again
+	CheckProjectName func(string) error
+	CheckEntities    func([]string) error
+}
+
+func New(in io.Reader, out io.Writer) *Wizard {
+	return &Wizard{in: bufio.NewReader(in), out: out}
+}
+
+// IsTerminal reports whether f is an interactive terminal
+func IsTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// Ask prompts for every answer, offering the current values as defaults.
+// The entities aren't asked for when askEntities is false, e.g. when they
+// come from a schema file.
+func (w *Wizard) Ask(a *Answers, askEntities bool) error {
+	fmt.Fprintf(w.out, "🧙 New project (press Enter to keep the value in brackets)\n\n")
+
+	var err error
+	if a.ProjectName, err = w.text("Project name", a.ProjectName, w.CheckProjectName); err != nil {
+		return err
+	}
+
+	if askEntities {
+		for {
+			answer, err := w.text("Entities, primary first (comma-separated)", strings.Join(a.EntityNames(), ","), nil)
+			if err != nil {
+				return err
+			}
+			entities := splitList(answer)
+			if w.CheckEntities != nil {
+				if err := w.CheckEntities(entities); err != nil {
+					fmt.Fprintf(w.out, "  ✗ %v\n", err)
+					continue
+				}
+			}
+			a.SetEntities(entities)
+			break
+		}
+	}
+
+	if a.Description, err = w.text("Description (empty for the template's)", a.Description, nil); err != nil {
+		return err
+	}
+	if a.ModulePrefix, err = w.text("Go module prefix", a.ModulePrefix, nil); err != nil {
+		return err
+	}
+
+	for _, q := range []struct {
+		label  string
+		answer *bool
+	}{
+		{"Include JWT authentication?", &a.Features.Auth},
+		{"Include S3 file uploads?", &a.Features.S3},
+		{"Include Redis caching?", &a.Features.Redis},
+		{"Include Next.js frontend?", &a.Features.Frontend},
+	} {
+		if *q.answer, err = w.yesNo(q.label, *q.answer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Confirm shows what will be created and asks whether to go ahead
+func (w *Wizard) Confirm(summary []string) (bool, error) {
+	fmt.Fprintf(w.out, "\n📋 About to create:\n")
+	for _, line := range summary {
+		fmt.Fprintf(w.out, "  %s\n", line)
+	}
+	fmt.Fprintln(w.out)
+	return w.yesNo("Create the project?", true)
+}
+
+// text asks for a line of text until check accepts it
+func (w *Wizard) text(label, def string, check func(string) error) (string, error) {
+	for {
+		prompt := label
+		if def != "" {
+			prompt += " [" + def + "]"
+		}
+		answer, err := w.ask(prompt)
+		if err != nil {
+			return "", err
+		}
+		if answer == "" {
+			answer = def
+		}
+
+		if check != nil {
+			if err := check(answer); err != nil {
+				fmt.Fprintf(w.out, "  ✗ %v\n", err)
+				continue
+			}
+		}
+		return answer, nil
+	}
+}
+
+// yesNo asks a yes/no question until it gets one
+func (w *Wizard) yesNo(label string, def bool) (bool, error) {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	for {
+		answer, err := w.ask(label + " [" + hint + "]")
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return def, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		fmt.Fprintf(w.out, "  ✗ answer y or n\n")
+	}
+}
+
+func (w *Wizard) ask(prompt string) (string, error) {
+	fmt.Fprintf(w.out, "%s: ", prompt)
+	line, err := w.in.ReadString('\n')
+	switch {
+	case errors.Is(err, io.EOF) && line == "":
+		return "", fmt.Errorf("input ended before every question was answered")
+	case err != nil && !errors.Is(err, io.EOF):
+		return "", fmt.Errorf("failed to read answer: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
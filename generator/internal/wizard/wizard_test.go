@@ -0,0 +1,86 @@
+package wizard
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/darkphotonKN/go-template-generator/internal/schema"
+)
+
+func TestAsk(t *testing.T) {
+	input := strings.Join([]string{
+		"Shop",            // rejected project name
+		"",                // keeps the default
+		"order, Customer", // rejected entities
+		"order, customer",
+		"", // description: keeps the template's
+		"example.com/acme/",
+		"no",    // auth
+		"maybe", // not an answer
+		"y",     // S3
+		"",      // Redis: keeps the default
+		"",      // frontend: keeps the default
+	}, "\n") + "\n"
+
+	var out strings.Builder
+	w := New(strings.NewReader(input), &out)
+	w.CheckProjectName = func(name string) error {
+		if strings.ToLower(name) != name {
+			return errors.New("lower case only")
+		}
+		return nil
+	}
+	w.CheckEntities = func(entities []string) error {
+		for _, entity := range entities {
+			if strings.ToLower(entity) != entity {
+				return errors.New("lower case only")
+			}
+		}
+		return nil
+	}
+
+	answers := &Answers{
+		ProjectName:  "shop",
+		Entities:     []schema.Entity{{Name: "order", Fields: []schema.Field{{Name: "total", Type: "decimal"}}}},
+		ModulePrefix: "github.com/me/",
+		Features:     Features{Auth: true, Redis: true},
+	}
+	if err := w.Ask(answers, true); err != nil {
+		t.Fatalf("Ask failed: %v", err)
+	}
+
+	want := &Answers{
+		ProjectName:  "shop",
+		Entities:     []schema.Entity{{Name: "order", Fields: []schema.Field{{Name: "total", Type: "decimal"}}}, {Name: "customer"}},
+		ModulePrefix: "example.com/acme/",
+		Features:     Features{S3: true, Redis: true},
+	}
+	if !reflect.DeepEqual(answers, want) {
+		t.Errorf("answers = %+v, want %+v", answers, want)
+	}
+	if got := strings.Count(out.String(), "✗"); got != 3 {
+		t.Errorf("Expected 3 rejected answers, got %d in:\n%s", got, out.String())
+	}
+	if !strings.Contains(out.String(), "Entities, primary first (comma-separated) [order]") {
+		t.Errorf("Expected the entities prompt to offer the default, got:\n%s", out.String())
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	for input, want := range map[string]bool{"\n": true, "n\n": false, "yes\n": true} {
+		var out strings.Builder
+		got, err := New(strings.NewReader(input), &out).Confirm([]string{"Project: shop"})
+		if err != nil {
+			t.Fatalf("Confirm(%q) failed: %v", input, err)
+		}
+		if got != want {
+			t.Errorf("Confirm(%q) = %v, want %v", input, got, want)
+		}
+	}
+
+	if _, err := New(strings.NewReader(""), &strings.Builder{}).Confirm(nil); err == nil {
+		t.Errorf("Expected an error when the input ends")
+	}
+}
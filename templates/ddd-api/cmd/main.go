@@ -11,7 +11,8 @@ import (
 	"time"
 
 	"github.com/joho/godotenv"
-	"github.com/darkphotonKN/go-template-generator/config"
+	"github.com/darkphotonKN/go-template-generator/templates/ddd-api/config"
+	"github.com/darkphotonKN/go-template-generator/templates/ddd-api/internal/cache"
 )
 
 func main() {
@@ -33,7 +34,15 @@ func main() {
 	}
 	defer db.Close()
 
+	rdb, err := cache.NewRedis(logger)
+	if err != nil {
+		logger.Error("failed to connect to redis", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	defer rdb.Close()
+
 	router := config.SetupRoutes(db, logger)
+	router.GET("/health/redis", cache.HealthCheck(rdb))
 
 	port := os.Getenv("PORT")
 	if port == "" {
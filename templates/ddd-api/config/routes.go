@@ -1,13 +1,19 @@
 package config
 
 import (
+	"context"
 	"log/slog"
+	"os"
+	"strings"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
-	"github.com/darkphotonKN/go-template-generator/internal/item"
-	"github.com/darkphotonKN/go-template-generator/internal/middleware"
+	"github.com/darkphotonKN/go-template-generator/templates/ddd-api/internal/auth"
+	"github.com/darkphotonKN/go-template-generator/templates/ddd-api/internal/docs"
+	"github.com/darkphotonKN/go-template-generator/templates/ddd-api/internal/item"
+	"github.com/darkphotonKN/go-template-generator/templates/ddd-api/internal/middleware"
+	"github.com/darkphotonKN/go-template-generator/templates/ddd-api/internal/s3"
 )
 
 func SetupRoutes(db *sqlx.DB, logger *slog.Logger) *gin.Engine {
@@ -23,26 +29,48 @@ func SetupRoutes(db *sqlx.DB, logger *slog.Logger) *gin.Engine {
 	router.Use(corsMiddleware())
 
 	// Initialize services
-	repo := item.NewRepository(db)
-	service := item.NewService(repo, logger)
-	handler := item.NewHandler(service, logger)
+	itemRepo := item.NewRepository(db)
+	itemService := item.NewService(itemRepo, logger)
+	itemHandler := item.NewHandler(itemService, logger)
 
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
 
+	// OpenAPI document and docs page
+	docs.Register(router)
+
 	// API routes
 	api := router.Group("/api")
+
+	// Login issues the tokens the routes below require; it is registered
+	// before the middleware so it stays public
+	authHandler := auth.NewHandler(os.Getenv("JWT_SECRET"), os.Getenv("AUTH_EMAIL"), os.Getenv("AUTH_PASSWORD"), logger)
+	api.POST("/auth/login", authHandler.Login)
+
+	// Require a valid JWT on every other API route
+	api.Use(auth.Middleware(os.Getenv("JWT_SECRET"), logger))
+
 	{
 		// Item endpoints
 		items := api.Group("/items")
 		{
-			items.POST("", handler.CreateItem)
-			items.GET("", handler.ListItems)
-			items.GET("/:id", handler.GetItem)
-			items.PUT("/:id", handler.UpdateItem)
-			items.DELETE("/:id", handler.DeleteItem)
+			items.POST("", itemHandler.CreateItem)
+			items.GET("", itemHandler.ListItems)
+			items.GET("/:id", itemHandler.GetItem)
+			items.PUT("/:id", itemHandler.UpdateItem)
+			items.DELETE("/:id", itemHandler.DeleteItem)
+		}
+
+		// Upload endpoints
+		uploadService, err := s3.NewService(context.Background(), logger)
+		if err != nil {
+			logger.Error("uploads disabled: failed to initialize S3", slog.String("error", err.Error()))
+		} else {
+			uploadHandler := s3.NewHandler(uploadService, logger)
+			uploads := api.Group("/uploads")
+			uploads.POST("/presigned-url", uploadHandler.PresignUpload)
 		}
 	}
 
@@ -52,7 +80,10 @@ func SetupRoutes(db *sqlx.DB, logger *slog.Logger) *gin.Engine {
 func corsMiddleware() gin.HandlerFunc {
 	config := cors.DefaultConfig()
 	config.AllowOrigins = []string{"*"}
-	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
+	if origins := os.Getenv("CORS_ALLOWED_ORIGINS"); origins != "" {
+		config.AllowOrigins = strings.Split(origins, ",")
+	}
+	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
 	config.AllowHeaders = []string{"Content-Type", "Authorization"}
 	return cors.New(config)
 }
\ No newline at end of file
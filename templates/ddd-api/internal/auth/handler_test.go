@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newRouter(email, password string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	router := gin.New()
+	api := router.Group("/api")
+	api.POST("/auth/login", NewHandler("secret", email, password, logger).Login)
+	api.Use(Middleware("secret", logger))
+	api.GET("/me", func(c *gin.Context) {
+		c.JSON(200, gin.H{"user_id": c.GetString(ContextUserIDKey)})
+	})
+	return router
+}
+
+func login(router *gin.Engine, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestLoginIssuesTokenForProtectedRoutes(t *testing.T) {
+	router := newRouter("admin@example.com", "hunter2")
+
+	w := login(router, `{"email": "admin@example.com", "password": "hunter2"}`)
+	if w.Code != 200 {
+		t.Fatalf("login: status %d: %s", w.Code, w.Body)
+	}
+	var resp LoginResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Token == "" || resp.User.Email != "admin@example.com" {
+		t.Fatalf("unexpected response: %s", w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+	req.Header.Set("Authorization", "Bearer "+resp.Token)
+	router.ServeHTTP(w, req)
+	if w.Code != 200 || !strings.Contains(w.Body.String(), "admin@example.com") {
+		t.Errorf("protected route with token: status %d: %s", w.Code, w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/me", nil))
+	if w.Code != 401 {
+		t.Errorf("protected route without token: status %d, want 401", w.Code)
+	}
+}
+
+func TestLoginRejectsBadCredentials(t *testing.T) {
+	tests := map[string]struct {
+		email, password string
+		body            string
+		want            int
+	}{
+		"wrong password":     {"admin@example.com", "hunter2", `{"email": "admin@example.com", "password": "nope"}`, 401},
+		"wrong email":        {"admin@example.com", "hunter2", `{"email": "other@example.com", "password": "hunter2"}`, 401},
+		"no account set up":  {"", "", `{"email": "admin@example.com", "password": "x"}`, 401},
+		"no password set up": {"admin@example.com", "", `{"email": "admin@example.com", "password": "x"}`, 401},
+		"invalid body":       {"admin@example.com", "hunter2", `{"email": "not-an-email"}`, 400},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if w := login(newRouter(tt.email, tt.password), tt.body); w.Code != tt.want {
+				t.Errorf("status %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ContextUserIDKey is the gin context key holding the authenticated subject
+const ContextUserIDKey = "user_id"
+
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+/**
+* Middleware rejects requests that don't carry a valid HS256 bearer token and
+* stores the token's subject in the request context.
+**/
+func Middleware(secret string, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		tokenStr, found := strings.CutPrefix(header, "Bearer ")
+		if !found || tokenStr == "" {
+			c.AbortWithStatusJSON(401, gin.H{"error": "Missing bearer token"})
+			return
+		}
+
+		subject, err := ParseToken(secret, tokenStr)
+		if err != nil {
+			logger.Debug("rejected token", slog.String("error", err.Error()))
+			c.AbortWithStatusJSON(401, gin.H{"error": "Invalid or expired token"})
+			return
+		}
+
+		c.Set(ContextUserIDKey, subject)
+		c.Next()
+	}
+}
+
+// GenerateToken signs a token for subject that expires after ttl
+func GenerateToken(secret, subject string, ttl time.Duration) (string, error) {
+	claims := jwt.RegisteredClaims{
+		Subject:   subject,
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		return "", fmt.Errorf("signing token: %w", err)
+	}
+
+	return token, nil
+}
+
+// ParseToken validates a token and returns its subject
+func ParseToken(secret, tokenStr string) (string, error) {
+	claims := &jwt.RegisteredClaims{}
+	_, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	return claims.Subject, nil
+}
@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+func NewRedis(logger *slog.Logger) (*redis.Client, error) {
+	host := os.Getenv("REDIS_HOST")
+	if host == "" {
+		host = "localhost"
+	}
+
+	port := os.Getenv("REDIS_PORT")
+	if port == "" {
+		port = "6379"
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     fmt.Sprintf("%s:%s", host, port),
+		Password: os.Getenv("REDIS_PASSWORD"),
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("connecting to redis: %w", err)
+	}
+
+	logger.Info("connected to redis",
+		slog.String("host", host),
+		slog.String("port", port))
+
+	return client, nil
+}
+
+func HealthCheck(client *redis.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := client.Ping(c.Request.Context()).Err(); err != nil {
+			c.JSON(503, gin.H{"status": "unavailable", "error": err.Error()})
+			return
+		}
+		c.JSON(200, gin.H{"status": "ok"})
+	}
+}
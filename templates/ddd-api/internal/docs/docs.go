@@ -0,0 +1,40 @@
+// Package docs serves the API's OpenAPI document and a page to browse it.
+// openapi.json is written by go-gen from the routes, handlers and models;
+// describe routes added by hand there too.
+package docs
+
+import (
+	_ "embed"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed openapi.json
+var spec []byte
+
+const page = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>API docs</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
+
+// Register serves the document at /openapi.json and the docs page at /docs
+func Register(router gin.IRoutes) {
+	router.GET("/openapi.json", func(c *gin.Context) {
+		c.Data(200, "application/json", spec)
+	})
+	router.GET("/docs", func(c *gin.Context) {
+		c.Data(200, "text/html; charset=utf-8", []byte(page))
+	})
+}
@@ -6,7 +6,7 @@ import (
 	"log/slog"
 	"strconv"
 
-	"github.com/darkphotonKN/go-template-generator/internal/utils/errorutils"
+	"github.com/darkphotonKN/go-template-generator/templates/ddd-api/internal/utils/errorutils"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -48,6 +48,8 @@ func (h *Handler) CreateItem(c *gin.Context) {
 			c.JSON(400, gin.H{"error": err.Error()})
 		case errors.Is(err, errorutils.ErrConstraintViolation):
 			c.JSON(400, gin.H{"error": "Invalid data provided"})
+		case errors.Is(err, errorutils.ErrForeignKeyViolation):
+			c.JSON(422, gin.H{"error": "Referenced resource does not exist"})
 		default:
 			h.logger.Error("failed to create item", slog.String("error", err.Error()))
 			c.JSON(500, gin.H{"error": "Failed to create item"})
@@ -141,11 +143,13 @@ func (h *Handler) UpdateItem(c *gin.Context) {
 		case errors.Is(err, errorutils.ErrNotFound):
 			c.JSON(404, gin.H{"error": "Item not found"})
 		case errors.Is(err, errorutils.ErrDuplicateResource):
-			c.JSON(409, gin.H{"error": "Item with that name already exists"})
+			c.JSON(409, gin.H{"error": "Item already exists"})
 		case errors.Is(err, errorutils.ErrInvalidInput):
 			c.JSON(400, gin.H{"error": err.Error()})
 		case errors.Is(err, errorutils.ErrConstraintViolation):
 			c.JSON(400, gin.H{"error": "Invalid data provided"})
+		case errors.Is(err, errorutils.ErrForeignKeyViolation):
+			c.JSON(422, gin.H{"error": "Referenced resource does not exist"})
 		default:
 			h.logger.Error("failed to update item", slog.String("error", err.Error()))
 			c.JSON(500, gin.H{"error": "Failed to update item"})
@@ -170,9 +174,12 @@ func (h *Handler) DeleteItem(c *gin.Context) {
 	}
 
 	if err := h.service.DeleteItem(c.Request.Context(), id); err != nil {
-		if errors.Is(err, errorutils.ErrNotFound) {
+		switch {
+		case errors.Is(err, errorutils.ErrNotFound):
 			c.JSON(404, gin.H{"error": "Item not found"})
-		} else {
+		case errors.Is(err, errorutils.ErrForeignKeyViolation):
+			c.JSON(409, gin.H{"error": "Item is still referenced"})
+		default:
 			h.logger.Error("failed to delete item",
 				slog.String("error", err.Error()),
 				slog.String("id", id.String()))
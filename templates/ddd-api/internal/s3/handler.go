@@ -0,0 +1,40 @@
+package s3
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+	logger  *slog.Logger
+}
+
+type Service interface {
+	PresignUpload(ctx context.Context, filename, contentType string) (*PresignedUpload, error)
+}
+
+func NewHandler(service Service, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) PresignUpload(c *gin.Context) {
+	var req PresignUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	upload, err := h.service.PresignUpload(c.Request.Context(), req.Filename, req.ContentType)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to create upload URL"})
+		return
+	}
+
+	c.JSON(201, upload)
+}
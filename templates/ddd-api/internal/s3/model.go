@@ -0,0 +1,14 @@
+package s3
+
+import "time"
+
+type PresignUploadRequest struct {
+	Filename    string `json:"filename" binding:"required"`
+	ContentType string `json:"content_type" binding:"required"`
+}
+
+type PresignedUpload struct {
+	URL       string    `json:"url"`
+	Key       string    `json:"key"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,60 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	awss3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/google/uuid"
+)
+
+type service struct {
+	presign *awss3.PresignClient
+	bucket  string
+	logger  *slog.Logger
+}
+
+func NewService(ctx context.Context, logger *slog.Logger) (*service, error) {
+	bucket := os.Getenv("S3_BUCKET")
+	if bucket == "" {
+		return nil, fmt.Errorf("S3_BUCKET environment variable is required")
+	}
+
+	cfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("loading AWS config: %w", err)
+	}
+
+	return &service{
+		presign: awss3.NewPresignClient(awss3.NewFromConfig(cfg)),
+		bucket:  bucket,
+		logger:  logger,
+	}, nil
+}
+
+func (s *service) PresignUpload(ctx context.Context, filename, contentType string) (*PresignedUpload, error) {
+	key := path.Join("uploads", uuid.New().String()+path.Ext(filename))
+	expires := 15 * time.Minute
+
+	req, err := s.presign.PresignPutObject(ctx, &awss3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+	}, awss3.WithPresignExpires(expires))
+	if err != nil {
+		s.logger.Error("failed to presign upload", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("failed to presign upload: %w", err)
+	}
+
+	return &PresignedUpload{
+		URL:       req.URL,
+		Key:       key,
+		ExpiresAt: time.Now().Add(expires),
+	}, nil
+}
@@ -17,6 +17,9 @@ func AnalyzeDBErr(err error) error {
 	if IsDuplicateError(err) {
 		return ErrDuplicateResource
 	}
+	if IsForeignKeyViolation(err) {
+		return ErrForeignKeyViolation
+	}
 	if IsConstraintViolation(err) {
 		return ErrConstraintViolation
 	}
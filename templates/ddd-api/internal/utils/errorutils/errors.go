@@ -11,6 +11,7 @@ var (
 	ErrInvalidInput        = errors.New("Invalid input.")
 	ErrDuplicateResource   = errors.New("Resource already exists.")
 	ErrConstraintViolation = errors.New("Input does not follow column constraints.")
+	ErrForeignKeyViolation = errors.New("Referenced resource does not exist or is still referenced.")
 	ErrForbidden           = errors.New("You do not have permission to access this resource.")
 	ErrUnauthorized        = errors.New("Incorrect credentials entered during when attempting to authenticate.")
 )
\ No newline at end of file
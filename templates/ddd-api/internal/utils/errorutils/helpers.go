@@ -23,4 +23,15 @@ func IsConstraintViolation(err error) bool {
 		return false
 	}
 	return strings.Contains(err.Error(), "violates check constraint")
+}
+
+/**
+* Helper function to determine if an error is from a foreign key: a reference to
+* a missing row, or deleting a row that is still referenced.
+**/
+func IsForeignKeyViolation(err error) bool {
+	if err == nil {
+		return false
+	}
+	return strings.Contains(err.Error(), "violates foreign key constraint")
 }
\ No newline at end of file
@@ -0,0 +1,11 @@
+// Package templates bundles the project templates into the go-gen binary.
+//
+// Each top-level directory is one template. Template directories keep their
+// go.mod as go.mod.tmpl because the embed package cannot include files that
+// belong to a nested module; the generator renames it back on output.
+package templates
+
+import "embed"
+
+//go:embed all:ddd-api all:nextjs-frontend
+var FS embed.FS
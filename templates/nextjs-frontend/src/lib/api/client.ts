@@ -1,7 +1,8 @@
 import axios, { AxiosError, InternalAxiosRequestConfig } from "axios";
 import { useAuthStore } from "@/stores/auth.store";
+import { API_ENDPOINTS } from "@/lib/api/endpoints";
 
-const API_URL = process.env.NEXT_PUBLIC_API_URL || "http://localhost:8000";
+const API_URL = process.env.NEXT_PUBLIC_API_URL || "http://localhost:8000/api";
 
 export const apiClient = axios.create({
   baseURL: API_URL,
@@ -29,8 +30,8 @@ apiClient.interceptors.request.use(
 apiClient.interceptors.response.use(
   (response) => response,
   async (error: AxiosError) => {
-    if (error.response?.status === 401) {
-      // Handle unauthorized access
+    if (error.response?.status === 401 && error.config?.url !== API_ENDPOINTS.AUTH.LOGIN) {
+      // Handle unauthorized access; a failed login is the login page's to show
       useAuthStore.getState().logout();
       if (typeof window !== "undefined") {
         window.location.href = "/login";
@@ -19,8 +19,6 @@ export const API_ENDPOINTS = {
 
   // Upload endpoints
   UPLOAD: {
-    IMAGE: "/upload/image",
-    FILE: "/upload/file",
-    PRESIGNED_URL: "/upload/presigned-url",
+    PRESIGNED_URL: "/uploads/presigned-url",
   },
 } as const;
\ No newline at end of file